@@ -0,0 +1,153 @@
+// Package batch generates a code for every row of a table and returns them
+// as a ZIP archive of PNG images, a PDF sheet or an XLSX workbook.
+package batch
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/dataset"
+	"qr-code-generator/payloads"
+	"qr-code-generator/qrcode"
+)
+
+type Output string
+
+const (
+	ZIP  Output = "zip"
+	PDF  Output = "pdf"
+	XLSX Output = "xlsx"
+)
+
+func ParseOutput(name string) (Output, error) {
+	switch Output(name) {
+	case "", ZIP:
+		return ZIP, nil
+	case PDF, XLSX:
+		return Output(name), nil
+	}
+	return "", fmt.Errorf("output must be zip, pdf or xlsx, got %q", name)
+}
+
+func (output Output) ContentType() string {
+	switch output {
+	case PDF:
+		return "application/pdf"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/zip"
+}
+
+type Options struct {
+	// Fields are the fields of /generate, such as type, content or ssid.
+	// Their values may refer to the columns of a row as {column}.
+	Fields url.Values
+	// Filename names the images in a ZIP archive, with {row} for the row
+	// number and {column} for values. It defaults to code-{row}.
+	Filename string
+	// Label is printed under each code on PDF sheets.
+	Label     string
+	Size      int
+	Symbology qrcode.Symbology
+	// Columns is the number of codes across a PDF sheet, four by default.
+	Columns int
+}
+
+// Code is the code generated for one row of the table.
+type Code struct {
+	Filename string
+	Label    string
+	Symbol   *qrcode.SimpleQRCode
+}
+
+// rowForm gives the fields of a batch with the values of one row filled in.
+type rowForm struct {
+	fields url.Values
+	row    dataset.Row
+}
+
+func (form rowForm) Get(name string) string {
+	return form.row.Expand(form.fields.Get(name))
+}
+
+var unsafeFilename = regexp.MustCompile(`[^\pL\pN._\- ]+`)
+
+// Generate builds the code of every row, so that a row whose content is not
+// valid fails the batch before anything is written.
+func Generate(table *dataset.Table, options Options) ([]Code, error) {
+	filename := options.Filename
+	if filename == "" {
+		filename = "code-{row}"
+	}
+
+	var codes []Code
+	used := map[string]bool{}
+	for i, row := range table.Rows {
+		content, err := payloads.Content(rowForm{options.Fields, row})
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i+1, err)
+		}
+		if content == "" {
+			return nil, fmt.Errorf("row %d: the content is empty", i+1)
+		}
+		symbol := &qrcode.SimpleQRCode{Content: content, Size: options.Size, Symbology: options.Symbology}
+		if _, err := symbol.Modules(); err != nil {
+			return nil, fmt.Errorf("row %d: %v", i+1, err)
+		}
+
+		name := strings.ReplaceAll(filename, "{row}", strconv.Itoa(i+1))
+		name = strings.TrimSpace(unsafeFilename.ReplaceAllString(row.Expand(name), "_"))
+		if name == "" || used[name] {
+			name = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		used[name] = true
+
+		codes = append(codes, Code{
+			Filename: name,
+			Label:    row.Expand(strings.ReplaceAll(options.Label, "{row}", strconv.Itoa(i+1))),
+			Symbol:   symbol,
+		})
+	}
+	return codes, nil
+}
+
+// Write renders the codes of a table in the given output format.
+func Write(output Output, table *dataset.Table, codes []Code, options Options) ([]byte, error) {
+	switch output {
+	case PDF:
+		columns := options.Columns
+		if columns == 0 {
+			columns = 4
+		}
+		return sheet(codes, columns)
+	case XLSX:
+		return workbook(table, codes)
+	}
+	return archive(codes)
+}
+
+func archive(codes []Code) ([]byte, error) {
+	buffer := bytes.NewBuffer(nil)
+	archive := zip.NewWriter(buffer)
+	for _, code := range codes {
+		image, err := code.Symbol.Generate()
+		if err != nil {
+			return nil, err
+		}
+		entry, err := archive.Create(code.Filename + ".png")
+		if err != nil {
+			return nil, err
+		}
+		entry.Write(image)
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,102 @@
+package batch
+
+import (
+	"fmt"
+
+	"qr-code-generator/caption"
+	"qr-code-generator/pdf"
+)
+
+// Sheets are A4 pages in millimetres, with the codes in a grid of cells and
+// up to two lines of label under each code.
+const (
+	pageWidth  = 210.0
+	pageHeight = 297.0
+	margin     = 10.0
+	gutter     = 5.0
+	labelSize  = 8.0
+	labelLines = 2
+)
+
+func sheet(codes []Code, columns int) ([]byte, error) {
+	if columns < 1 || columns > 10 {
+		return nil, fmt.Errorf("a sheet has 1 to 10 columns, got %d", columns)
+	}
+
+	labelHeight := 0.0
+	for _, code := range codes {
+		if code.Label != "" {
+			labelHeight = labelLines * labelSize * 1.2 / pdf.MM
+		}
+	}
+	cellWidth := (pageWidth - 2*margin) / float64(columns)
+	codeSize := cellWidth - gutter
+	cellHeight := codeSize + labelHeight + gutter
+	rows := int((pageHeight - 2*margin) / cellHeight)
+	perPage := rows * columns
+
+	document := pdf.New()
+	var page *pdf.Page
+	for i, code := range codes {
+		if i%perPage == 0 {
+			page = document.AddPage(pageWidth*pdf.MM, pageHeight*pdf.MM)
+		}
+		position := i % perPage
+		x := margin + float64(position%columns)*cellWidth + gutter/2
+		top := pageHeight - margin - float64(position/columns)*cellHeight - gutter/2
+
+		modules, err := code.Symbol.Modules()
+		if err != nil {
+			return nil, err
+		}
+		drawModules(page, modules, code.Symbol.Symbology.QuietZone(), x*pdf.MM, top*pdf.MM, codeSize*pdf.MM)
+
+		if !pdf.WinAnsi(code.Label) {
+			// Labels in other scripts are shaped and drawn as outlines,
+			// since the standard fonts only cover Western European text.
+			label, err := caption.New(code.Label, labelSize, codeSize*pdf.MM)
+			if err != nil {
+				return nil, fmt.Errorf("could not set the label %q: %v", code.Label, err)
+			}
+			label.Limit(labelLines)
+			label.DrawPDF(page, (x+codeSize/2)*pdf.MM, (top-codeSize)*pdf.MM)
+			continue
+		}
+		lines := pdf.WrapText(pdf.Helvetica, labelSize, codeSize*pdf.MM, code.Label)
+		if len(lines) > labelLines {
+			lines = lines[:labelLines]
+		}
+		for j, line := range lines {
+			width := pdf.TextWidth(pdf.Helvetica, labelSize, line)
+			baseline := (top-codeSize)*pdf.MM - float64(j+1)*labelSize*1.2
+			page.Text((x+codeSize/2)*pdf.MM-width/2, baseline, pdf.Helvetica, labelSize, line)
+		}
+	}
+	return document.Bytes()
+}
+
+// drawModules draws a symbol and its quiet zone in a square of the given
+// size hanging from top, centred across it when the symbol is wider than
+// it is tall or the other way round.
+func drawModules(page *pdf.Page, modules [][]bool, quietZone int, x, top, size float64) {
+	width, height := len(modules[0])+2*quietZone, len(modules)+2*quietZone
+	module := size / float64(max(width, height))
+	x += (size - float64(width)*module) / 2
+
+	page.SetFillColor(0, 0, 0)
+	for row, line := range modules {
+		y := top - float64(quietZone+row+1)*module
+		// Runs of dark modules are drawn as one rectangle.
+		for col := 0; col < len(line); col++ {
+			if !line[col] {
+				continue
+			}
+			start := col
+			for col < len(line) && line[col] {
+				col++
+			}
+			page.Rect(x+float64(quietZone+start)*module, y, float64(col-start)*module, module)
+		}
+	}
+	page.Fill()
+}
@@ -0,0 +1,193 @@
+package batch
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/png"
+	"strings"
+
+	"qr-code-generator/dataset"
+)
+
+// Images are placed in EMUs, English Metric Units, of which there are 9525
+// to a pixel at 96 DPI.
+const emuPerPixel = 9525
+
+const (
+	spreadsheetNamespace  = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	relationshipNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	packageRelationships  = "http://schemas.openxmlformats.org/package/2006/relationships"
+)
+
+var workbookParts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Default Extension="png" ContentType="image/png"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/drawings/drawing1.xml" ContentType="application/vnd.openxmlformats-officedocument.drawing+xml"/>` +
+		`</Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="` + packageRelationships + `">` +
+		`<Relationship Id="rId1" Type="` + relationshipNamespace + `/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`,
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="` + spreadsheetNamespace + `" xmlns:r="` + relationshipNamespace + `">` +
+		`<sheets><sheet name="QR codes" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="` + packageRelationships + `">` +
+		`<Relationship Id="rId1" Type="` + relationshipNamespace + `/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="` + relationshipNamespace + `/styles" Target="styles.xml"/>` +
+		`</Relationships>`,
+	"xl/worksheets/_rels/sheet1.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="` + packageRelationships + `">` +
+		`<Relationship Id="rId1" Type="` + relationshipNamespace + `/drawing" Target="../drawings/drawing1.xml"/>` +
+		`</Relationships>`,
+	// Cell format 1 is the bold header and 2 centres data vertically
+	// against the images.
+	"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="` + spreadsheetNamespace + `">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1"><alignment vertical="center"/></xf>` +
+		`</cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`,
+}
+
+// workbook writes the table to a single sheet, with each row's code as an
+// image in a new column after the data. Values are written as text, so that
+// identifiers keep their leading zeros.
+func workbook(table *dataset.Table, codes []Code) ([]byte, error) {
+	images := make([][]byte, len(codes))
+	bounds := make([]image.Point, len(codes))
+	widest, tallest := 0, 0
+	for i, code := range codes {
+		data, err := code.Symbol.Generate()
+		if err != nil {
+			return nil, err
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("could not read the code image: %v", err)
+		}
+		images[i] = data
+		bounds[i] = image.Pt(config.Width, config.Height)
+		widest, tallest = max(widest, config.Width), max(tallest, config.Height)
+	}
+
+	column := len(table.Columns)
+	taken := map[string]bool{}
+	for _, name := range table.Columns {
+		taken[name] = true
+	}
+	heading := "QR code"
+	for i := 2; taken[heading]; i++ {
+		heading = fmt.Sprintf("QR code %d", i)
+	}
+
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	fmt.Fprintf(&sheet, `<worksheet xmlns="%s" xmlns:r="%s">`, spreadsheetNamespace, relationshipNamespace)
+	// Column widths are in characters of about seven pixels.
+	fmt.Fprintf(&sheet, `<cols><col min="%d" max="%d" width="%.2f" customWidth="1"/></cols>`, column+1, column+1, float64(widest+8)/7)
+	sheet.WriteString(`<sheetData>`)
+	writeRow(&sheet, 1, 1, 0, append(append([]string{}, table.Columns...), heading))
+	for i, row := range table.Rows {
+		values := make([]string, len(table.Columns))
+		for j, name := range table.Columns {
+			values[j] = row[name]
+		}
+		// Row heights are in points, three quarters of a pixel.
+		writeRow(&sheet, i+2, 2, float64(tallest+8)*0.75, values)
+	}
+	sheet.WriteString(`</sheetData><drawing r:id="rId1"/></worksheet>`)
+
+	var drawing, relationships strings.Builder
+	drawing.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	fmt.Fprintf(&drawing, `<xdr:wsDr xmlns:xdr="http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="%s">`, relationshipNamespace)
+	relationships.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	fmt.Fprintf(&relationships, `<Relationships xmlns="%s">`, packageRelationships)
+	for i, code := range codes {
+		width, height := bounds[i].X*emuPerPixel, bounds[i].Y*emuPerPixel
+		fmt.Fprintf(&drawing, `<xdr:oneCellAnchor><xdr:from><xdr:col>%d</xdr:col><xdr:colOff>%d</xdr:colOff><xdr:row>%d</xdr:row><xdr:rowOff>%d</xdr:rowOff></xdr:from>`,
+			column, 4*emuPerPixel, i+1, 4*emuPerPixel)
+		fmt.Fprintf(&drawing, `<xdr:ext cx="%d" cy="%d"/><xdr:pic>`, width, height)
+		fmt.Fprintf(&drawing, `<xdr:nvPicPr><xdr:cNvPr id="%d" name="QR code %d" descr="%s"/><xdr:cNvPicPr><a:picLocks noChangeAspect="1"/></xdr:cNvPicPr></xdr:nvPicPr>`,
+			i+2, i+1, escapeXML(code.Symbol.Content))
+		fmt.Fprintf(&drawing, `<xdr:blipFill><a:blip r:embed="rId%d"/><a:stretch><a:fillRect/></a:stretch></xdr:blipFill>`, i+1)
+		fmt.Fprintf(&drawing, `<xdr:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></xdr:spPr>`, width, height)
+		drawing.WriteString(`</xdr:pic><xdr:clientData/></xdr:oneCellAnchor>`)
+		fmt.Fprintf(&relationships, `<Relationship Id="rId%d" Type="%s/image" Target="../media/image%d.png"/>`, i+1, relationshipNamespace, i+1)
+	}
+	drawing.WriteString(`</xdr:wsDr>`)
+	relationships.WriteString(`</Relationships>`)
+
+	buffer := bytes.NewBuffer(nil)
+	archive := zip.NewWriter(buffer)
+	add := func(name string, data []byte) error {
+		entry, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = entry.Write(data)
+		return err
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/_rels/sheet1.xml.rels"} {
+		if err := add(name, []byte(workbookParts[name])); err != nil {
+			return nil, err
+		}
+	}
+	if err := add("xl/worksheets/sheet1.xml", []byte(sheet.String())); err != nil {
+		return nil, err
+	}
+	if err := add("xl/drawings/drawing1.xml", []byte(drawing.String())); err != nil {
+		return nil, err
+	}
+	if err := add("xl/drawings/_rels/drawing1.xml.rels", []byte(relationships.String())); err != nil {
+		return nil, err
+	}
+	for i, data := range images {
+		if err := add(fmt.Sprintf("xl/media/image%d.png", i+1), data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeRow(sheet *strings.Builder, number, style int, height float64, values []string) {
+	if height > 0 {
+		fmt.Fprintf(sheet, `<row r="%d" ht="%.2f" customHeight="1">`, number, height)
+	} else {
+		fmt.Fprintf(sheet, `<row r="%d">`, number)
+	}
+	for i, value := range values {
+		if value == "" {
+			continue
+		}
+		fmt.Fprintf(sheet, `<c r="%s%d" t="inlineStr" s="%d"><is><t xml:space="preserve">%s</t></is></c>`,
+			dataset.ColumnName(i), number, style, escapeXML(value))
+	}
+	sheet.WriteString(`</row>`)
+}
+
+func escapeXML(text string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
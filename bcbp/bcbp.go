@@ -0,0 +1,161 @@
+package bcbp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BoardingPass follows IATA Resolution 792 (Bar Coded Boarding Pass),
+// version 6 and later. Fields are strings in the form they take on the pass;
+// shorter values are padded to the field length.
+type BoardingPass struct {
+	PassengerName    string `json:"passenger_name"`
+	ElectronicTicket bool   `json:"electronic_ticket"`
+	Legs             []Leg  `json:"legs"`
+
+	Version              string   `json:"version,omitempty"`
+	PassengerDescription string   `json:"passenger_description,omitempty"`
+	CheckInSource        string   `json:"check_in_source,omitempty"`
+	IssuanceSource       string   `json:"issuance_source,omitempty"`
+	IssueDate            string   `json:"issue_date,omitempty"`
+	DocumentType         string   `json:"document_type,omitempty"`
+	Issuer               string   `json:"issuer,omitempty"`
+	BaggageTags          []string `json:"baggage_tags,omitempty"`
+
+	Security *SecurityData `json:"security,omitempty"`
+}
+
+type Leg struct {
+	PNR             string `json:"pnr"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Carrier         string `json:"carrier"`
+	FlightNumber    string `json:"flight_number"`
+	FlightDate      string `json:"flight_date"`
+	Compartment     string `json:"compartment"`
+	Seat            string `json:"seat"`
+	CheckInSequence string `json:"check_in_sequence"`
+	PassengerStatus string `json:"passenger_status"`
+
+	AirlineNumericCode   string `json:"airline_numeric_code,omitempty"`
+	DocumentSerial       string `json:"document_serial,omitempty"`
+	Selectee             string `json:"selectee,omitempty"`
+	DocumentVerification string `json:"document_verification,omitempty"`
+	MarketingCarrier     string `json:"marketing_carrier,omitempty"`
+	FrequentFlyerAirline string `json:"frequent_flyer_airline,omitempty"`
+	FrequentFlyerNumber  string `json:"frequent_flyer_number,omitempty"`
+	IDADIndicator        string `json:"id_ad_indicator,omitempty"`
+	FreeBaggageAllowance string `json:"free_baggage_allowance,omitempty"`
+	FastTrack            string `json:"fast_track,omitempty"`
+	AirlineUse           string `json:"airline_use,omitempty"`
+}
+
+type SecurityData struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+const (
+	formatCode    = "M"
+	maxLegs       = 4
+	maxSecurity   = 0xff
+	latestVersion = "8"
+)
+
+type kind int
+
+const (
+	letters kind = iota
+	digits
+	alphanumeric
+)
+
+type field struct {
+	name   string
+	length int
+	kind   kind
+	value  *string
+}
+
+func (leg *Leg) mandatoryFields() []field {
+	return []field{
+		{"operating carrier PNR code", 7, alphanumeric, &leg.PNR},
+		{"from city airport code", 3, letters, &leg.From},
+		{"to city airport code", 3, letters, &leg.To},
+		{"operating carrier designator", 3, alphanumeric, &leg.Carrier},
+		{"flight number", 5, alphanumeric, &leg.FlightNumber},
+		{"date of flight", 3, digits, &leg.FlightDate},
+		{"compartment code", 1, letters, &leg.Compartment},
+		{"seat number", 4, alphanumeric, &leg.Seat},
+		{"check-in sequence number", 5, alphanumeric, &leg.CheckInSequence},
+		{"passenger status", 1, alphanumeric, &leg.PassengerStatus},
+	}
+}
+
+func (leg *Leg) conditionalFields() []field {
+	return []field{
+		{"airline numeric code", 3, digits, &leg.AirlineNumericCode},
+		{"document form/serial number", 10, alphanumeric, &leg.DocumentSerial},
+		{"selectee indicator", 1, alphanumeric, &leg.Selectee},
+		{"international documentation verification", 1, alphanumeric, &leg.DocumentVerification},
+		{"marketing carrier designator", 3, alphanumeric, &leg.MarketingCarrier},
+		{"frequent flyer airline designator", 3, alphanumeric, &leg.FrequentFlyerAirline},
+		{"frequent flyer number", 16, alphanumeric, &leg.FrequentFlyerNumber},
+		{"ID/AD indicator", 1, alphanumeric, &leg.IDADIndicator},
+		{"free baggage allowance", 3, alphanumeric, &leg.FreeBaggageAllowance},
+		{"fast track", 1, letters, &leg.FastTrack},
+	}
+}
+
+func (pass *BoardingPass) conditionalFields(tags *[3]string) []field {
+	return []field{
+		{"passenger description", 1, alphanumeric, &pass.PassengerDescription},
+		{"source of check-in", 1, alphanumeric, &pass.CheckInSource},
+		{"source of boarding pass issuance", 1, alphanumeric, &pass.IssuanceSource},
+		{"date of issue of boarding pass", 4, digits, &pass.IssueDate},
+		{"document type", 1, letters, &pass.DocumentType},
+		{"airline designator of boarding pass issuer", 3, alphanumeric, &pass.Issuer},
+		{"baggage tag licence plate number", 13, digits, &tags[0]},
+		{"first non-consecutive baggage tag licence plate number", 13, digits, &tags[1]},
+		{"second non-consecutive baggage tag licence plate number", 13, digits, &tags[2]},
+	}
+}
+
+func (f field) validate() error {
+	value := strings.TrimRight(*f.value, " ")
+	if len(value) > f.length {
+		return fmt.Errorf("%s must be at most %d characters, got %q", f.name, f.length, value)
+	}
+	for _, char := range value {
+		if char < 0x20 || char > 0x7e {
+			return fmt.Errorf("%s contains the character %q, which is not printable ASCII", f.name, char)
+		}
+		var ok bool
+		switch f.kind {
+		case letters:
+			ok = (char >= 'A' && char <= 'Z') || char == ' '
+		case digits:
+			ok = (char >= '0' && char <= '9') || char == ' '
+		case alphanumeric:
+			ok = (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') || char == ' '
+		default:
+			ok = true
+		}
+		if !ok {
+			return fmt.Errorf("%s contains the character %q, which is not permitted", f.name, char)
+		}
+	}
+	return nil
+}
+
+// JulianDate returns the day of the year as used for the date of flight.
+func JulianDate(date time.Time) string {
+	return fmt.Sprintf("%03d", date.YearDay())
+}
+
+// IssueDate returns the last digit of the year followed by the day of the
+// year, as used for the date of issue of the boarding pass.
+func IssueDate(date time.Time) string {
+	return fmt.Sprintf("%d%03d", date.Year()%10, date.YearDay())
+}
@@ -0,0 +1,47 @@
+package bcbp
+
+import "testing"
+
+// The examples are from IATA Resolution 792: a pass of mandatory items
+// alone, and two legs with conditional items and security data.
+var examples = []string{
+	"M1DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J001A0025 100",
+	"M2DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J001A0025 14D>6181WW6225BAC 00141234560032A0141234567890 1AC AC 1234567890123    20KYLX58ZDEF456 FRAGVALH 3664 327C012C0002 12E2A0140987654321 1AC AC 1234567890123    2PCNWQ^164GIWVC5EH7JNT684FVNJ91W2QA4DVN5J8K4F0L0GEQ3DF5TGBN8709HKT5D3DW3GBHFCVHMY7J5T6HFR41W2QA4DVN5J8K4F0L0GE",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, example := range examples {
+		pass, err := Parse(example)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", example, err)
+			continue
+		}
+		encoded, err := pass.Encode()
+		if err != nil {
+			t.Errorf("%q does not encode: %v", example, err)
+			continue
+		}
+		if encoded != example {
+			t.Errorf("%q encodes back to\n%q", example, encoded)
+		}
+	}
+}
+
+func TestEncodeConditionalItems(t *testing.T) {
+	pass, err := Parse(examples[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass.Legs[0].FrequentFlyerNumber = "1234567890"
+	encoded, err := pass.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(encoded)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", encoded, err)
+	}
+	if parsed.Version != latestVersion || parsed.Legs[0].FrequentFlyerNumber != "1234567890" {
+		t.Errorf("%q parses to version %q and frequent flyer number %q", encoded, parsed.Version, parsed.Legs[0].FrequentFlyerNumber)
+	}
+}
@@ -0,0 +1,180 @@
+package bcbp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	namePattern         = regexp.MustCompile(`^[A-Z][A-Z '\-]*/?[A-Z '\-]*$`)
+	flightNumberPattern = regexp.MustCompile(`^\d{1,4}[A-Z ]?$`)
+	seatPattern         = regexp.MustCompile(`^(\d{1,3}[A-Z]|INF|GATE|STBY)$`)
+	sequencePattern     = regexp.MustCompile(`^(\d{1,4}[A-Z ]?|INF)$`)
+)
+
+func (pass *BoardingPass) Validate() error {
+	name := strings.TrimRight(pass.PassengerName, " ")
+	if name == "" || len(name) > 20 || !namePattern.MatchString(name) {
+		return fmt.Errorf("passenger name must be SURNAME/GIVEN NAMES in capitals, at most 20 characters")
+	}
+	if len(pass.Legs) == 0 || len(pass.Legs) > maxLegs {
+		return fmt.Errorf("a boarding pass must have between 1 and %d legs, got %d", maxLegs, len(pass.Legs))
+	}
+	if pass.Version != "" && (len(pass.Version) != 1 || pass.Version < "1" || pass.Version > "9") {
+		return fmt.Errorf("version must be a single digit, got %q", pass.Version)
+	}
+	if len(pass.BaggageTags) > 3 {
+		return fmt.Errorf("at most three baggage tag licence plate numbers are allowed")
+	}
+
+	for _, f := range pass.conditionalFields(pass.baggageTags()) {
+		if err := f.validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range pass.Legs {
+		leg := &pass.Legs[i]
+		for _, f := range append(leg.mandatoryFields(), leg.conditionalFields()...) {
+			if err := f.validate(); err != nil {
+				return fmt.Errorf("leg %d: %v", i+1, err)
+			}
+		}
+		for _, f := range leg.mandatoryFields() {
+			if strings.TrimSpace(*f.value) == "" {
+				return fmt.Errorf("leg %d: %s is required", i+1, f.name)
+			}
+		}
+		if len(leg.From) != 3 || len(leg.To) != 3 {
+			return fmt.Errorf("leg %d: airport codes must be three letters", i+1)
+		}
+		if !flightNumberPattern.MatchString(strings.TrimSpace(leg.FlightNumber)) {
+			return fmt.Errorf("leg %d: flight number must be up to four digits and an optional suffix", i+1)
+		}
+		if day, err := strconv.Atoi(leg.FlightDate); err != nil || day < 1 || day > 366 {
+			return fmt.Errorf("leg %d: date of flight must be a day of the year from 001 to 366", i+1)
+		}
+		if !seatPattern.MatchString(strings.TrimSpace(leg.Seat)) {
+			return fmt.Errorf("leg %d: seat number must be a row and a letter, such as 012C", i+1)
+		}
+		if !sequencePattern.MatchString(strings.TrimSpace(leg.CheckInSequence)) {
+			return fmt.Errorf("leg %d: check-in sequence number must be up to four digits", i+1)
+		}
+		if len(leg.AirlineUse) > 0xff {
+			return fmt.Errorf("leg %d: airline use data must be at most 255 characters", i+1)
+		}
+	}
+
+	if pass.Security != nil {
+		if len(pass.Security.Type) != 1 {
+			return fmt.Errorf("security data type must be a single character")
+		}
+		if len(pass.Security.Data) > maxSecurity {
+			return fmt.Errorf("security data must be at most %d characters", maxSecurity)
+		}
+	}
+
+	return nil
+}
+
+func (pass *BoardingPass) Encode() (string, error) {
+	if err := pass.Validate(); err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(formatCode)
+	fmt.Fprintf(&result, "%d", len(pass.Legs))
+	result.WriteString(pad(pass.PassengerName, 20))
+	if pass.ElectronicTicket {
+		result.WriteString("E")
+	} else {
+		result.WriteString(" ")
+	}
+
+	for i := range pass.Legs {
+		leg := &pass.Legs[i]
+		for _, f := range leg.mandatoryFields() {
+			result.WriteString(formatField(f))
+		}
+
+		var conditional strings.Builder
+		repeated := joinFields(leg.conditionalFields())
+		if i == 0 {
+			// A pass of mandatory items alone, such as one parsed from a
+			// pass without conditional items, has no version either.
+			unique := joinFields(pass.conditionalFields(pass.baggageTags()))
+			if pass.Version != "" || unique != "" || repeated != "" || leg.AirlineUse != "" {
+				version := pass.Version
+				if version == "" {
+					version = latestVersion
+				}
+				fmt.Fprintf(&conditional, ">%s%02X%s%02X%s", version, len(unique), unique, len(repeated), repeated)
+			}
+		} else if repeated != "" || leg.AirlineUse != "" {
+			fmt.Fprintf(&conditional, "%02X%s", len(repeated), repeated)
+		}
+		conditional.WriteString(leg.AirlineUse)
+
+		fmt.Fprintf(&result, "%02X%s", conditional.Len(), conditional.String())
+	}
+
+	if pass.Security != nil {
+		fmt.Fprintf(&result, "^%s%02X%s", pass.Security.Type, len(pass.Security.Data), pass.Security.Data)
+	}
+
+	return result.String(), nil
+}
+
+func (pass *BoardingPass) baggageTags() *[3]string {
+	var tags [3]string
+	copy(tags[:], pass.BaggageTags)
+	return &tags
+}
+
+// joinFields writes the fields up to the last one that has a value. Items
+// in the conditional sections may be left off the end, but not the middle.
+func joinFields(fields []field) string {
+	last := -1
+	for i, f := range fields {
+		if strings.TrimSpace(*f.value) != "" {
+			last = i
+		}
+	}
+
+	var result strings.Builder
+	for _, f := range fields[:last+1] {
+		result.WriteString(formatField(f))
+	}
+	return result.String()
+}
+
+func formatField(f field) string {
+	value := strings.TrimRight(*f.value, " ")
+	switch f.name {
+	case "flight number", "check-in sequence number":
+		return padNumber(value, 4, f.length)
+	case "seat number", "date of flight":
+		return padNumber(value, 3, f.length)
+	}
+	return pad(value, f.length)
+}
+
+// padNumber zero-pads the numeric part of values such as flight 123A, seat
+// 1C or day 45 to the given number of digits.
+func padNumber(value string, digits, length int) string {
+	numeric := strings.TrimRight(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ ")
+	if numeric == "" {
+		return pad(value, length)
+	}
+	return pad(strings.Repeat("0", digits-len(numeric))+value, length)
+}
+
+func pad(value string, length int) string {
+	if len(value) >= length {
+		return value[:length]
+	}
+	return value + strings.Repeat(" ", length-len(value))
+}
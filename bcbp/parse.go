@@ -0,0 +1,205 @@
+package bcbp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type reader struct {
+	data   string
+	offset int
+}
+
+func (r *reader) take(length int, name string) (string, error) {
+	if r.offset+length > len(r.data) {
+		return "", fmt.Errorf("boarding pass ends inside the %s at position %d", name, r.offset+1)
+	}
+	value := r.data[r.offset : r.offset+length]
+	r.offset += length
+	return value, nil
+}
+
+func (r *reader) size(name string) (int, error) {
+	value, err := r.take(2, name+" size")
+	if err != nil {
+		return 0, err
+	}
+	size, err := strconv.ParseUint(value, 16, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%s size %q is not a hexadecimal number", name, value)
+	}
+	return int(size), nil
+}
+
+// fields reads as many of the given fields as fit in the section; fields
+// may be left off the end of a conditional section.
+func readFields(fields []field, section string) error {
+	for _, f := range fields {
+		if len(section) == 0 {
+			return nil
+		}
+		if len(section) < f.length {
+			return fmt.Errorf("%s is truncated", f.name)
+		}
+		*f.value = strings.TrimRight(section[:f.length], " ")
+		section = section[f.length:]
+	}
+	if len(section) > 0 {
+		return fmt.Errorf("%d unexpected characters at the end of a conditional section", len(section))
+	}
+	return nil
+}
+
+func Parse(data string) (*BoardingPass, error) {
+	r := &reader{data: data}
+	pass := &BoardingPass{}
+
+	format, err := r.take(1, "format code")
+	if err != nil {
+		return nil, err
+	}
+	if format != formatCode {
+		return nil, fmt.Errorf("format code must be %s, got %q", formatCode, format)
+	}
+
+	legCount, err := r.take(1, "number of legs")
+	if err != nil {
+		return nil, err
+	}
+	legs, err := strconv.Atoi(legCount)
+	if err != nil || legs < 1 || legs > maxLegs {
+		return nil, fmt.Errorf("number of legs must be between 1 and %d, got %q", maxLegs, legCount)
+	}
+
+	name, err := r.take(20, "passenger name")
+	if err != nil {
+		return nil, err
+	}
+	pass.PassengerName = strings.TrimRight(name, " ")
+
+	ticket, err := r.take(1, "electronic ticket indicator")
+	if err != nil {
+		return nil, err
+	}
+	pass.ElectronicTicket = ticket == "E"
+
+	var tags [3]string
+	pass.Legs = make([]Leg, legs)
+	for i := range pass.Legs {
+		leg := &pass.Legs[i]
+		for _, f := range leg.mandatoryFields() {
+			value, err := r.take(f.length, f.name)
+			if err != nil {
+				return nil, fmt.Errorf("leg %d: %v", i+1, err)
+			}
+			*f.value = strings.TrimRight(value, " ")
+		}
+
+		size, err := r.size("conditional items")
+		if err != nil {
+			return nil, fmt.Errorf("leg %d: %v", i+1, err)
+		}
+		conditional, err := r.take(size, "conditional items")
+		if err != nil {
+			return nil, fmt.Errorf("leg %d: %v", i+1, err)
+		}
+		if err := pass.parseConditional(leg, conditional, i == 0, &tags); err != nil {
+			return nil, fmt.Errorf("leg %d: %v", i+1, err)
+		}
+	}
+
+	for _, tag := range tags {
+		if tag != "" {
+			pass.BaggageTags = append(pass.BaggageTags, tag)
+		}
+	}
+
+	if r.offset < len(r.data) {
+		if err := pass.parseSecurity(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := pass.Validate(); err != nil {
+		return nil, err
+	}
+	return pass, nil
+}
+
+func (pass *BoardingPass) parseConditional(leg *Leg, data string, first bool, tags *[3]string) error {
+	if data == "" {
+		return nil
+	}
+	r := &reader{data: data}
+
+	if first {
+		marker, err := r.take(1, "version number indicator")
+		if err != nil {
+			return err
+		}
+		if marker != ">" {
+			return fmt.Errorf("conditional items must start with >, got %q", marker)
+		}
+		if pass.Version, err = r.take(1, "version number"); err != nil {
+			return err
+		}
+		size, err := r.size("unique conditional items")
+		if err != nil {
+			return err
+		}
+		unique, err := r.take(size, "unique conditional items")
+		if err != nil {
+			return err
+		}
+		if err := readFields(pass.conditionalFields(tags), unique); err != nil {
+			return err
+		}
+	}
+
+	if r.offset == len(r.data) {
+		return nil
+	}
+	size, err := r.size("repeated conditional items")
+	if err != nil {
+		return err
+	}
+	repeated, err := r.take(size, "repeated conditional items")
+	if err != nil {
+		return err
+	}
+	if err := readFields(leg.conditionalFields(), repeated); err != nil {
+		return err
+	}
+
+	leg.AirlineUse = r.data[r.offset:]
+	return nil
+}
+
+func (pass *BoardingPass) parseSecurity(r *reader) error {
+	marker, err := r.take(1, "security data indicator")
+	if err != nil {
+		return err
+	}
+	if marker != "^" {
+		return fmt.Errorf("unexpected %q after the last leg", marker)
+	}
+
+	security := &SecurityData{}
+	if security.Type, err = r.take(1, "security data type"); err != nil {
+		return err
+	}
+	size, err := r.size("security data")
+	if err != nil {
+		return err
+	}
+	if security.Data, err = r.take(size, "security data"); err != nil {
+		return err
+	}
+	if r.offset != len(r.data) {
+		return fmt.Errorf("%d unexpected characters after the security data", len(r.data)-r.offset)
+	}
+
+	pass.Security = security
+	return nil
+}
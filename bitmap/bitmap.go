@@ -0,0 +1,85 @@
+// Package bitmap writes codes in simple uncompressed formats for embedded
+// displays, printers and older tools: BMP, Netpbm, XBM, ICO and bilevel
+// TIFF, and as ZPL and ESC/POS printer commands.
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strings"
+
+	"qr-code-generator/qrcode"
+)
+
+type Format struct {
+	Extension   string
+	ContentType string
+	// Bilevel formats have only black and white pixels.
+	Bilevel bool
+	// Encode writes img. name is used by formats that embed an identifier,
+	// such as the C variables of XBM.
+	Encode func(out io.Writer, img image.Image, name string) error
+}
+
+var Formats = map[string]Format{
+	"bmp":    {".bmp", "image/bmp", false, func(out io.Writer, img image.Image, _ string) error { return EncodeBMP(out, img, 24) }},
+	"bmp1":   {".bmp", "image/bmp", true, func(out io.Writer, img image.Image, _ string) error { return EncodeBMP(out, img, 1) }},
+	"pbm":    {".pbm", "image/x-portable-bitmap", true, func(out io.Writer, img image.Image, _ string) error { return EncodePBM(out, img) }},
+	"pgm":    {".pgm", "image/x-portable-graymap", false, func(out io.Writer, img image.Image, _ string) error { return EncodePGM(out, img) }},
+	"xbm":    {".xbm", "image/x-xbitmap", true, EncodeXBM},
+	"ico":    {".ico", "image/vnd.microsoft.icon", false, func(out io.Writer, img image.Image, _ string) error { return EncodeICO(out, img) }},
+	"tiff":   {".tif", "image/tiff", true, func(out io.Writer, img image.Image, _ string) error { return EncodeTIFF(out, img) }},
+	"zpl":    {".zpl", "text/plain; charset=us-ascii", true, func(out io.Writer, img image.Image, _ string) error { return EncodeZPL(out, img) }},
+	"escpos": {".bin", "application/octet-stream", true, func(out io.Writer, img image.Image, _ string) error { return EncodeESCPOS(out, img) }},
+}
+
+func gray(c color.Color) uint8 {
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
+
+// dark decides the colour of a pixel in the bilevel formats.
+func dark(c color.Color) bool {
+	return gray(c) < 0x80
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// identifier makes a C identifier of a file name.
+func identifier(name string) string {
+	name = strings.Trim(nonIdentifier.ReplaceAllString(name, "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "qr_" + name
+	}
+	return name
+}
+
+// Options place a watermark on codes rendered as bitmaps.
+type Options struct {
+	Watermark []byte
+	// Dithering and Threshold reduce the watermark to black and white for
+	// the bilevel formats.
+	Dithering Dithering
+	Threshold uint8
+}
+
+// Render draws the code for a format, with modules scaled exactly and the
+// watermark, if there is one, in the centre.
+func Render(code *qrcode.SimpleQRCode, format Format, options Options) (image.Image, error) {
+	symbol, err := code.Bitmap()
+	if err != nil {
+		return nil, err
+	}
+	if options.Watermark == nil {
+		return symbol, nil
+	}
+	composite, err := code.WatermarkImage(symbol, options.Watermark)
+	if err != nil {
+		return nil, err
+	}
+	if format.Bilevel {
+		return Overlay(symbol, composite, options.Dithering, options.Threshold), nil
+	}
+	return composite, nil
+}
@@ -0,0 +1,75 @@
+package bitmap
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+// checker is a bilevel test image whose width is not a whole number of
+// bytes.
+func checker() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 13, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 13; x++ {
+			if (x+y)%3 == 0 {
+				img.SetGray(x, y, color.Gray{0})
+			} else {
+				img.SetGray(x, y, color.Gray{0xff})
+			}
+		}
+	}
+	return img
+}
+
+func TestEncodeTIFF(t *testing.T) {
+	want := checker()
+	var buf bytes.Buffer
+	if err := EncodeTIFF(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := tiff.Decode(&buf)
+	if err != nil {
+		t.Fatalf("could not decode the TIFF: %v", err)
+	}
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("TIFF is %v, expected %v", got.Bounds(), want.Bounds())
+	}
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 13; x++ {
+			if dark(got.At(x, y)) != dark(want.At(x, y)) {
+				t.Fatalf("pixel %d,%d differs", x, y)
+			}
+		}
+	}
+}
+
+func TestEncodeZPL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeZPL(&buf, checker()); err != nil {
+		t.Fatal(err)
+	}
+	// Rows of 13 pixels take two bytes; the first row is dark at 0, 3, 6,
+	// 9 and 12.
+	if want := "^GFA,10,10,2,9248"; !strings.Contains(buf.String(), want) {
+		t.Errorf("ZPL %q does not contain %q", buf.String(), want)
+	}
+}
+
+func TestEncodeESCPOS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeESCPOS(&buf, checker()); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x1b, '@', 0x1d, 'v', '0', 0, 2, 0, 5, 0, 0x92, 0x48}
+	if !bytes.HasPrefix(buf.Bytes(), want) {
+		t.Errorf("ESC/POS starts % x, expected % x", buf.Bytes()[:len(want)], want)
+	}
+	if len(buf.Bytes()) != len(want)-2+10+3 {
+		t.Errorf("ESC/POS is %d bytes", len(buf.Bytes()))
+	}
+}
@@ -0,0 +1,72 @@
+package bitmap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+)
+
+// BMP resolution in pixels per metre, which is 72 DPI.
+const bmpResolution = 2835
+
+// EncodeBMP writes an uncompressed Windows bitmap with 1 or 24 bits per
+// pixel. One-bit bitmaps have black at palette index 0.
+func EncodeBMP(out io.Writer, img image.Image, bits int) error {
+	if bits != 1 && bits != 24 {
+		return fmt.Errorf("BMP images have 1 or 24 bits per pixel, got %d", bits)
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// Rows are padded to a multiple of four bytes.
+	stride := (width*bits + 31) / 32 * 4
+	paletteSize := 0
+	if bits == 1 {
+		paletteSize = 8
+	}
+	offset := 14 + 40 + paletteSize
+
+	header := make([]byte, offset)
+	copy(header, "BM")
+	binary.LittleEndian.PutUint32(header[2:], uint32(offset+stride*height))
+	binary.LittleEndian.PutUint32(header[10:], uint32(offset))
+	binary.LittleEndian.PutUint32(header[14:], 40)
+	binary.LittleEndian.PutUint32(header[18:], uint32(width))
+	binary.LittleEndian.PutUint32(header[22:], uint32(height))
+	binary.LittleEndian.PutUint16(header[26:], 1)
+	binary.LittleEndian.PutUint16(header[28:], uint16(bits))
+	binary.LittleEndian.PutUint32(header[34:], uint32(stride*height))
+	binary.LittleEndian.PutUint32(header[38:], bmpResolution)
+	binary.LittleEndian.PutUint32(header[42:], bmpResolution)
+	if bits == 1 {
+		binary.LittleEndian.PutUint32(header[46:], 2)
+		// Palette entries are blue, green, red and a reserved byte.
+		copy(header[54:], []byte{0, 0, 0, 0, 0xff, 0xff, 0xff, 0})
+	}
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+
+	// Rows are stored from the bottom up.
+	row := make([]byte, stride)
+	for y := bounds.Max.Y - 1; y >= bounds.Min.Y; y-- {
+		for i := range row {
+			row[i] = 0
+		}
+		for x := 0; x < width; x++ {
+			c := img.At(bounds.Min.X+x, y)
+			if bits == 1 {
+				if !dark(c) {
+					row[x/8] |= 0x80 >> (x % 8)
+				}
+				continue
+			}
+			r, g, b, _ := c.RGBA()
+			row[x*3], row[x*3+1], row[x*3+2] = byte(b>>8), byte(g>>8), byte(r>>8)
+		}
+		if _, err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
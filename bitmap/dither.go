@@ -0,0 +1,122 @@
+package bitmap
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+type Dithering string
+
+const (
+	Threshold      Dithering = "threshold"
+	FloydSteinberg Dithering = "floyd-steinberg"
+	Atkinson       Dithering = "atkinson"
+	Bayer          Dithering = "bayer"
+)
+
+func ParseDithering(name string) (Dithering, error) {
+	switch Dithering(name) {
+	case "":
+		return FloydSteinberg, nil
+	case Threshold, FloydSteinberg, Atkinson, Bayer:
+		return Dithering(name), nil
+	}
+	return "", fmt.Errorf("dithering must be threshold, floyd-steinberg, atkinson or bayer, got %q", name)
+}
+
+// diffusion spreads the quantisation error of a pixel over its neighbours
+// still to be visited, as offsets and weights out of the divisor.
+type diffusion struct {
+	divisor int
+	weights []struct{ dx, dy, weight int }
+}
+
+var diffusions = map[Dithering]diffusion{
+	FloydSteinberg: {16, []struct{ dx, dy, weight int }{
+		{1, 0, 7}, {-1, 1, 3}, {0, 1, 5}, {1, 1, 1},
+	}},
+	// Atkinson diffuses only three quarters of the error, which keeps
+	// light and dark areas clean at the cost of some detail.
+	Atkinson: {8, []struct{ dx, dy, weight int }{
+		{1, 0, 1}, {2, 0, 1}, {-1, 1, 1}, {0, 1, 1}, {1, 1, 1}, {0, 2, 1},
+	}},
+}
+
+// bayerMatrix is the 8x8 ordered dithering matrix, with levels 0 to 63.
+var bayerMatrix = [8][8]int{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// Overlay reduces a code with an overlay, such as a watermark, to black and
+// white for the 1-bit formats. Only the pixels the overlay changed are
+// dithered; the modules of the symbol stay exactly as they were, and no
+// error is carried across them. threshold is the grey level, 128 for half
+// brightness, below which pixels become black.
+func Overlay(symbol *image.Paletted, composite image.Image, dithering Dithering, threshold uint8) *image.Paletted {
+	bounds := symbol.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	result := image.NewPaletted(bounds, color.Palette{color.White, color.Black})
+	copy(result.Pix, symbol.Pix)
+
+	levels := make([]int, width*height)
+	changed := make([]bool, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			level := int(gray(composite.At(bounds.Min.X+x, bounds.Min.Y+y)))
+			original := 0xff
+			if symbol.Pix[symbol.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)] == 1 {
+				original = 0
+			}
+			levels[y*width+x] = level
+			changed[y*width+x] = level != original
+		}
+	}
+
+	spread, diffused := diffusions[dithering]
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			if !changed[i] {
+				continue
+			}
+			level := levels[i]
+			cutoff := int(threshold)
+			if dithering == Bayer {
+				// Spread the cutoff across the matrix, centred on the
+				// threshold.
+				cutoff += (bayerMatrix[y%8][x%8]*4 + 2) - 128
+			}
+			black := level < cutoff
+			offset := result.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)
+			result.Pix[offset] = 0
+			if black {
+				result.Pix[offset] = 1
+			}
+
+			if !diffused {
+				continue
+			}
+			quantised := 0xff
+			if black {
+				quantised = 0
+			}
+			errorValue := level - quantised
+			for _, weight := range spread.weights {
+				nx, ny := x+weight.dx, y+weight.dy
+				if nx < 0 || nx >= width || ny >= height || !changed[ny*width+nx] {
+					continue
+				}
+				levels[ny*width+nx] += errorValue * weight.weight / spread.divisor
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package bitmap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+)
+
+// EncodeICO writes a Windows icon holding a single 32-bit bitmap, which
+// every version of Windows can read. Icons are at most 256 pixels square.
+func EncodeICO(out io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > 256 || height > 256 {
+		return fmt.Errorf("icons are at most 256 pixels, this code is %dx%d; ask for a smaller size", width, height)
+	}
+
+	// The bitmap is followed by a one-bit transparency mask, all opaque,
+	// with rows padded to four bytes.
+	maskStride := (width + 31) / 32 * 4
+	imageSize := 40 + width*height*4 + maskStride*height
+
+	header := make([]byte, 6+16+40)
+	binary.LittleEndian.PutUint16(header[2:], 1)
+	binary.LittleEndian.PutUint16(header[4:], 1)
+	// A width or height of 256 is written as 0.
+	header[6], header[7] = byte(width), byte(height)
+	binary.LittleEndian.PutUint16(header[10:], 1)
+	binary.LittleEndian.PutUint16(header[12:], 32)
+	binary.LittleEndian.PutUint32(header[14:], uint32(imageSize))
+	binary.LittleEndian.PutUint32(header[18:], 22)
+
+	info := header[22:]
+	binary.LittleEndian.PutUint32(info[0:], 40)
+	binary.LittleEndian.PutUint32(info[4:], uint32(width))
+	// The height counts both the image and the mask.
+	binary.LittleEndian.PutUint32(info[8:], uint32(2*height))
+	binary.LittleEndian.PutUint16(info[12:], 1)
+	binary.LittleEndian.PutUint16(info[14:], 32)
+	binary.LittleEndian.PutUint32(info[20:], uint32(width*height*4+maskStride*height))
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+
+	row := make([]byte, width*4)
+	for y := bounds.Max.Y - 1; y >= bounds.Min.Y; y-- {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, y).RGBA()
+			copy(row[x*4:], []byte{byte(b >> 8), byte(g >> 8), byte(r >> 8), byte(a >> 8)})
+		}
+		if _, err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	_, err := out.Write(make([]byte, maskStride*height))
+	return err
+}
@@ -0,0 +1,58 @@
+package bitmap
+
+import (
+	"fmt"
+	"image"
+	"io"
+)
+
+// EncodePBM writes a binary (P4) portable bitmap, in which 1 is black.
+func EncodePBM(out io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	if _, err := fmt.Fprintf(out, "P4\n%d %d\n", bounds.Dx(), bounds.Dy()); err != nil {
+		return err
+	}
+	return packRows(img, func(row []byte) error {
+		_, err := out.Write(row)
+		return err
+	})
+}
+
+// packRows passes each row of img to write with 8 pixels to a byte, the
+// leftmost in the high bit, and 1 for black. The row is reused.
+func packRows(img image.Image, write func(row []byte) error) error {
+	bounds := img.Bounds()
+	row := make([]byte, (bounds.Dx()+7)/8)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for i := range row {
+			row[i] = 0
+		}
+		for x := 0; x < bounds.Dx(); x++ {
+			if dark(img.At(bounds.Min.X+x, y)) {
+				row[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		if err := write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodePGM writes a binary (P5) portable graymap with 8 bits per pixel.
+func EncodePGM(out io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	if _, err := fmt.Fprintf(out, "P5\n%d %d\n255\n", bounds.Dx(), bounds.Dy()); err != nil {
+		return err
+	}
+	row := make([]byte, bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := range row {
+			row[x] = gray(img.At(bounds.Min.X+x, y))
+		}
+		if _, err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
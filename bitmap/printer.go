@@ -0,0 +1,68 @@
+package bitmap
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+// EncodeZPL writes a Zebra label that prints the image at the label's top
+// left corner, as an ASCII hex graphic field (^GFA) in which 1 is a printed
+// dot.
+func EncodeZPL(out io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	stride := (bounds.Dx() + 7) / 8
+	var data strings.Builder
+	if err := packRows(img, func(row []byte) error {
+		data.WriteString(strings.ToUpper(hex.EncodeToString(row)))
+		return nil
+	}); err != nil {
+		return err
+	}
+	total := stride * bounds.Dy()
+	_, err := fmt.Fprintf(out, "^XA\n^FO0,0^GFA,%d,%d,%d,%s^FS\n^XZ\n", total, total, stride, data.String())
+	return err
+}
+
+// escposMaxHeight is the most rows many receipt printers take in one
+// raster command, so taller images are sent in bands.
+const escposMaxHeight = 2048
+
+// EncodeESCPOS writes receipt printer commands that initialise the printer,
+// print the image with the raster bit image command (GS v 0), in which 1 is
+// a printed dot, and feed the paper past the tear bar.
+func EncodeESCPOS(out io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	stride := (bounds.Dx() + 7) / 8
+	if stride > 0xffff {
+		return fmt.Errorf("ESC/POS images must be at most %d pixels wide", 0xffff*8)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\x1b@")
+	var band [][]byte
+	flush := func() {
+		buf.Write([]byte{0x1d, 'v', '0', 0, byte(stride), byte(stride >> 8), byte(len(band)), byte(len(band) >> 8)})
+		for _, row := range band {
+			buf.Write(row)
+		}
+		band = band[:0]
+	}
+	if err := packRows(img, func(row []byte) error {
+		band = append(band, append([]byte(nil), row...))
+		if len(band) == escposMaxHeight {
+			flush()
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(band) > 0 {
+		flush()
+	}
+	buf.WriteString("\x1bd\x03")
+	_, err := out.Write(buf.Bytes())
+	return err
+}
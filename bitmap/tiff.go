@@ -0,0 +1,77 @@
+package bitmap
+
+import (
+	"encoding/binary"
+	"image"
+	"io"
+)
+
+// TIFF field types.
+const (
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+)
+
+// tiffResolution is 72 DPI, as for BMP.
+const tiffResolution = 72
+
+// EncodeTIFF writes a baseline bilevel TIFF: one uncompressed strip with 1
+// bit per pixel, in which 1 is black (WhiteIsZero), as fax software and
+// label printer drivers expect.
+func EncodeTIFF(out io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	stride := (width + 7) / 8
+
+	type entry struct {
+		tag, kind uint16
+		value     uint32
+	}
+	const count = 12
+	ifdSize := 2 + count*12 + 4
+	resolutionOffset := 8 + ifdSize
+	dataOffset := resolutionOffset + 16
+	entries := [count]entry{
+		{256, tiffLong, uint32(width)},                    // ImageWidth
+		{257, tiffLong, uint32(height)},                   // ImageLength
+		{258, tiffShort, 1},                               // BitsPerSample
+		{259, tiffShort, 1},                               // Compression: none
+		{262, tiffShort, 0},                               // PhotometricInterpretation: WhiteIsZero
+		{273, tiffLong, uint32(dataOffset)},               // StripOffsets
+		{277, tiffShort, 1},                               // SamplesPerPixel
+		{278, tiffLong, uint32(height)},                   // RowsPerStrip
+		{279, tiffLong, uint32(stride * height)},          // StripByteCounts
+		{282, tiffRational, uint32(resolutionOffset)},     // XResolution
+		{283, tiffRational, uint32(resolutionOffset + 8)}, // YResolution
+		{296, tiffShort, 2},                               // ResolutionUnit: inch
+	}
+
+	header := make([]byte, dataOffset)
+	copy(header, "II*\x00")
+	binary.LittleEndian.PutUint32(header[4:], 8)
+	binary.LittleEndian.PutUint16(header[8:], count)
+	for i, e := range entries {
+		field := header[10+12*i:]
+		binary.LittleEndian.PutUint16(field, e.tag)
+		binary.LittleEndian.PutUint16(field[2:], e.kind)
+		binary.LittleEndian.PutUint32(field[4:], 1)
+		// Shorts are left-justified in the value field.
+		if e.kind == tiffShort {
+			binary.LittleEndian.PutUint16(field[8:], uint16(e.value))
+		} else {
+			binary.LittleEndian.PutUint32(field[8:], e.value)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		binary.LittleEndian.PutUint32(header[resolutionOffset+8*i:], tiffResolution)
+		binary.LittleEndian.PutUint32(header[resolutionOffset+8*i+4:], 1)
+	}
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	return packRows(img, func(row []byte) error {
+		_, err := out.Write(row)
+		return err
+	})
+}
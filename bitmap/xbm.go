@@ -0,0 +1,44 @@
+package bitmap
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+)
+
+// EncodeXBM writes an X bitmap, which is C source declaring the width,
+// height and bits of the image, so it can be compiled into firmware. Bits
+// are set for black pixels, least significant bit first.
+func EncodeXBM(out io.Writer, img image.Image, name string) error {
+	name = identifier(name)
+	bounds := img.Bounds()
+	writer := bufio.NewWriter(out)
+	fmt.Fprintf(writer, "#define %s_width %d\n", name, bounds.Dx())
+	fmt.Fprintf(writer, "#define %s_height %d\n", name, bounds.Dy())
+	fmt.Fprintf(writer, "static unsigned char %s_bits[] = {", name)
+
+	count := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := 0; x < bounds.Dx(); x += 8 {
+			var value byte
+			for bit := 0; bit < 8 && x+bit < bounds.Dx(); bit++ {
+				if dark(img.At(bounds.Min.X+x+bit, y)) {
+					value |= 1 << bit
+				}
+			}
+			if count > 0 {
+				writer.WriteString(",")
+			}
+			if count%12 == 0 {
+				writer.WriteString("\n  ")
+			} else {
+				writer.WriteString(" ")
+			}
+			fmt.Fprintf(writer, "0x%02x", value)
+			count++
+		}
+	}
+	writer.WriteString("\n};\n")
+	return writer.Flush()
+}
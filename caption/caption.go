@@ -0,0 +1,330 @@
+package caption
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-text/typesetting/di"
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/bidi"
+)
+
+var (
+	//go:embed fonts/Amiri-Regular.ttf
+	amiri []byte
+	//go:embed fonts/NotoSansThai-Regular.ttf
+	notoSansThai []byte
+	//go:embed fonts/NotoSansDevanagari-Regular.ttf
+	notoSansDevanagari []byte
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	dejaVuSans []byte
+)
+
+// fallback is the chain of bundled fonts. Each character is set in the
+// first font that has it: Go Regular for Latin, Greek and Cyrillic, Amiri
+// for Arabic, Noto Sans Thai and Noto Sans Devanagari for those scripts,
+// and DejaVu Sans for Hebrew and whatever else it covers.
+type fallback []*font.Face
+
+func (chain fallback) ResolveFace(char rune) *font.Face {
+	for _, face := range chain {
+		if _, ok := face.NominalGlyph(char); ok {
+			return face
+		}
+	}
+	return chain[0]
+}
+
+func (chain fallback) covers(char rune) bool {
+	for _, face := range chain {
+		if _, ok := face.NominalGlyph(char); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	loadFonts sync.Once
+	fonts     fallback
+	fontsErr  error
+)
+
+func bundledFonts() (fallback, error) {
+	loadFonts.Do(func() {
+		for _, data := range [][]byte{goregular.TTF, amiri, notoSansThai, notoSansDevanagari, dejaVuSans} {
+			face, err := font.ParseTTF(bytes.NewReader(data))
+			if err != nil {
+				fontsErr = fmt.Errorf("could not read a bundled font: %v", err)
+				return
+			}
+			fonts = append(fonts, face)
+		}
+	})
+	return fonts, fontsErr
+}
+
+const maxLength = 200
+
+// Caption is text shaped and laid out in lines, ready to be drawn. Lengths
+// are in the units of the size it was shaped at, pixels or points.
+type Caption struct {
+	lines  []shaping.Line
+	Width  float64
+	Height float64
+	// Ascent is the height of the first line above its baseline, for
+	// setting a caption on the baseline of other text.
+	Ascent float64
+}
+
+// New shapes text at the given size, wrapping it to maxWidth. Text is
+// split into runs of one direction, script and font, so that Arabic letters
+// join, marks are placed and right-to-left runs read in the right order
+// whatever the paragraph's direction.
+func New(text string, size, maxWidth float64) (*Caption, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("caption is empty")
+	}
+	if utf8.RuneCountInString(text) > maxLength {
+		return nil, fmt.Errorf("caption must be at most %d characters", maxLength)
+	}
+	chain, err := bundledFonts()
+	if err != nil {
+		return nil, err
+	}
+	for _, char := range text {
+		if unicode.IsSpace(char) || unicode.Is(unicode.Cf, char) {
+			continue
+		}
+		if unicode.IsControl(char) {
+			return nil, fmt.Errorf("caption must not contain control characters")
+		}
+		if !chain.covers(char) {
+			return nil, fmt.Errorf("no bundled font has %q, which is %s", char, language.LookupScript(char))
+		}
+	}
+
+	runes := []rune(text)
+	direction := paragraphDirection(runes)
+	input := shaping.Input{
+		Text:      runes,
+		RunStart:  0,
+		RunEnd:    len(runes),
+		Direction: direction,
+		Size:      fixed.Int26_6(size * 64),
+	}
+	var (
+		segmenter shaping.Segmenter
+		shaper    shaping.HarfbuzzShaper
+		runs      []shaping.Output
+	)
+	for _, run := range segmenter.Split(input, chain) {
+		runs = append(runs, shaper.Shape(run))
+	}
+
+	var wrapper shaping.LineWrapper
+	lines, _ := wrapper.WrapParagraph(shaping.WrapConfig{Direction: direction}, int(maxWidth), runes, shaping.NewSliceIterator(runs))
+	caption := &Caption{}
+	for _, line := range lines {
+		// The wrapper reuses its lines, so they are copied.
+		line = visualOrder(append(shaping.Line(nil), line...), runes, direction)
+		caption.lines = append(caption.lines, line)
+	}
+	caption.measure()
+	return caption, nil
+}
+
+// Limit drops the lines after the first few, for captions that only have
+// room for so many.
+func (caption *Caption) Limit(lines int) {
+	if len(caption.lines) <= lines {
+		return
+	}
+	caption.lines = caption.lines[:lines]
+	caption.measure()
+}
+
+func (caption *Caption) measure() {
+	caption.Width, caption.Height, caption.Ascent = 0, 0, 0
+	for i, line := range caption.lines {
+		ascent, descent := lineBounds(line)
+		if i == 0 {
+			caption.Ascent = toFloat(ascent)
+		}
+		caption.Width = max(caption.Width, toFloat(lineAdvance(line)))
+		caption.Height += toFloat(ascent - descent)
+	}
+}
+
+// paragraphDirection follows the first strong character, as the Unicode
+// bidirectional algorithm does when no direction is given.
+func paragraphDirection(runes []rune) di.Direction {
+	for _, char := range runes {
+		properties, _ := bidi.LookupRune(char)
+		switch properties.Class() {
+		case bidi.L:
+			return di.DirectionLTR
+		case bidi.R, bidi.AL:
+			return di.DirectionRTL
+		}
+	}
+	return di.DirectionLTR
+}
+
+// visualOrder puts the runs of a line in the order they are drawn, left to
+// right. The wrapper's own ordering treats numbers in right-to-left text as
+// left-to-right text, which would split the right-to-left text around them,
+// so levels are assigned as rules W7 and I1 to I2 of the bidirectional
+// algorithm do and runs are reversed as rule L2 does.
+func visualOrder(line shaping.Line, runes []rune, direction di.Direction) shaping.Line {
+	paragraph := 0
+	if direction == di.DirectionRTL {
+		paragraph = 1
+	}
+	levels := make([]int, len(line))
+	highest := paragraph
+	for i, run := range line {
+		switch {
+		case run.Direction == di.DirectionRTL:
+			levels[i] = 1
+		case paragraph == 1, numbersAfterRTL(runes, run.Runes.Offset, run.Runes.Offset+run.Runes.Count):
+			levels[i] = 2
+		}
+		highest = max(highest, levels[i])
+	}
+	for level := highest; level >= 1; level-- {
+		for start := 0; start < len(line); {
+			if levels[start] < level {
+				start++
+				continue
+			}
+			end := start
+			for end < len(line) && levels[end] >= level {
+				end++
+			}
+			for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+				line[i], line[j] = line[j], line[i]
+				levels[i], levels[j] = levels[j], levels[i]
+			}
+			start = end
+		}
+	}
+	return line
+}
+
+// numbersAfterRTL reports whether a left-to-right run has no strong
+// characters of its own, only numbers and neutrals, and follows
+// right-to-left text. Its numbers then keep their level above the
+// right-to-left text rather than joining the left-to-right text around it.
+func numbersAfterRTL(runes []rune, start, end int) bool {
+	for _, char := range runes[start:end] {
+		if properties, _ := bidi.LookupRune(char); properties.Class() == bidi.L {
+			return false
+		}
+	}
+	for i := start - 1; i >= 0; i-- {
+		properties, _ := bidi.LookupRune(runes[i])
+		switch properties.Class() {
+		case bidi.L:
+			return false
+		case bidi.R, bidi.AL:
+			return true
+		}
+	}
+	return false
+}
+
+func lineBounds(line shaping.Line) (ascent, descent fixed.Int26_6) {
+	for _, run := range line {
+		ascent = max(ascent, run.LineBounds.Ascent)
+		descent = min(descent, run.LineBounds.Descent)
+	}
+	return ascent, descent
+}
+
+func lineAdvance(line shaping.Line) fixed.Int26_6 {
+	var advance fixed.Int26_6
+	for _, run := range line {
+		advance += run.Advance
+	}
+	return advance
+}
+
+func toFloat(value fixed.Int26_6) float64 {
+	return float64(value) / 64
+}
+
+// Pen receives the outlines of a caption, with y increasing downwards.
+// Each glyph is one or more closed contours to be filled with the nonzero
+// winding rule.
+type Pen interface {
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	QuadTo(x1, y1, x2, y2 float64)
+	CubeTo(x1, y1, x2, y2, x3, y3 float64)
+	Close()
+}
+
+// Draw outlines the caption with its top at y, centring each line on
+// centreX.
+func (caption *Caption) Draw(pen Pen, centreX, y float64) {
+	for _, line := range caption.lines {
+		ascent, descent := lineBounds(line)
+		baseline := y + toFloat(ascent)
+		x := centreX - toFloat(lineAdvance(line))/2
+		for _, run := range line {
+			scale := toFloat(run.Size) / float64(run.Face.Upem())
+			for _, glyph := range run.Glyphs {
+				if outline, ok := run.Face.GlyphData(glyph.GlyphID).(font.GlyphOutline); ok {
+					drawOutline(pen, outline, x+toFloat(glyph.XOffset), baseline-toFloat(glyph.YOffset), scale)
+				}
+				x += toFloat(glyph.XAdvance)
+			}
+		}
+		y += toFloat(ascent - descent)
+	}
+}
+
+// drawOutline converts a glyph from font units, with y increasing upwards,
+// to the pen's coordinates.
+func drawOutline(pen Pen, outline font.GlyphOutline, x, y, scale float64) {
+	point := func(p ot.SegmentPoint) (float64, float64) {
+		return x + float64(p.X)*scale, y - float64(p.Y)*scale
+	}
+	started := false
+	for _, segment := range outline.Segments {
+		switch segment.Op {
+		case ot.SegmentOpMoveTo:
+			if started {
+				pen.Close()
+			}
+			pen.MoveTo(point(segment.Args[0]))
+			started = true
+		case ot.SegmentOpLineTo:
+			pen.LineTo(point(segment.Args[0]))
+		case ot.SegmentOpQuadTo:
+			x1, y1 := point(segment.Args[0])
+			x2, y2 := point(segment.Args[1])
+			pen.QuadTo(x1, y1, x2, y2)
+		case ot.SegmentOpCubeTo:
+			x1, y1 := point(segment.Args[0])
+			x2, y2 := point(segment.Args[1])
+			x3, y3 := point(segment.Args[2])
+			pen.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	if started {
+		pen.Close()
+	}
+}
@@ -0,0 +1,130 @@
+package caption
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-text/typesetting/di"
+
+	"qr-code-generator/pdf"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// scripts are captions in each script the bundled fonts cover, with
+// numbers and Latin text inside the right-to-left ones.
+var scripts = []struct {
+	name, text string
+}{
+	{"latin", "Scan for the menu"},
+	{"arabic", "امسح الرمز لعرض القائمة 2024"},
+	{"hebrew", "סרקו לתפריט QR 12"},
+	{"thai", "สแกนเพื่อดูเมนู"},
+	{"devanagari", "मेनू देखने के लिए स्कैन करें"},
+}
+
+// golden compares output with a file in testdata, or rewrites the file
+// with -update.
+func golden(t *testing.T, name string, got []byte) []byte {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run the tests with -update to create it", err)
+	}
+	return want
+}
+
+func TestBelowGolden(t *testing.T) {
+	for _, script := range scripts {
+		t.Run(script.name, func(t *testing.T) {
+			code := image.NewRGBA(image.Rect(0, 0, 320, 8))
+			draw.Draw(code, code.Bounds(), image.White, image.Point{}, draw.Src)
+			img, err := Below(code, script.text, color.Black, color.White)
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded := bytes.NewBuffer(nil)
+			if err := png.Encode(encoded, img); err != nil {
+				t.Fatal(err)
+			}
+			want, err := png.Decode(bytes.NewReader(golden(t, script.name+".png", encoded.Bytes())))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want.Bounds() != img.Bounds() {
+				t.Fatalf("caption is %v, expected %v", img.Bounds(), want.Bounds())
+			}
+			// Antialiasing may differ by a level or two where floating
+			// point is computed differently, so only clear differences
+			// count.
+			differ := 0
+			for y := 0; y < img.Bounds().Dy(); y++ {
+				for x := 0; x < img.Bounds().Dx(); x++ {
+					a := color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+					b := color.GrayModel.Convert(want.At(x, y)).(color.Gray).Y
+					if max(a, b)-min(a, b) > 16 {
+						differ++
+					}
+				}
+			}
+			if differ > 0 {
+				t.Errorf("%d pixels differ from testdata/%s.png", differ, script.name)
+			}
+		})
+	}
+}
+
+func TestDrawPDFGolden(t *testing.T) {
+	for _, script := range scripts {
+		t.Run(script.name, func(t *testing.T) {
+			caption, err := New(script.text, 10, 80*pdf.MM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			page := pdf.New().AddPage(100*pdf.MM, 30*pdf.MM)
+			caption.DrawPDF(page, 50*pdf.MM, 25*pdf.MM)
+			if want := golden(t, script.name+".txt", page.Content()); !bytes.Equal(page.Content(), want) {
+				t.Errorf("path operators differ from testdata/%s.txt", script.name)
+			}
+		})
+	}
+}
+
+func TestNewRejects(t *testing.T) {
+	for _, text := range []string{"", "   ", "扫描", "line\x07bell", strings.Repeat("a", maxLength+1)} {
+		if _, err := New(text, 12, 200); err == nil {
+			t.Errorf("New(%q) should fail", text)
+		}
+	}
+}
+
+func TestParagraphDirection(t *testing.T) {
+	for _, script := range scripts {
+		caption, err := New(script.text, 12, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(caption.lines) != 1 {
+			t.Errorf("%s caption has %d lines, expected 1", script.name, len(caption.lines))
+		}
+		rtl := script.name == "arabic" || script.name == "hebrew"
+		if got := paragraphDirection([]rune(script.text)) == di.DirectionRTL; got != rtl {
+			t.Errorf("%s caption is right to left: %v, expected %v", script.name, got, rtl)
+		}
+	}
+}
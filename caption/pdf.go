@@ -0,0 +1,45 @@
+package caption
+
+import "qr-code-generator/pdf"
+
+// pdfPen draws outlines on a page, whose y axis points up, with the
+// caption's top at top.
+type pdfPen struct {
+	page         *pdf.Page
+	top          float64
+	lastX, lastY float64
+}
+
+func (pen *pdfPen) MoveTo(x, y float64) {
+	pen.page.MoveTo(x, pen.top-y)
+	pen.lastX, pen.lastY = x, y
+}
+
+func (pen *pdfPen) LineTo(x, y float64) {
+	pen.page.LineTo(x, pen.top-y)
+	pen.lastX, pen.lastY = x, y
+}
+
+// QuadTo raises a quadratic curve to the cubic one PDF paths take.
+func (pen *pdfPen) QuadTo(x1, y1, x2, y2 float64) {
+	pen.CubeTo(
+		pen.lastX+2*(x1-pen.lastX)/3, pen.lastY+2*(y1-pen.lastY)/3,
+		x2+2*(x1-x2)/3, y2+2*(y1-y2)/3,
+		x2, y2,
+	)
+}
+
+func (pen *pdfPen) CubeTo(x1, y1, x2, y2, x3, y3 float64) {
+	pen.page.CurveTo(x1, pen.top-y1, x2, pen.top-y2, x3, pen.top-y3)
+	pen.lastX, pen.lastY = x3, y3
+}
+
+func (pen *pdfPen) Close() { pen.page.ClosePath() }
+
+// DrawPDF fills the caption's outlines on a page in the current fill
+// colour, centred on centreX with its top at top, both in points. The text
+// does not depend on fonts in the document or the reader.
+func (caption *Caption) DrawPDF(page *pdf.Page, centreX, top float64) {
+	caption.Draw(&pdfPen{page: page, top: top}, centreX, 0)
+	page.Fill()
+}
@@ -0,0 +1,50 @@
+package caption
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/vector"
+)
+
+type rasterPen struct {
+	z *vector.Rasterizer
+}
+
+func (pen rasterPen) MoveTo(x, y float64) { pen.z.MoveTo(float32(x), float32(y)) }
+func (pen rasterPen) LineTo(x, y float64) { pen.z.LineTo(float32(x), float32(y)) }
+func (pen rasterPen) Close()              { pen.z.ClosePath() }
+
+func (pen rasterPen) QuadTo(x1, y1, x2, y2 float64) {
+	pen.z.QuadTo(float32(x1), float32(y1), float32(x2), float32(y2))
+}
+
+func (pen rasterPen) CubeTo(x1, y1, x2, y2, x3, y3 float64) {
+	pen.z.CubeTo(float32(x1), float32(y1), float32(x2), float32(y2), float32(x3), float32(y3))
+}
+
+// Below adds the caption under a rendered code on a band of the background
+// colour. The text is a sixteenth of the code's width high and wraps to
+// nine tenths of it.
+func Below(img image.Image, text string, foreground, background color.Color) (*image.RGBA, error) {
+	bounds := img.Bounds()
+	size := max(12, float64(bounds.Dx())/16)
+	caption, err := New(text, size, float64(bounds.Dx())*0.9)
+	if err != nil {
+		return nil, err
+	}
+
+	margin := int(size / 2)
+	height := int(caption.Height+0.5) + margin
+	result := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()+height))
+	draw.Draw(result, result.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(result, image.Rect(0, 0, bounds.Dx(), bounds.Dy()), img, bounds.Min, draw.Src)
+
+	// The code's quiet zone already separates it from the text, so the
+	// margin goes below.
+	z := vector.NewRasterizer(bounds.Dx(), height)
+	caption.Draw(rasterPen{z}, float64(bounds.Dx())/2, 0)
+	z.Draw(result, image.Rect(0, bounds.Dy(), bounds.Dx(), bounds.Dy()+height), image.NewUniform(foreground), image.Point{})
+	return result, nil
+}
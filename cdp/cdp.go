@@ -0,0 +1,192 @@
+// Package cdp makes copy-detection patterns: squares of random noise,
+// derived from a secret and a label's serial number, printed beside a code.
+// Printing loses a little of the noise's fine detail and copying a print
+// loses much more, so a capture of a genuine label matches the expected
+// pattern closely and a capture of a copy does not.
+package cdp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand/v2"
+)
+
+const (
+	// Cells is the number of noise cells across a pattern. The threshold
+	// is calibrated for it: with fewer, larger cells copying keeps most of
+	// the noise, and copies score as well as prints.
+	Cells = 64
+	// Threshold is the lowest score of a genuine label. Simulated captures
+	// of prints score above 0.8 and of copies below 0.7, but real printers
+	// and cameras differ, so it is worth calibrating against captures of
+	// known prints and copies.
+	Threshold = 0.75
+)
+
+// Pattern is a square of cells, true where they are dark.
+type Pattern [][]bool
+
+// New derives the pattern for a serial number. The same secret and serial
+// always give the same pattern, and without the secret it cannot be
+// predicted from other labels.
+func New(secret []byte, serial string) (Pattern, error) {
+	if serial == "" {
+		return nil, fmt.Errorf("the serial number is empty")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(serial))
+	var seed [32]byte
+	copy(seed[:], mac.Sum(nil))
+	random := rand.New(rand.NewChaCha8(seed))
+
+	pattern := make(Pattern, Cells)
+	for row := range pattern {
+		pattern[row] = make([]bool, Cells)
+		for col := range pattern[row] {
+			pattern[row][col] = random.IntN(2) == 1
+		}
+	}
+	return pattern, nil
+}
+
+// Draw renders the pattern side pixels across, inside a dark frame one cell
+// wide that lets a capture be cropped to it.
+func (pattern Pattern) Draw(side int) *image.Gray {
+	across := len(pattern) + 2
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		row := y * across / side
+		for x := 0; x < side; x++ {
+			col := x * across / side
+			dark := row == 0 || col == 0 || row == across-1 || col == across-1 || pattern[row-1][col-1]
+			if !dark {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return img
+}
+
+// Label places the pattern to the right of a rendered code, as tall as the
+// symbol and level with it, separated by the code's own quiet zone.
+func Label(code image.Image, pattern Pattern) (*image.RGBA, error) {
+	symbol, ok := darkBounds(code)
+	if !ok {
+		return nil, fmt.Errorf("the code has no dark modules")
+	}
+	if symbol.Dy() < 2*(len(pattern)+2) {
+		return nil, fmt.Errorf("the code is too small for a pattern of %d cells, the symbol needs to be at least %d pixels high",
+			len(pattern), 2*(len(pattern)+2))
+	}
+	bounds := code.Bounds()
+	margin := bounds.Max.X - symbol.Max.X
+	label := image.NewRGBA(image.Rect(0, 0, bounds.Dx()+symbol.Dy()+margin, bounds.Dy()))
+	draw.Draw(label, label.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(label, bounds.Sub(bounds.Min), code, bounds.Min, draw.Src)
+	at := image.Pt(bounds.Dx(), symbol.Min.Y-bounds.Min.Y)
+	draw.Draw(label, image.Rectangle{at, at.Add(image.Pt(symbol.Dy(), symbol.Dy()))}, pattern.Draw(symbol.Dy()), image.Point{}, draw.Src)
+	return label, nil
+}
+
+func luma(img image.Image, x, y int) float64 {
+	return float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+}
+
+// darkBounds is the smallest rectangle holding every pixel darker than
+// mid-grey.
+func darkBounds(img image.Image) (image.Rectangle, bool) {
+	bounds := img.Bounds()
+	found := image.Rectangle{}
+	ok := false
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if luma(img, x, y) >= 128 {
+				continue
+			}
+			pixel := image.Rect(x, y, x+1, y+1)
+			if ok {
+				found = found.Union(pixel)
+			} else {
+				found, ok = pixel, true
+			}
+		}
+	}
+	return found, ok
+}
+
+// Locate finds the pattern's frame in a capture, which may be of the
+// pattern alone or of the whole label. A capture much wider than it is high
+// is taken to be a label, with the pattern as the square at its right.
+func Locate(capture image.Image) (image.Rectangle, error) {
+	frame, ok := darkBounds(capture)
+	if !ok {
+		return frame, fmt.Errorf("the capture is blank")
+	}
+	if frame.Dx() > frame.Dy()*3/2 {
+		frame.Min.X = frame.Max.X - frame.Dy()
+	}
+	if frame.Dx() < Cells+2 || frame.Dy() < Cells+2 {
+		return frame, fmt.Errorf("the pattern in the capture is too small to read, %dx%d pixels", frame.Dx(), frame.Dy())
+	}
+	return frame, nil
+}
+
+// Score compares a capture with the expected pattern. It is the correlation
+// between the darkness of the middle of each cell and the pattern, from 1
+// for a perfect match down to 0 for no resemblance.
+func Score(capture image.Image, pattern Pattern) (float64, error) {
+	frame, err := Locate(capture)
+	if err != nil {
+		return 0, err
+	}
+	across := float64(len(pattern) + 2)
+	cellWidth, cellHeight := float64(frame.Dx())/across, float64(frame.Dy())/across
+
+	var expected, measured []float64
+	for row := range pattern {
+		for col := range pattern[row] {
+			// The middle half of the cell, away from the blur of its edges.
+			left := float64(frame.Min.X) + (float64(col)+1.25)*cellWidth
+			top := float64(frame.Min.Y) + (float64(row)+1.25)*cellHeight
+			var sum, count float64
+			for y := int(top); y < int(math.Ceil(top+cellHeight/2)); y++ {
+				for x := int(left); x < int(math.Ceil(left+cellWidth/2)); x++ {
+					sum += 255 - luma(capture, x, y)
+					count++
+				}
+			}
+			measured = append(measured, sum/max(1, count))
+			if pattern[row][col] {
+				expected = append(expected, 1)
+			} else {
+				expected = append(expected, 0)
+			}
+		}
+	}
+	return max(0, correlation(expected, measured)), nil
+}
+
+func correlation(a, b []float64) float64 {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(len(a))
+	meanB /= float64(len(b))
+	var covariance, varianceA, varianceB float64
+	for i := range a {
+		covariance += (a[i] - meanA) * (b[i] - meanB)
+		varianceA += (a[i] - meanA) * (a[i] - meanA)
+		varianceB += (b[i] - meanB) * (b[i] - meanB)
+	}
+	if varianceA == 0 || varianceB == 0 {
+		return 0
+	}
+	return covariance / math.Sqrt(varianceA*varianceB)
+}
@@ -0,0 +1,82 @@
+package cdp
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"qr-code-generator/qrcode"
+)
+
+func label(t *testing.T, pattern Pattern) image.Image {
+	t.Helper()
+	code := &qrcode.SimpleQRCode{Content: "https://example.com/p/SN-0001", Size: 600}
+	data, err := code.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := Label(rendered, pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestScoreSeparatesPrintsFromCopies(t *testing.T) {
+	pattern, err := New([]byte("secret"), "SN-0001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := label(t, pattern)
+	for generations := 1; generations <= 3; generations++ {
+		score, err := Score(Photocopy(img, generations), pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if genuine := score >= Threshold; genuine != (generations == 1) {
+			t.Errorf("generation %d scores %.3f against a threshold of %.2f", generations, score, Threshold)
+		}
+	}
+}
+
+func TestScoreOfAnotherSerial(t *testing.T) {
+	pattern, err := New([]byte("secret"), "SN-0001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := New([]byte("secret"), "SN-0002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	score, err := Score(Photocopy(label(t, pattern), 1), other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score > 0.1 {
+		t.Errorf("another serial scores %.3f, expected about 0", score)
+	}
+}
+
+func TestNewIsDeterministic(t *testing.T) {
+	a, _ := New([]byte("secret"), "SN-0001")
+	b, _ := New([]byte("secret"), "SN-0001")
+	c, _ := New([]byte("other"), "SN-0001")
+	same, differs := true, false
+	for row := range a {
+		for col := range a[row] {
+			same = same && a[row][col] == b[row][col]
+			differs = differs || a[row][col] != c[row][col]
+		}
+	}
+	if !same || !differs {
+		t.Errorf("patterns should depend on the secret and serial alone")
+	}
+	if _, err := New([]byte("secret"), ""); err == nil {
+		t.Errorf("an empty serial should be rejected")
+	}
+}
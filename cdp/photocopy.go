@@ -0,0 +1,66 @@
+package cdp
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Photocopy simulates copying a label: the optics blur the fine detail of
+// the noise and the toner pushes every grey back to black or white. Each
+// generation copies the previous one, so a generation of one is like a
+// capture of a genuine print and two or more are like captures of copies.
+// The blur is scaled to the image, as if it were printed at the same size
+// each time.
+func Photocopy(img image.Image, generations int) *image.Gray {
+	bounds := img.Bounds()
+	copied := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			copied.Set(x, y, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	sigma := float64(min(bounds.Dx(), bounds.Dy())) / 160
+	for range generations {
+		copied = blur(copied, sigma)
+		for i, value := range copied.Pix {
+			// A steep S curve around mid-grey.
+			copied.Pix[i] = uint8(math.Round(255 / (1 + math.Exp(-(float64(value)-128)/18))))
+		}
+	}
+	return copied
+}
+
+// blur applies a Gaussian blur, horizontally and then vertically.
+func blur(img *image.Gray, sigma float64) *image.Gray {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	var total float64
+	for i := range kernel {
+		offset := float64(i - radius)
+		kernel[i] = math.Exp(-offset * offset / (2 * sigma * sigma))
+		total += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= total
+	}
+
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	pass := func(src *image.Gray, dx, dy int) *image.Gray {
+		dst := image.NewGray(src.Rect)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				var sum float64
+				for i, weight := range kernel {
+					// Pixels past the edge repeat the edge.
+					sx := min(width-1, max(0, x+(i-radius)*dx))
+					sy := min(height-1, max(0, y+(i-radius)*dy))
+					sum += weight * float64(src.GrayAt(sx, sy).Y)
+				}
+				dst.SetGray(x, y, color.Gray{uint8(math.Round(sum))})
+			}
+		}
+		return dst
+	}
+	return pass(pass(img, 1, 0), 0, 1)
+}
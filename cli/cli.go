@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"decode":   {"read the codes in an image or PDF, or report on a ZIP archive of images", decode},
+	"generate": {"write a QR code, and optionally its NDEF message, to files", generate},
+	"show":     {"display a QR code in the terminal", show},
+	"watch":    {"generate batches from data files dropped into folders", watch},
+}
+
+// Run executes the subcommand named by the first argument and returns the
+// process exit code. Without arguments the program serves HTTP instead.
+func Run(args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(os.Stderr)
+		return 2
+	}
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(output io.Writer) {
+	fmt.Fprintln(output, "usage: qr-code-generator [command] [flags] [field=value ...]")
+	fmt.Fprintln(output, "Without a command, the HTTP server listens on :8080.")
+	fmt.Fprintln(output, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(output, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
+
+// formFields reads name=value arguments into the same fields the HTTP
+// endpoints take.
+func formFields(args []string) (url.Values, error) {
+	form := url.Values{}
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected field=value, got %q", arg)
+		}
+		form.Add(name, value)
+	}
+	return form, nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"qr-code-generator/dataset"
+	"qr-code-generator/scan"
+)
+
+func decode(args []string) error {
+	flags := flag.NewFlagSet("decode", flag.ContinueOnError)
+	manifest := flags.String("manifest", "", "CSV, JSON or XLSX file with the expected content of each image, in file and content columns")
+	format := flags.String("format", "json", "report format for archives: json or csv")
+	output := flags.String("output", "", "file to write the report to, standard output by default")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: qr-code-generator decode [flags] file")
+		fmt.Fprintln(flags.Output(), "The file is a ZIP archive of images, reported on image by image, or a single PDF, PNG, JPEG or GIF file.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one file")
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q, expected json or csv", *format)
+	}
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if !strings.EqualFold(filepath.Ext(flags.Arg(0)), ".zip") {
+		if *manifest != "" {
+			return fmt.Errorf("a manifest can only be used with a ZIP archive")
+		}
+		codes, err := scan.File(data)
+		if err != nil {
+			return err
+		}
+		if codes == nil {
+			codes = []scan.Code{}
+		}
+		return writeJSON(out, codes)
+	}
+
+	var expected map[string]string
+	if *manifest != "" {
+		manifestData, err := os.ReadFile(*manifest)
+		if err != nil {
+			return err
+		}
+		table, err := dataset.Read(*manifest, manifestData)
+		if err != nil {
+			return fmt.Errorf("could not read the manifest: %v", err)
+		}
+		if expected, err = scan.Manifest(table); err != nil {
+			return err
+		}
+	}
+	entries, err := scan.Archive(data, expected)
+	if err != nil {
+		return err
+	}
+	if *format == "csv" {
+		err = scan.WriteCSV(out, entries)
+	} else {
+		err = writeJSON(out, entries)
+	}
+	if err != nil {
+		return err
+	}
+	// Failing when images do not match lets scripts check a batch.
+	failed := 0
+	for _, entry := range entries {
+		if entry.Match == scan.Mismatched || entry.Match == scan.Missing {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of the %d files in the manifest do not match", failed, len(expected))
+	}
+	return nil
+}
+
+func writeJSON(out io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
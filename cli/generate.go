@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"qr-code-generator/bitmap"
+	"qr-code-generator/logo"
+	"qr-code-generator/payloads"
+	"qr-code-generator/qrcode"
+)
+
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	size := flags.Int("size", 256, "width of the code in pixels")
+	symbology := flags.String("symbology", "", "qr, aztec or pdf417")
+	format := flags.String("format", "png", "png, ndef to also write the NDEF message, or bmp, bmp1, pbm, pgm, xbm, ico, tiff, zpl or escpos")
+	errorCorrection := flags.String("error-correction", "M", "QR code error correction: L, M, Q or H")
+	watermark := flags.String("watermark", "", "PNG image to place in the center")
+	logoText := flags.String("logo-text", "", "initials or a short word to place in the center instead of an image")
+	logoShape := flags.String("logo-shape", "circle", "plate behind the logo text: circle, rounded or square")
+	dithering := flags.String("dithering", "floyd-steinberg", "how 1-bit formats show a watermark: threshold, floyd-steinberg, atkinson or bayer")
+	threshold := flags.Uint("threshold", 128, "grey level below which watermark pixels turn black in 1-bit formats")
+	output := flags.String("output", "code.png", "file to write the code to, code.<format> by default for bitmap formats")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: qr-code-generator generate [flags] [field=value ...]")
+		fmt.Fprintln(flags.Output(), "Fields are those of /generate, such as type=wifi ssid=Home password=secret123.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	bitmapFormat, isBitmap := bitmap.Formats[*format]
+	if *format != "png" && *format != "ndef" && !isBitmap {
+		return fmt.Errorf("unknown format %q, expected png, ndef, bmp, bmp1, pbm, pgm, xbm, ico, tiff, zpl or escpos", *format)
+	}
+	outputSet := false
+	flags.Visit(func(f *flag.Flag) { outputSet = outputSet || f.Name == "output" })
+	if isBitmap && !outputSet {
+		*output = "code" + bitmapFormat.Extension
+	}
+
+	form, err := formFields(flags.Args())
+	if err != nil {
+		return err
+	}
+	content, err := payloads.Content(form)
+	if err != nil {
+		return fmt.Errorf("could not build the QR code content: %v", err)
+	}
+	if content == "" {
+		return fmt.Errorf("no content given, pass content=... or a type and its fields")
+	}
+
+	parsedSymbology, err := qrcode.ParseSymbology(*symbology)
+	if err != nil {
+		return err
+	}
+	level, err := qrcode.ParseErrorCorrection(*errorCorrection)
+	if err != nil {
+		return err
+	}
+	qrCode := &qrcode.SimpleQRCode{Content: content, Size: *size, Symbology: parsedSymbology, ErrorCorrection: level}
+
+	var watermarkData []byte
+	switch {
+	case *watermark != "" && *logoText != "":
+		return fmt.Errorf("give either -watermark or -logo-text, not both")
+	case *watermark != "":
+		if watermarkData, err = os.ReadFile(*watermark); err != nil {
+			return err
+		}
+	case *logoText != "":
+		shape, err := logo.ParseShape(*logoShape)
+		if err != nil {
+			return err
+		}
+		if watermarkData, err = (&logo.Text{Text: *logoText, Shape: shape}).PNG(); err != nil {
+			return fmt.Errorf("could not draw the text logo: %v", err)
+		}
+	}
+	if isBitmap {
+		if *threshold > 255 {
+			return fmt.Errorf("threshold must be a grey level from 0 to 255, got %d", *threshold)
+		}
+		options := bitmap.Options{Threshold: uint8(*threshold)}
+		if options.Dithering, err = bitmap.ParseDithering(*dithering); err != nil {
+			return err
+		}
+		options.Watermark = watermarkData
+		return writeBitmap(qrCode, bitmapFormat, options, *output)
+	}
+
+	var codeData []byte
+	if watermarkData != nil {
+		codeData, err = qrCode.GenerateWithWatermark(watermarkData)
+		if err != nil {
+			return err
+		}
+	} else {
+		codeData, err = qrCode.Generate()
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(*output, codeData, 0o644); err != nil {
+		return err
+	}
+
+	if *format == "ndef" {
+		message, err := payloads.NDEF(form, content)
+		if err != nil {
+			return fmt.Errorf("could not build the NDEF message: %v", err)
+		}
+		ndefData, err := message.Encode()
+		if err != nil {
+			return err
+		}
+		ndefPath := strings.TrimSuffix(*output, filepath.Ext(*output)) + ".ndef"
+		if err := os.WriteFile(ndefPath, ndefData, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBitmap writes the code in a bitmap format. XBM variables are named
+// after the output file. A watermarked code is read back, and a warning
+// printed if it no longer scans.
+func writeBitmap(qrCode *qrcode.SimpleQRCode, format bitmap.Format, options bitmap.Options, output string) error {
+	rendered, err := bitmap.Render(qrCode, format, options)
+	if err != nil {
+		return err
+	}
+	if options.Watermark != nil {
+		if err := qrCode.Verify(rendered); err != nil && !errors.Is(err, qrcode.ErrNotVerifiable) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+
+	encoded := bytes.NewBuffer(nil)
+	name := strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+	if err := format.Encode(encoded, rendered, name); err != nil {
+		return err
+	}
+	return os.WriteFile(output, encoded.Bytes(), 0o644)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"qr-code-generator/payloads"
+	"qr-code-generator/qrcode"
+	"qr-code-generator/terminal"
+)
+
+func show(args []string) error {
+	flags := flag.NewFlagSet("show", flag.ContinueOnError)
+	size := flags.Int("size", 256, "width of the code in pixels, for kitty and sixel")
+	symbology := flags.String("symbology", "", "qr, aztec or pdf417")
+	watermark := flags.String("watermark", "", "PNG image to place in the center")
+	protocol := flags.String("protocol", "auto", "auto, kitty, sixel or blocks")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: qr-code-generator show [flags] [field=value ...]")
+		fmt.Fprintln(flags.Output(), "Fields are those of /generate, such as type=wifi ssid=Home password=secret123.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// Detection queries the terminal, so it is left out when the protocol
+	// is given.
+	var chosen terminal.Protocol
+	if *protocol == "auto" {
+		chosen = terminal.Detect()
+	} else {
+		var err error
+		if chosen, err = terminal.ParseProtocol(*protocol); err != nil {
+			return err
+		}
+	}
+
+	form, err := formFields(flags.Args())
+	if err != nil {
+		return err
+	}
+	content, err := payloads.Content(form)
+	if err != nil {
+		return fmt.Errorf("could not build the QR code content: %v", err)
+	}
+	if content == "" {
+		return fmt.Errorf("no content given, pass content=... or a type and its fields")
+	}
+	parsedSymbology, err := qrcode.ParseSymbology(*symbology)
+	if err != nil {
+		return err
+	}
+	var watermarkData []byte
+	if *watermark != "" {
+		if watermarkData, err = os.ReadFile(*watermark); err != nil {
+			return err
+		}
+	}
+
+	// Blocks show one module per half cell, so the code is drawn at one
+	// pixel per module.
+	if chosen == terminal.Blocks {
+		qrCode := &qrcode.SimpleQRCode{Content: content, Size: -1, Symbology: parsedSymbology}
+		symbol, err := qrCode.Bitmap()
+		if err != nil {
+			return err
+		}
+		var img image.Image = symbol
+		if watermarkData != nil {
+			if img, err = qrCode.WatermarkImage(symbol, watermarkData); err != nil {
+				return err
+			}
+		}
+		return terminal.WriteBlocks(os.Stdout, img, terminal.Truecolor(os.Getenv))
+	}
+
+	qrCode := &qrcode.SimpleQRCode{Content: content, Size: *size, Symbology: parsedSymbology}
+	var codeData []byte
+	if watermarkData != nil {
+		codeData, err = qrCode.GenerateWithWatermark(watermarkData)
+	} else {
+		codeData, err = qrCode.Generate()
+	}
+	if err != nil {
+		return err
+	}
+	if chosen == terminal.Kitty {
+		return terminal.WriteKitty(os.Stdout, codeData)
+	}
+	img, err := png.Decode(bytes.NewReader(codeData))
+	if err != nil {
+		return err
+	}
+	return terminal.WriteSixel(os.Stdout, img)
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"qr-code-generator/batch"
+	"qr-code-generator/hotfolder"
+	"qr-code-generator/qrcode"
+)
+
+// folders collects -input flags, each of which may list several folders
+// separated by commas.
+type folders []string
+
+func (list *folders) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *folders) Set(value string) error {
+	for _, folder := range strings.Split(value, ",") {
+		if folder = strings.TrimSpace(folder); folder != "" {
+			*list = append(*list, folder)
+		}
+	}
+	return nil
+}
+
+func watch(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	var inputs folders
+	flags.Var(&inputs, "input", "folder to watch for .csv, .json and .xlsx files, repeatable")
+	output := flags.String("output", "", "folder to write the batch outputs to")
+	done := flags.String("done", "done", "folder for processed inputs, relative to each input folder")
+	failed := flags.String("failed", "failed", "folder for inputs that failed, relative to each input folder")
+	interval := flags.Duration("interval", 2*time.Second, "time between scans of the input folders")
+	format := flags.String("format", "zip", "zip, pdf or xlsx")
+	size := flags.Int("size", 256, "width of the codes in pixels")
+	symbology := flags.String("symbology", "", "qr, aztec or pdf417")
+	filename := flags.String("filename", "", "template naming the images in ZIP outputs")
+	label := flags.String("label", "", "template printed under each code on PDF sheets")
+	columns := flags.Int("columns", 0, "codes across a PDF sheet")
+	sheet := flags.String("sheet", "", "sheet of XLSX workbooks to read")
+	once := flags.Bool("once", false, "process the files already in the folders and exit")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: qr-code-generator watch -input folder -output folder [flags] [field=value ...]")
+		fmt.Fprintln(flags.Output(), "Fields are those of /batch, such as type=url url=https://example.com/{id}.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" {
+		return fmt.Errorf("-output is required")
+	}
+
+	fields, err := formFields(flags.Args())
+	if err != nil {
+		return err
+	}
+	parsedFormat, err := batch.ParseOutput(*format)
+	if err != nil {
+		return err
+	}
+	parsedSymbology, err := qrcode.ParseSymbology(*symbology)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := hotfolder.New(hotfolder.Config{
+		Inputs:   inputs,
+		Output:   *output,
+		Done:     *done,
+		Failed:   *failed,
+		Interval: *interval,
+		Format:   parsedFormat,
+		Batch: batch.Options{
+			Fields:    fields,
+			Filename:  *filename,
+			Label:     *label,
+			Size:      *size,
+			Symbology: parsedSymbology,
+			Columns:   *columns,
+		},
+		Sheet: *sheet,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *once {
+		// Files are only read once two scans agree on them.
+		for watcher.Scan() > 0 {
+			time.Sleep(*interval)
+		}
+		return nil
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "watching %s\n", inputs.String())
+	watcher.Run(ctx)
+	return nil
+}
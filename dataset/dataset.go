@@ -0,0 +1,137 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Table holds rows of named fields, read from a data file.
+type Table struct {
+	Columns []string
+	Rows    []Row
+}
+
+type Row map[string]string
+
+// Read parses data according to the extension of name: .csv, .json for an
+// array of objects, or .xlsx for the first sheet of a workbook with a header
+// row.
+func Read(name string, data []byte) (*Table, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return readCSV(data)
+	case ".json":
+		return readJSON(data)
+	case ".xlsx":
+		return ReadXLSX(data, "", true)
+	}
+	return nil, fmt.Errorf("data file %q must be .csv, .json or .xlsx", name)
+}
+
+func readCSV(data []byte) (*Table, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+	return fromRecords(records[0], records[1:])
+}
+
+// fromRecords builds a table from a header and rows of cells, skipping rows
+// that are entirely empty.
+func fromRecords(header []string, records [][]string) (*Table, error) {
+	table := &Table{}
+	seen := map[string]bool{}
+	for _, column := range header {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			return nil, fmt.Errorf("the header has an empty column name")
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("column %q appears more than once", column)
+		}
+		seen[column] = true
+		table.Columns = append(table.Columns, column)
+	}
+
+	for i, record := range records {
+		if len(record) > len(header) {
+			return nil, fmt.Errorf("row %d has %d fields, the header has %d", i+2, len(record), len(header))
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		row := Row{}
+		for j, column := range table.Columns {
+			if j < len(record) {
+				row[column] = record[j]
+			} else {
+				row[column] = ""
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+func readJSON(data []byte) (*Table, error) {
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("JSON data must be an array of objects: %v", err)
+	}
+
+	table := &Table{}
+	seen := map[string]bool{}
+	for _, object := range objects {
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		row := Row{}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				table.Columns = append(table.Columns, key)
+			}
+			switch value := object[key].(type) {
+			case nil:
+				row[key] = ""
+			case string:
+				row[key] = value
+			default:
+				encoded, _ := json.Marshal(value)
+				row[key] = string(encoded)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	for _, row := range table.Rows {
+		for _, column := range table.Columns {
+			if _, ok := row[column]; !ok {
+				row[column] = ""
+			}
+		}
+	}
+	return table, nil
+}
+
+// Expand replaces {column} in template with the values of row.
+func (row Row) Expand(template string) string {
+	replacements := make([]string, 0, len(row)*2)
+	for column, value := range row {
+		replacements = append(replacements, "{"+column+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
@@ -0,0 +1,304 @@
+package dataset
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+	DatePR struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (text xlsxText) String() string {
+	var result strings.Builder
+	result.WriteString(text.Text)
+	for _, run := range text.Runs {
+		result.WriteString(run.Text)
+	}
+	return result.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Style  int      `xml:"s,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxStyles struct {
+	NumberFormats []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellFormats []struct {
+		NumberFormat int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+// xlsxFile reads the parts of a workbook.
+type xlsxFile map[string]*zip.File
+
+// maxPartBytes limits each part of a workbook once unzipped, so that a small
+// zip bomb cannot exhaust memory. A sheet this size holds hundreds of
+// thousands of rows.
+const maxPartBytes = 64 << 20
+
+func (file xlsxFile) decode(name string, value interface{}) error {
+	entry, ok := file[name]
+	if !ok {
+		return fmt.Errorf("the workbook has no %s", name)
+	}
+	reader, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxPartBytes+1))
+	if err != nil {
+		return fmt.Errorf("could not unzip %s: %v", name, err)
+	}
+	if len(data) > maxPartBytes {
+		return fmt.Errorf("%s is larger than %d MB once unzipped", name, maxPartBytes>>20)
+	}
+	if err := xml.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("could not read %s: %v", name, err)
+	}
+	return nil
+}
+
+// ReadXLSX reads the named sheet, or the first one, of an Excel workbook.
+// Numbers are kept as Excel stores them and dates are written as
+// YYYY-MM-DD, with the time if there is one.
+func ReadXLSX(data []byte, sheet string, header bool) (*Table, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an XLSX workbook: %v", err)
+	}
+	file := xlsxFile{}
+	for _, entry := range reader.File {
+		file[entry.Name] = entry
+	}
+
+	var workbook xlsxWorkbook
+	if err := file.decode("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("the workbook has no sheets")
+	}
+	id := workbook.Sheets[0].ID
+	if sheet != "" {
+		id = ""
+		var names []string
+		for _, candidate := range workbook.Sheets {
+			names = append(names, candidate.Name)
+			if candidate.Name == sheet {
+				id = candidate.ID
+			}
+		}
+		if id == "" {
+			return nil, fmt.Errorf("the workbook has no sheet %q, only %s", sheet, strings.Join(names, ", "))
+		}
+	}
+
+	var relationships xlsxRelationships
+	if err := file.decode("xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+	var target string
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID == id {
+			target = relationship.Target
+		}
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := file["xl/sharedStrings.xml"]; ok {
+		if err := file.decode("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var styles xlsxStyles
+	if _, ok := file["xl/styles.xml"]; ok {
+		if err := file.decode("xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+	}
+	dateStyles := styles.dateStyles()
+
+	var worksheet xlsxWorksheet
+	if err := file.decode(target, &worksheet); err != nil {
+		return nil, err
+	}
+
+	var records [][]string
+	for _, row := range worksheet.Rows {
+		var record []string
+		for i, cell := range row.Cells {
+			column := i
+			if cell.Ref != "" {
+				if column, err = columnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", cell.Ref)
+				}
+				value = shared.Items[index].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = map[string]string{"0": "FALSE", "1": "TRUE"}[value]
+			case "", "n":
+				if dateStyles[cell.Style] && value != "" {
+					value = excelDate(value, workbook.DatePR.Date1904)
+				}
+			}
+			record[column] = value
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("sheet is empty")
+	}
+
+	width := 0
+	for _, record := range records {
+		width = max(width, len(record))
+	}
+	if header {
+		// Columns without a heading are named by their letter.
+		columns := make([]string, width)
+		for i := range columns {
+			columns[i] = ColumnName(i)
+			if i < len(records[0]) && strings.TrimSpace(records[0][i]) != "" {
+				columns[i] = records[0][i]
+			}
+		}
+		return fromRecords(columns, records[1:])
+	}
+	columns := make([]string, width)
+	for i := range columns {
+		columns[i] = ColumnName(i)
+	}
+	return fromRecords(columns, records)
+}
+
+var cellRefPattern = regexp.MustCompile(`^([A-Z]{1,3})\d*$`)
+
+func columnIndex(ref string) (int, error) {
+	match := cellRefPattern.FindStringSubmatch(ref)
+	if match == nil {
+		return 0, fmt.Errorf("%q is not a cell reference", ref)
+	}
+	index := 0
+	for _, letter := range match[1] {
+		index = index*26 + int(letter-'A'+1)
+	}
+	return index - 1, nil
+}
+
+// ColumnName returns the spreadsheet letters of a zero-based column index.
+func ColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+var (
+	dateCodePattern    = regexp.MustCompile(`[dmyhs]`)
+	literalCodePattern = regexp.MustCompile(`"[^"]*"|\\.|\[[^\]]*\]`)
+)
+
+// dateStyles returns the cell formats that show numbers as dates: built in
+// formats 14 to 22 and 45 to 47, and custom formats with date parts.
+func (styles xlsxStyles) dateStyles() map[int]bool {
+	custom := map[int]bool{}
+	for _, format := range styles.NumberFormats {
+		// Drop quoted text, escaped characters and colors before looking
+		// for date parts.
+		code := literalCodePattern.ReplaceAllString(strings.ToLower(format.Code), "")
+		custom[format.ID] = dateCodePattern.MatchString(code)
+	}
+
+	result := map[int]bool{}
+	for i, format := range styles.CellFormats {
+		id := format.NumberFormat
+		result[i] = (id >= 14 && id <= 22) || (id >= 45 && id <= 47) || custom[id]
+	}
+	return result
+}
+
+func excelDate(value string, date1904 bool) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 86400)
+	date := epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+	if seconds == 0 {
+		return date.Format("2006-01-02")
+	}
+	if days == 0 {
+		return date.Format("15:04:05")
+	}
+	return date.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,110 @@
+package design
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// ParseColor reads #rrggbb, or #rrggbbaa for a colour with transparency.
+func ParseColor(value string) (color.NRGBA, error) {
+	if !colorPattern.MatchString(value) {
+		return color.NRGBA{}, fmt.Errorf("colors must be given as #rrggbb or #rrggbbaa, got %q", value)
+	}
+	if len(value) == 7 {
+		value += "ff"
+	}
+	rgba, _ := strconv.ParseUint(value[1:], 16, 32)
+	return color.NRGBA{uint8(rgba >> 24), uint8(rgba >> 16), uint8(rgba >> 8), uint8(rgba)}, nil
+}
+
+// linear converts a colour to linear RGB from 0 to 1, ignoring transparency.
+func linear(c color.NRGBA) [3]float64 {
+	var rgb [3]float64
+	for i, channel := range []uint8{c.R, c.G, c.B} {
+		value := float64(channel) / 255
+		if value <= 0.04045 {
+			rgb[i] = value / 12.92
+		} else {
+			rgb[i] = math.Pow((value+0.055)/1.055, 2.4)
+		}
+	}
+	return rgb
+}
+
+// Luminance is the relative luminance of a colour, as defined by WCAG.
+func Luminance(c color.NRGBA) float64 {
+	rgb := linear(c)
+	return 0.2126*rgb[0] + 0.7152*rgb[1] + 0.0722*rgb[2]
+}
+
+// Contrast is the WCAG contrast ratio of two colours, from 1 for the same
+// luminance to 21 for black on white.
+func Contrast(a, b color.NRGBA) float64 {
+	lighter, darker := Luminance(a), Luminance(b)
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+	return (lighter + 0.05) / (darker + 0.05)
+}
+
+type Deficiency string
+
+const (
+	Protanopia   Deficiency = "protanopia"
+	Deuteranopia Deficiency = "deuteranopia"
+	Tritanopia   Deficiency = "tritanopia"
+	// Grayscale is how a camera that reads luma sees colours, which is
+	// also close to complete colour blindness.
+	Grayscale Deficiency = "grayscale"
+)
+
+var Deficiencies = []Deficiency{Protanopia, Deuteranopia, Tritanopia}
+
+// Simulation matrices for complete dichromacy from Machado, Oliveira and
+// Fernandes (2009), applied in linear RGB.
+var deficiencyMatrices = map[Deficiency][3][3]float64{
+	Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// Simulate shows a colour as someone with the deficiency sees it.
+func Simulate(c color.NRGBA, deficiency Deficiency) color.NRGBA {
+	if deficiency == Grayscale {
+		// Rec. 601 luma, computed from the gamma encoded values as camera
+		// pipelines do rather than from linear light.
+		luma := uint8(math.Round(0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)))
+		return color.NRGBA{luma, luma, luma, c.A}
+	}
+	matrix := deficiencyMatrices[deficiency]
+	rgb := linear(c)
+	var channels [3]uint8
+	for i, row := range matrix {
+		value := row[0]*rgb[0] + row[1]*rgb[1] + row[2]*rgb[2]
+		value = min(1, max(0, value))
+		if value <= 0.0031308 {
+			value *= 12.92
+		} else {
+			value = 1.055*math.Pow(value, 1/2.4) - 0.055
+		}
+		channels[i] = uint8(math.Round(value * 255))
+	}
+	return color.NRGBA{channels[0], channels[1], channels[2], c.A}
+}
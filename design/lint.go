@@ -0,0 +1,150 @@
+package design
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"qr-code-generator/qrcode"
+)
+
+// Design is what a code will look like: its symbol, in modules, and the
+// options it is styled with.
+type Design struct {
+	Symbology       qrcode.Symbology
+	ErrorCorrection qrcode.ErrorCorrection
+	Width, Height   int
+	Foreground      color.NRGBA
+	Background      color.NRGBA
+	// Logo is the width of a centred logo relative to the image, zero when
+	// there is none.
+	Logo float64
+}
+
+// Warning is a rule that the design breaks.
+type Warning struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// A rule returns a message explaining the problem, or nothing when the
+// design passes.
+type rule struct {
+	name  string
+	check func(*Design) string
+}
+
+var rules = []rule{
+	{"low-contrast", lowContrast},
+	{"inverted", inverted},
+	{"transparent-background", transparentBackground},
+	{"color-blind", colorBlind},
+	{"logo-coverage", logoCoverage},
+}
+
+// Scanners binarise the image, and below this contrast ratio the threshold
+// between modules and background becomes unreliable, particularly in poor
+// light.
+const minimumContrast = 4
+
+// Lint checks a design before it is rendered.
+func Lint(design *Design) []Warning {
+	warnings := []Warning{}
+	for _, rule := range rules {
+		if message := rule.check(design); message != "" {
+			warnings = append(warnings, Warning{rule.name, message})
+		}
+	}
+	return warnings
+}
+
+func lowContrast(design *Design) string {
+	if contrast := Contrast(design.Foreground, design.Background); contrast < minimumContrast {
+		return fmt.Sprintf("the colors have a contrast ratio of %.1f:1, and scanners need at least %d:1", contrast, minimumContrast)
+	}
+	return ""
+}
+
+func inverted(design *Design) string {
+	if Luminance(design.Foreground) > Luminance(design.Background) {
+		return "light modules on a dark background are inverted, which older scanners and some apps cannot read"
+	}
+	return ""
+}
+
+func transparentBackground(design *Design) string {
+	if design.Background.A < 0xff {
+		return "the background is transparent, so the contrast and the quiet zone depend on what the code is placed on"
+	}
+	return ""
+}
+
+// colorBlind looks for colours that scanners tell apart but people with a
+// colour vision deficiency may not, so that the code does not stand out.
+// Low contrast for everyone is left to lowContrast.
+func colorBlind(design *Design) string {
+	if Contrast(design.Foreground, design.Background) < minimumContrast {
+		return ""
+	}
+	var affected []string
+	for _, deficiency := range Deficiencies {
+		contrast := Contrast(Simulate(design.Foreground, deficiency), Simulate(design.Background, deficiency))
+		if contrast < minimumContrast {
+			affected = append(affected, fmt.Sprintf("%s (%.1f:1)", deficiency, contrast))
+		}
+	}
+	if len(affected) > 0 {
+		return "the colors lose contrast with " + strings.Join(affected, ", ")
+	}
+	return ""
+}
+
+// Error correction budgets: the share of codewords each level restores.
+// Aztec codes are drawn with 33% check words, which correct half as many
+// unknown errors, and PDF417 codes at level 4 have too few to spare.
+var budgets = map[qrcode.ErrorCorrection]float64{"L": 0.07, "M": 0.15, "Q": 0.25, "H": 0.30}
+
+const aztecBudget = 0.16
+
+func logoCoverage(design *Design) string {
+	if design.Logo == 0 {
+		return ""
+	}
+	side := design.Logo * float64(design.Width+2*design.Symbology.QuietZone())
+
+	var covered, budget float64
+	switch design.Symbology {
+	case qrcode.PDF417:
+		covered = min(side, float64(design.Width)) * min(side, float64(design.Height)) / float64(design.Width*design.Height)
+		return fmt.Sprintf("the logo covers %.0f%% of the symbol, and PDF417 codes have too little error correction to restore it", covered*100)
+	case qrcode.Aztec:
+		covered = min(side, float64(design.Width)) * min(side, float64(design.Height)) / float64(design.Width*design.Height)
+		budget = aztecBudget
+	default:
+		// QR codewords are two modules wide and four high, so a logo spoils
+		// every codeword its edges cut through as well as those beneath it.
+		covered = min(1, (side+1)*(side+3)/qrDataModules(design.Width))
+		budget = budgets[design.ErrorCorrection]
+	}
+	if covered > budget {
+		return fmt.Sprintf("the logo spoils about %.0f%% of the symbol's codewords, more than the %.0f%% its error correction restores",
+			covered*100, budget*100)
+	}
+	return ""
+}
+
+// qrDataModules estimates how many modules of a QR code carry codewords,
+// leaving out the finder, timing and alignment patterns and the format and
+// version information.
+func qrDataModules(width int) float64 {
+	version := (width - 17) / 4
+	modules := width*width - 3*64 - 2*(width-16) - 31
+	if version >= 2 {
+		side := version/7 + 2
+		modules -= 25 * (side*side - 3)
+	}
+	if version >= 7 {
+		modules -= 36
+	}
+	return float64(modules)
+}
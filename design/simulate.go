@@ -0,0 +1,66 @@
+package design
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Simulations are the views a design is checked in: each colour vision
+// deficiency and a grayscale camera.
+var Simulations = []Deficiency{Protanopia, Deuteranopia, Tritanopia, Grayscale}
+
+// SimulateImage shows a rendered code as it is seen with the deficiency.
+func SimulateImage(img image.Image, deficiency Deficiency) *image.NRGBA {
+	bounds := img.Bounds()
+	simulated := image.NewNRGBA(bounds)
+	// Codes have few distinct colours, so each is converted only once.
+	converted := map[color.NRGBA]color.NRGBA{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			result, ok := converted[c]
+			if !ok {
+				result = Simulate(c, deficiency)
+				converted[c] = result
+			}
+			simulated.SetNRGBA(x, y, result)
+		}
+	}
+	return simulated
+}
+
+// View is the contrast of a design's colours in one simulation.
+type View struct {
+	Name       Deficiency `json:"name"`
+	Foreground string     `json:"foreground"`
+	Background string     `json:"background"`
+	Contrast   float64    `json:"contrast"`
+	Sufficient bool       `json:"sufficient"`
+}
+
+// Views reports the effective contrast of the colours as they are seen
+// normally and in each simulation.
+func Views(foreground, background color.NRGBA) []View {
+	views := []View{view("normal", foreground, background)}
+	for _, deficiency := range Simulations {
+		views = append(views, view(deficiency, Simulate(foreground, deficiency), Simulate(background, deficiency)))
+	}
+	return views
+}
+
+func view(name Deficiency, foreground, background color.NRGBA) View {
+	contrast := Contrast(foreground, background)
+	return View{
+		Name:       name,
+		Foreground: hexColor(foreground),
+		Background: hexColor(background),
+		Contrast:   math.Round(contrast*100) / 100,
+		Sufficient: contrast >= minimumContrast,
+	}
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
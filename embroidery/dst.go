@@ -0,0 +1,91 @@
+package embroidery
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// A DST record moves at most 121 units, 12.1mm, along each axis.
+const maxMove = 121
+
+// DST writes the pattern as a Tajima DST file: a 512 byte text header and a
+// record of three bytes for each move. Longer stitches and jumps are split
+// into several records. The design starts from its centre, where machines
+// expect the needle to be at the start.
+func (pattern *Pattern) DST(label string) []byte {
+	var records bytes.Buffer
+	count := 0
+	minX, minY, maxX, maxY := 0, 0, 0, 0
+	x, y := 0, 0
+	for _, stitch := range pattern.Stitches {
+		dx, dy := stitch.X-pattern.Width/2-x, stitch.Y-pattern.Height/2-y
+		steps := max(1, (max(abs(dx), abs(dy))+maxMove-1)/maxMove)
+		for i := 1; i <= steps; i++ {
+			nx, ny := x+dx*i/steps, y+dy*i/steps
+			px, py := x+dx*(i-1)/steps, y+dy*(i-1)/steps
+			records.Write(dstRecord(nx-px, ny-py, stitch.Jump))
+			count++
+		}
+		x, y = x+dx, y+dy
+		minX, minY = min(minX, x), min(minY, y)
+		maxX, maxY = max(maxX, x), max(maxY, y)
+	}
+	records.Write([]byte{0, 0, 0xf3})
+
+	var header bytes.Buffer
+	if len(label) > 16 {
+		label = label[:16]
+	}
+	fmt.Fprintf(&header, "LA:%-16s\r", label)
+	fmt.Fprintf(&header, "ST:%7d\r", count)
+	fmt.Fprintf(&header, "CO:%3d\r", 0)
+	// Extents are measured from the start, with y pointing up.
+	fmt.Fprintf(&header, "+X:%5d\r-X:%5d\r+Y:%5d\r-Y:%5d\r", maxX, -minX, -minY, maxY)
+	fmt.Fprintf(&header, "AX:+%5d\rAY:+%5d\rMX:+%5d\rMY:+%5d\rPD:******\r", x, -y, 0, 0)
+	header.WriteByte(0x1a)
+	for header.Len() < 512 {
+		header.WriteByte(' ')
+	}
+	return append(header.Bytes(), records.Bytes()...)
+}
+
+// dstRecord encodes a move in balanced ternary: each axis is the sum of
+// ±1, ±3, ±9, ±27 and ±81, each with its own bit. DST's y axis points up.
+func dstRecord(dx, dy int, jump bool) []byte {
+	var b [3]byte
+	dy = -dy
+	type digit struct {
+		value       int
+		byteIndex   int
+		plus, minus byte
+	}
+	xDigits := []digit{{81, 2, 0x04, 0x08}, {27, 1, 0x04, 0x08}, {9, 0, 0x04, 0x08}, {3, 1, 0x01, 0x02}, {1, 0, 0x01, 0x02}}
+	yDigits := []digit{{81, 2, 0x20, 0x10}, {27, 1, 0x20, 0x10}, {9, 0, 0x20, 0x10}, {3, 1, 0x80, 0x40}, {1, 0, 0x80, 0x40}}
+	encode := func(value int, digits []digit) {
+		for _, d := range digits {
+			half := d.value / 2
+			switch {
+			case value > half:
+				b[d.byteIndex] |= d.plus
+				value -= d.value
+			case value < -half:
+				b[d.byteIndex] |= d.minus
+				value += d.value
+			}
+		}
+	}
+	encode(dx, xDigits)
+	encode(dy, yDigits)
+	b[2] |= 0x03
+	if jump {
+		b[2] |= 0x80
+	}
+	return b[:]
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
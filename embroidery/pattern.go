@@ -0,0 +1,229 @@
+// Package embroidery turns a code's modules into machine embroidery stitches
+// and writes them as Tajima DST files.
+package embroidery
+
+import (
+	"fmt"
+	"math"
+)
+
+// Coordinates are in tenths of a millimetre, the unit of DST files, with y
+// growing downwards like the module matrix.
+type Stitch struct {
+	X, Y int
+	Jump bool
+}
+
+type Pattern struct {
+	Stitches []Stitch
+	// Width and Height are the size of the code with its quiet zone.
+	Width, Height int
+	// Blocks is the number of separately sewn areas, each reached by a
+	// jump.
+	Blocks int
+}
+
+type Options struct {
+	// Size is the width of the code with its quiet zone, in millimetres.
+	Size float64
+	// Density is the spacing of satin and fill lines in millimetres.
+	Density float64
+}
+
+const (
+	// Areas narrower than this are sewn in satin stitch across their short
+	// side; wider ones are filled with rows of running stitches.
+	maxSatinWidth = 70
+	// Fill stitches are at most this long, and each row is offset by a
+	// third of it so that needle holes do not line up.
+	fillStitch = 30
+	// Modules smaller than a millimetre cannot be sewn legibly.
+	minModule = 10
+)
+
+// rect is an area of dark modules, in modules, with exclusive ends.
+type rect struct{ x0, y0, x1, y1 int }
+
+// FromModules sews the dark modules of a symbol. Runs of dark modules along
+// a row become one area, and runs of the same columns in consecutive rows
+// are merged into a single rectangle, so that each is sewn without jumps.
+func FromModules(modules [][]bool, quietZone int, options Options) (*Pattern, error) {
+	if options.Density < 0.2 || options.Density > 2 {
+		return nil, fmt.Errorf("density must be between 0.2 and 2mm, got %g", options.Density)
+	}
+	width, height := len(modules[0])+2*quietZone, len(modules)+2*quietZone
+	module := options.Size * 10 / float64(width)
+	if module < minModule {
+		return nil, fmt.Errorf("at %gmm the modules are %.2fmm; embroidered modules need at least 1mm", options.Size, module/10)
+	}
+
+	var rects []rect
+	open := map[[2]int]int{}
+	for y, row := range modules {
+		next := map[[2]int]int{}
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			key := [2]int{start, x}
+			if i, ok := open[key]; ok {
+				rects[i].y1 = y + 1
+				next[key] = i
+			} else {
+				next[key] = len(rects)
+				rects = append(rects, rect{start, y, x, y + 1})
+			}
+		}
+		open = next
+	}
+
+	scale := func(modules int) int {
+		return int(math.Round(float64(modules+quietZone) * module))
+	}
+	spacing := int(math.Round(options.Density * 10))
+	var blocks [][]Stitch
+	for _, r := range rects {
+		left, top := scale(r.x0), scale(r.y0)
+		right, bottom := scale(r.x1), scale(r.y1)
+		blocks = append(blocks, sewRect(left, top, right, bottom, spacing))
+	}
+
+	pattern := &Pattern{
+		Width:  int(math.Round(float64(width) * module)),
+		Height: int(math.Round(float64(height) * module)),
+		Blocks: len(blocks),
+	}
+	pattern.Stitches = order(blocks)
+	return pattern, nil
+}
+
+// sewRect fills a rectangle with satin stitch, or with rows of running
+// stitches when both sides are wider than satin can span, and ties the
+// thread off at both ends.
+func sewRect(left, top, right, bottom, spacing int) []Stitch {
+	var stitches []Stitch
+	add := func(x, y int) { stitches = append(stitches, Stitch{X: x, Y: y}) }
+
+	// Satin spacing is from peak to peak, so each stitch advances half of
+	// it.
+	advance := func(i int) int {
+		return int(math.Round(float64(i) * float64(spacing) / 2))
+	}
+	width, height := right-left, bottom-top
+	switch {
+	case height <= maxSatinWidth && width >= height:
+		// Zigzag between the top and bottom edges, moving right.
+		for i := 0; ; i++ {
+			x := min(left+advance(i), right)
+			if i%2 == 0 {
+				add(x, top)
+			} else {
+				add(x, bottom)
+			}
+			if x == right {
+				break
+			}
+		}
+	case width <= maxSatinWidth:
+		// Zigzag between the left and right edges, moving down.
+		for i := 0; ; i++ {
+			y := min(top+advance(i), bottom)
+			if i%2 == 0 {
+				add(left, y)
+			} else {
+				add(right, y)
+			}
+			if y == bottom {
+				break
+			}
+		}
+	default:
+		for row, y := 0, top; y <= bottom; row, y = row+1, y+spacing {
+			offset := row % 3 * fillStitch / 3
+			var xs []int
+			xs = append(xs, left)
+			for x := left + fillStitch - offset; x < right; x += fillStitch {
+				xs = append(xs, x)
+			}
+			xs = append(xs, right)
+			if row%2 == 1 {
+				for i, j := 0, len(xs)-1; i < j; i, j = i+1, j-1 {
+					xs[i], xs[j] = xs[j], xs[i]
+				}
+			}
+			for _, x := range xs {
+				add(x, y)
+			}
+		}
+	}
+	return tieOff(stitches)
+}
+
+// tieOff adds two short stitches back and forth at each end, which lock the
+// thread so it does not pull out when the jump to the next area is cut.
+func tieOff(stitches []Stitch) []Stitch {
+	first, last := stitches[0], stitches[len(stitches)-1]
+	lock := func(s Stitch, toward Stitch) []Stitch {
+		dx, dy := sign(toward.X-s.X)*3, sign(toward.Y-s.Y)*3
+		return []Stitch{s, {X: s.X + dx, Y: s.Y + dy}, s}
+	}
+	var result []Stitch
+	if len(stitches) > 1 {
+		result = append(result, lock(first, stitches[1])...)
+		result = append(result, stitches[1:len(stitches)-1]...)
+		result = append(result, lock(last, stitches[len(stitches)-2])...)
+		return result
+	}
+	return stitches
+}
+
+func sign(value int) int {
+	switch {
+	case value > 0:
+		return 1
+	case value < 0:
+		return -1
+	}
+	return 0
+}
+
+// order sews the blocks starting from the top left, each time moving to the
+// nearest end of a remaining block, which is sewn backwards if that end is
+// nearer. Moves between blocks are jumps.
+func order(blocks [][]Stitch) []Stitch {
+	var result []Stitch
+	done := make([]bool, len(blocks))
+	x, y := 0, 0
+	for range blocks {
+		best, reverse, bestDistance := -1, false, math.MaxFloat64
+		for i, block := range blocks {
+			if done[i] {
+				continue
+			}
+			first, last := block[0], block[len(block)-1]
+			if d := math.Hypot(float64(first.X-x), float64(first.Y-y)); d < bestDistance {
+				best, reverse, bestDistance = i, false, d
+			}
+			if d := math.Hypot(float64(last.X-x), float64(last.Y-y)); d < bestDistance {
+				best, reverse, bestDistance = i, true, d
+			}
+		}
+		done[best] = true
+		block := append([]Stitch(nil), blocks[best]...)
+		if reverse {
+			for i, j := 0, len(block)-1; i < j; i, j = i+1, j-1 {
+				block[i], block[j] = block[j], block[i]
+			}
+		}
+		// The block's first stitch, at the end of the jump, is where the
+		// needle first goes down.
+		result = append(result, Stitch{X: block[0].X, Y: block[0].Y, Jump: true})
+		result = append(result, block...)
+		x, y = block[len(block)-1].X, block[len(block)-1].Y
+	}
+	return result
+}
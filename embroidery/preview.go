@@ -0,0 +1,59 @@
+package embroidery
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+var (
+	threadColour = color.RGBA{0x1a, 0x23, 0x7e, 0xff}
+	// Jumps are light enough that a preview of a code still scans.
+	jumpColour = color.RGBA{0xf4, 0x8f, 0xb1, 0xff}
+)
+
+// Preview draws the stitch paths at the given width in pixels: stitches as
+// thread about 0.4mm thick and jumps as thin pink lines. The first jump,
+// from the start in the centre, is left out.
+func (pattern *Pattern) Preview(width int) image.Image {
+	scale := float64(width) / float64(pattern.Width)
+	height := int(math.Ceil(float64(pattern.Height) * scale))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	// Jumps are drawn first, so that they only show where they cross the
+	// fabric.
+	thread := max(1, 4*scale)
+	for _, jumps := range []bool{true, false} {
+		x, y := pattern.Stitches[0].X, pattern.Stitches[0].Y
+		for _, stitch := range pattern.Stitches {
+			switch {
+			case stitch.Jump && jumps:
+				drawLine(img, float64(x)*scale, float64(y)*scale, float64(stitch.X)*scale, float64(stitch.Y)*scale, 1, jumpColour)
+			case !stitch.Jump && !jumps:
+				drawLine(img, float64(x)*scale, float64(y)*scale, float64(stitch.X)*scale, float64(stitch.Y)*scale, thread, threadColour)
+			}
+			x, y = stitch.X, stitch.Y
+		}
+	}
+	return img
+}
+
+// drawLine stamps squares of the given thickness along a line.
+func drawLine(img *image.RGBA, x0, y0, x1, y1, thickness float64, c color.RGBA) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	half := thickness / 2
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		cx, cy := x0+(x1-x0)*t, y0+(y1-y0)*t
+		for py := int(cy - half); py <= int(cy+half); py++ {
+			for px := int(cx - half); px <= int(cx+half); px++ {
+				if image.Pt(px, py).In(img.Rect) {
+					img.SetRGBA(px, py, c)
+				}
+			}
+		}
+	}
+}
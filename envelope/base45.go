@@ -0,0 +1,58 @@
+package envelope
+
+import (
+	"fmt"
+	"strings"
+)
+
+// base45Alphabet is the QR code alphanumeric character set, in the order
+// RFC 9285 gives the digits of base 45.
+const base45Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// EncodeBase45 encodes each pair of bytes as three characters, and a last
+// odd byte as two, least significant digit first.
+func EncodeBase45(data []byte) string {
+	var encoded strings.Builder
+	for i := 0; i+1 < len(data); i += 2 {
+		value := int(data[i])<<8 | int(data[i+1])
+		encoded.WriteByte(base45Alphabet[value%45])
+		encoded.WriteByte(base45Alphabet[value/45%45])
+		encoded.WriteByte(base45Alphabet[value/45/45])
+	}
+	if len(data)%2 == 1 {
+		value := int(data[len(data)-1])
+		encoded.WriteByte(base45Alphabet[value%45])
+		encoded.WriteByte(base45Alphabet[value/45])
+	}
+	return encoded.String()
+}
+
+func DecodeBase45(text string) ([]byte, error) {
+	if len(text)%3 == 1 {
+		return nil, fmt.Errorf("base45 text cannot be %d characters long", len(text))
+	}
+	digits := make([]int, len(text))
+	for i := range text {
+		digits[i] = strings.IndexByte(base45Alphabet, text[i])
+		if digits[i] < 0 {
+			return nil, fmt.Errorf("%q is not a base45 character", text[i])
+		}
+	}
+	decoded := make([]byte, 0, len(text)/3*2+1)
+	for i := 0; i < len(digits); i += 3 {
+		if i+2 >= len(digits) {
+			value := digits[i] + digits[i+1]*45
+			if value > 0xff {
+				return nil, fmt.Errorf("base45 text ends in %q, which is more than a byte", text[i:])
+			}
+			decoded = append(decoded, byte(value))
+			break
+		}
+		value := digits[i] + digits[i+1]*45 + digits[i+2]*45*45
+		if value > 0xffff {
+			return nil, fmt.Errorf("base45 characters %q are more than two bytes", text[i:i+3])
+		}
+		decoded = append(decoded, byte(value>>8), byte(value))
+	}
+	return decoded, nil
+}
@@ -0,0 +1,137 @@
+// Package envelope packs structured data, such as JSON, into QR code
+// content compactly. The data is compressed behind a header byte and the
+// result encoded in base45, whose characters are exactly those of the
+// alphanumeric mode, which stores them in 5.5 bits each rather than the 8
+// of byte mode.
+package envelope
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Algorithm is the compression in the low four bits of the header byte.
+type Algorithm byte
+
+const (
+	Deflate Algorithm = 1
+	Zstd    Algorithm = 2
+)
+
+// Version is the envelope format, in the high four bits of the header byte.
+const Version = 1
+
+// maxDecoded bounds the size of decompressed data, since a few hundred
+// bytes of a QR code can expand to far more.
+const maxDecoded = 1 << 20
+
+var algorithmNames = map[string]Algorithm{"deflate": Deflate, "zstd": Zstd}
+
+func ParseAlgorithm(name string) (Algorithm, error) {
+	if algorithm, ok := algorithmNames[name]; ok {
+		return algorithm, nil
+	}
+	return 0, fmt.Errorf("compression must be deflate or zstd, got %q", name)
+}
+
+func (algorithm Algorithm) String() string {
+	for name, value := range algorithmNames {
+		if value == algorithm {
+			return name
+		}
+	}
+	return fmt.Sprintf("algorithm %d", byte(algorithm))
+}
+
+// Encode compresses data and returns the envelope as base45 text.
+func Encode(data []byte, algorithm Algorithm) (string, error) {
+	packed := bytes.NewBuffer([]byte{Version<<4 | byte(algorithm)})
+	switch algorithm {
+	case Deflate:
+		writer, err := flate.NewWriter(packed, flate.BestCompression)
+		if err != nil {
+			return "", err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return "", fmt.Errorf("could not compress the data: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			return "", fmt.Errorf("could not compress the data: %v", err)
+		}
+	case Zstd:
+		encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderCRC(false))
+		if err != nil {
+			return "", err
+		}
+		packed.Write(encoder.EncodeAll(data, nil))
+		encoder.Close()
+	default:
+		return "", fmt.Errorf("unknown compression %d", algorithm)
+	}
+	return EncodeBase45(packed.Bytes()), nil
+}
+
+// Decode reverses Encode, checking the header byte.
+func Decode(text string) ([]byte, Algorithm, error) {
+	packed, err := DecodeBase45(text)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(packed) == 0 {
+		return nil, 0, fmt.Errorf("the envelope is empty")
+	}
+	if version := packed[0] >> 4; version != Version {
+		return nil, 0, fmt.Errorf("unsupported envelope version %d", version)
+	}
+	algorithm, body := Algorithm(packed[0]&0x0f), packed[1:]
+
+	var reader io.Reader
+	switch algorithm {
+	case Deflate:
+		reader = flate.NewReader(bytes.NewReader(body))
+	case Zstd:
+		decoder, err := zstd.NewReader(bytes.NewReader(body), zstd.WithDecoderMaxMemory(maxDecoded))
+		if err != nil {
+			return nil, 0, err
+		}
+		defer decoder.Close()
+		reader = decoder
+	default:
+		return nil, 0, fmt.Errorf("unknown compression %d", algorithm)
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxDecoded+1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not decompress the %s data: %v", algorithm, err)
+	}
+	if len(data) > maxDecoded {
+		return nil, 0, fmt.Errorf("the data is larger than %d bytes once decompressed", maxDecoded)
+	}
+	return data, algorithm, nil
+}
+
+// Savings compares the bits of the data in a QR code's byte mode with those
+// of its envelope in alphanumeric mode, leaving out the mode and length
+// indicators both have.
+type Savings struct {
+	OriginalBytes int
+	OriginalBits  int
+	Characters    int
+	Bits          int
+	Percent       float64
+}
+
+func Compare(data []byte, envelope string) Savings {
+	original := 8 * len(data)
+	encoded := 11*(len(envelope)/2) + 6*(len(envelope)%2)
+	return Savings{
+		OriginalBytes: len(data),
+		OriginalBits:  original,
+		Characters:    len(envelope),
+		Bits:          encoded,
+		Percent:       100 * float64(original-encoded) / float64(max(1, original)),
+	}
+}
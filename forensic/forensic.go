@@ -0,0 +1,266 @@
+// Package forensic hides an identifier in a rendered code, so that a copy
+// found elsewhere can be traced back to the request that produced it.
+//
+// The identifier is spread over the whole image: the image is divided into
+// a grid of cells, independent of its size, and each bit is carried by a
+// dozen or more cells scattered by a secret key, each made slightly lighter
+// or darker. A few grey levels are invisible and far below what a scanner's
+// threshold notices, but summed over every pixel of the cells they survive
+// JPEG recompression and rescaling.
+package forensic
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"math/rand/v2"
+	"unicode/utf8"
+
+	"github.com/makiuchi-d/gozxing/common/reedsolomon"
+)
+
+const (
+	// grid is the number of cells across and down the image.
+	grid = 64
+	// amplitude is how far each cell is moved, in levels of 255.
+	amplitude = 3
+	// outlier is how far from the dark or light level a pixel can be and
+	// still count. Further out it is part of a logo or caption, not a
+	// module.
+	outlier = 6 * amplitude
+	// MaxLength is the longest identifier, in bytes.
+	MaxLength = 16
+	// MinSize is the smallest image, in pixels, that can carry a mark.
+	MinSize = 2 * grid
+	// A length byte, the identifier padded to MaxLength and a checksum,
+	// followed by Reed-Solomon check bytes that correct the few bits a
+	// heavily compressed or shrunk copy loses.
+	dataBytes   = 1 + MaxLength + 4
+	checkBytes  = 10
+	payloadBits = (dataBytes + checkBytes) * 8
+)
+
+var ErrNotFound = errors.New("no forensic watermark was found")
+
+// Validate checks that an identifier fits in a mark.
+func Validate(id string) error {
+	switch {
+	case id == "":
+		return fmt.Errorf("the identifier is empty")
+	case len(id) > MaxLength:
+		return fmt.Errorf("the identifier must be at most %d bytes, got %d", MaxLength, len(id))
+	case !utf8.ValidString(id):
+		return fmt.Errorf("the identifier must be UTF-8 text")
+	}
+	return nil
+}
+
+// layout assigns every cell to a bit of the payload, and gives it the sign
+// the bit is multiplied by. Without the key the cells cannot be found, so
+// the mark can be neither read nor deliberately cancelled.
+type layout struct {
+	bit  [grid * grid]int
+	sign [grid * grid]int
+}
+
+func newLayout(key []byte) *layout {
+	seed := sha256.Sum256(key)
+	random := rand.New(rand.NewChaCha8(seed))
+	cells := &layout{}
+	for i, cell := range random.Perm(grid * grid) {
+		cells.bit[cell] = i % payloadBits
+		cells.sign[cell] = 2*random.IntN(2) - 1
+	}
+	return cells
+}
+
+func cellOf(bounds image.Rectangle, x, y int) int {
+	row := (y - bounds.Min.Y) * grid / bounds.Dy()
+	col := (x - bounds.Min.X) * grid / bounds.Dx()
+	return row*grid + col
+}
+
+func encodePayload(id string) []int {
+	data := make([]byte, 1+MaxLength, dataBytes)
+	data[0] = byte(len(id))
+	copy(data[1:], id)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+	codewords := make([]int, dataBytes+checkBytes)
+	for i, b := range data {
+		codewords[i] = int(b)
+	}
+	reedsolomon.NewReedSolomonEncoder(reedsolomon.GenericGF_QR_CODE_FIELD_256).Encode(codewords, checkBytes)
+
+	bits := make([]int, payloadBits)
+	for i := range bits {
+		bits[i] = codewords[i/8] >> (7 - i%8) & 1
+	}
+	return bits
+}
+
+func decodePayload(bits []int) (string, bool) {
+	codewords := make([]int, dataBytes+checkBytes)
+	for i, bit := range bits {
+		codewords[i/8] |= bit << (7 - i%8)
+	}
+	if err := reedsolomon.NewReedSolomonDecoder(reedsolomon.GenericGF_QR_CODE_FIELD_256).Decode(codewords, checkBytes); err != nil {
+		return "", false
+	}
+	data := make([]byte, dataBytes)
+	for i := range data {
+		data[i] = byte(codewords[i])
+	}
+	body, checksum := data[:1+MaxLength], data[1+MaxLength:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(checksum) || int(body[0]) > MaxLength {
+		return "", false
+	}
+	return string(body[1 : 1+body[0]]), true
+}
+
+// Embed marks a copy of the image with the identifier. Colours are first
+// pulled in from black and white by the amplitude, so that every cell can
+// move both ways.
+func Embed(img image.Image, id string, key []byte) (*image.NRGBA, error) {
+	if err := Validate(id); err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() < MinSize || bounds.Dy() < MinSize {
+		return nil, fmt.Errorf("the image must be at least %d pixels across to carry a forensic watermark", MinSize)
+	}
+	cells := newLayout(key)
+	bits := encodePayload(id)
+
+	marked := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			cell := cellOf(bounds, x, y)
+			shift := float64(amplitude * cells.sign[cell] * (2*bits[cells.bit[cell]] - 1))
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			marked.SetNRGBA(x, y, color.NRGBA{
+				shiftChannel(c.R, shift), shiftChannel(c.G, shift), shiftChannel(c.B, shift), c.A,
+			})
+		}
+	}
+	return marked, nil
+}
+
+func shiftChannel(value uint8, shift float64) uint8 {
+	pulled := amplitude + float64(value)*(255-2*amplitude)/255
+	return uint8(pulled + shift + 0.5)
+}
+
+// Extract reads the identifier back from a marked image, which may have
+// been recompressed or resized since.
+//
+// Each pixel is compared with the average of the dark or light pixels it
+// belongs to, which leaves the mark and noise. Pixels at the edges of
+// modules are left out, since compression and resampling smear them far
+// more than the mark moves them.
+func Extract(img image.Image, key []byte) (string, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() < grid || bounds.Dy() < grid {
+		return "", fmt.Errorf("the image must be at least %d pixels across", grid)
+	}
+	width, height := bounds.Dx(), bounds.Dy()
+	luma := make([]float64, width*height)
+	var total float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray := color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+			luma[y*width+x] = float64(gray.Y)
+			total += float64(gray.Y)
+		}
+	}
+
+	// Two rounds of splitting at the midpoint of the class averages find
+	// the threshold between dark and light whatever the colours. The levels
+	// themselves are taken from where each class is densest, since the
+	// blended edges of modules in a resized copy pull averages away.
+	threshold := total / float64(len(luma))
+	for range 2 {
+		var darkSum, lightSum, darkCount, lightCount float64
+		for _, value := range luma {
+			if value < threshold {
+				darkSum, darkCount = darkSum+value, darkCount+1
+			} else {
+				lightSum, lightCount = lightSum+value, lightCount+1
+			}
+		}
+		threshold = (darkSum/max(1, darkCount) + lightSum/max(1, lightCount)) / 2
+	}
+	dark, light := level(luma, 0, threshold), level(luma, threshold, 256)
+
+	isDark := func(x, y int) bool { return luma[y*width+x] < threshold }
+	var residuals, counts [grid * grid]float64
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			class := isDark(x, y)
+			if isDark(x-1, y) != class || isDark(x+1, y) != class || isDark(x, y-1) != class || isDark(x, y+1) != class ||
+				isDark(x-1, y-1) != class || isDark(x+1, y+1) != class || isDark(x+1, y-1) != class || isDark(x-1, y+1) != class {
+				continue
+			}
+			residual := luma[y*width+x] - light
+			if class {
+				residual = luma[y*width+x] - dark
+			}
+			if residual > outlier || residual < -outlier {
+				continue
+			}
+			cell := cellOf(bounds, bounds.Min.X+x, bounds.Min.Y+y)
+			residuals[cell] += residual
+			counts[cell]++
+		}
+	}
+
+	cells := newLayout(key)
+	sums := make([]float64, payloadBits)
+	for cell := range residuals {
+		if counts[cell] > 0 {
+			sums[cells.bit[cell]] += float64(cells.sign[cell]) * residuals[cell] / counts[cell]
+		}
+	}
+	bits := make([]int, payloadBits)
+	for i, sum := range sums {
+		if sum > 0 {
+			bits[i] = 1
+		}
+	}
+	id, ok := decodePayload(bits)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return id, nil
+}
+
+// level is the grey level that most of the values from low up to high
+// are near: the mean of the values in the densest window twice the
+// amplitude either side, which holds both the lighter and the darker
+// cells of a colour.
+func level(values []float64, low, high float64) float64 {
+	var histogram [256]int
+	for _, value := range values {
+		if value >= low && value < high {
+			histogram[int(value)]++
+		}
+	}
+	window := func(centre int) (sum, count int) {
+		for value := max(0, centre-2*amplitude); value <= min(255, centre+2*amplitude); value++ {
+			sum += value * histogram[value]
+			count += histogram[value]
+		}
+		return sum, count
+	}
+	best, bestCount := 0, -1
+	for centre := range histogram {
+		if _, count := window(centre); count > bestCount {
+			best, bestCount = centre, count
+		}
+	}
+	sum, count := window(best)
+	return float64(sum) / float64(max(1, count))
+}
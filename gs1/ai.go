@@ -0,0 +1,215 @@
+package gs1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type charset int
+
+const (
+	numeric charset = iota
+	alphanumeric
+)
+
+type aiSpec struct {
+	title string
+	// Fixed length values have min == max.
+	min, max int
+	charset  charset
+	// Number of leading digits protected by a GS1 mod 10 check digit.
+	checked int
+	date    bool
+	// Number of leading digits that must be numeric in alphanumeric values,
+	// such as the GDTI in AI 253.
+	numericPrefix int
+}
+
+func fixed(title string, length int) aiSpec {
+	return aiSpec{title: title, min: length, max: length, charset: numeric}
+}
+
+func checked(title string, length int) aiSpec {
+	return aiSpec{title: title, min: length, max: length, charset: numeric, checked: length}
+}
+
+func date(title string) aiSpec {
+	return aiSpec{title: title, min: 6, max: 6, charset: numeric, date: true}
+}
+
+func digits(title string, max int) aiSpec {
+	return aiSpec{title: title, min: 1, max: max, charset: numeric}
+}
+
+func text(title string, max int) aiSpec {
+	return aiSpec{title: title, min: 1, max: max, charset: alphanumeric}
+}
+
+var applicationIdentifiers = map[string]aiSpec{
+	"00":   checked("SSCC", 18),
+	"01":   checked("GTIN", 14),
+	"02":   checked("CONTENT", 14),
+	"10":   text("BATCH/LOT", 20),
+	"11":   date("PROD DATE"),
+	"12":   date("DUE DATE"),
+	"13":   date("PACK DATE"),
+	"15":   date("BEST BEFORE or BEST BY"),
+	"16":   date("SELL BY"),
+	"17":   date("USE BY OR EXPIRY"),
+	"20":   fixed("VARIANT", 2),
+	"21":   text("SERIAL", 20),
+	"22":   text("CPV", 20),
+	"235":  text("TPX", 28),
+	"240":  text("ADDITIONAL ID", 30),
+	"241":  text("CUST. PART No.", 30),
+	"242":  digits("MTO VARIANT", 6),
+	"243":  text("PCN", 20),
+	"250":  text("SECONDARY SERIAL", 30),
+	"251":  text("REF. TO SOURCE", 30),
+	"253":  {title: "GDTI", min: 13, max: 30, charset: alphanumeric, checked: 13, numericPrefix: 13},
+	"254":  text("GLN EXTENSION COMPONENT", 20),
+	"255":  {title: "GCN", min: 13, max: 25, charset: numeric, checked: 13},
+	"30":   digits("VAR. COUNT", 8),
+	"37":   digits("COUNT", 8),
+	"400":  text("ORDER NUMBER", 30),
+	"401":  text("GINC", 30),
+	"402":  checked("GSIN", 17),
+	"403":  text("ROUTE", 30),
+	"410":  checked("SHIP TO LOC", 13),
+	"411":  checked("BILL TO", 13),
+	"412":  checked("PURCHASE FROM", 13),
+	"413":  checked("SHIP FOR LOC", 13),
+	"414":  checked("LOC No.", 13),
+	"415":  checked("PAY TO", 13),
+	"416":  checked("PROD/SERV LOC", 13),
+	"417":  checked("PARTY", 13),
+	"420":  text("SHIP TO POST", 20),
+	"421":  {title: "SHIP TO POST", min: 4, max: 12, charset: alphanumeric, numericPrefix: 3},
+	"422":  fixed("ORIGIN", 3),
+	"7040": {title: "UIC+EXT", min: 4, max: 4, charset: alphanumeric},
+	"8003": {title: "GRAI", min: 14, max: 30, charset: alphanumeric, checked: 14, numericPrefix: 14},
+	"8004": text("GIAI", 30),
+	"8006": fixed("ITIP", 18),
+	"8010": text("CPID", 30),
+	"8011": digits("CPID SERIAL", 12),
+	"8013": text("GMN", 25),
+	"8017": checked("GSRN - PROVIDER", 18),
+	"8018": checked("GSRN - RECIPIENT", 18),
+	"8019": digits("SRIN", 10),
+	"8020": text("REF No.", 25),
+	"8200": text("PRODUCT URL", 70),
+	"90":   text("INTERNAL", 30),
+}
+
+func init() {
+	for i := 1; i <= 9; i++ {
+		applicationIdentifiers[fmt.Sprintf("9%d", i)] = text("INTERNAL", 90)
+	}
+}
+
+// Measurement and amount AIs carry the implied decimal point position as
+// their last digit, as in 3103 for a net weight in kilograms with three
+// decimals.
+var decimalIdentifiers = []struct {
+	pattern *regexp.Regexp
+	spec    aiSpec
+}{
+	{regexp.MustCompile(`^3[1-6][0-9][0-9]$`), fixed("MEASURE", 6)},
+	{regexp.MustCompile(`^390[0-9]$`), digits("AMOUNT", 15)},
+	{regexp.MustCompile(`^391[0-9]$`), aiSpec{title: "AMOUNT", min: 4, max: 18, charset: numeric}},
+	{regexp.MustCompile(`^392[0-9]$`), digits("PRICE", 15)},
+	{regexp.MustCompile(`^393[0-9]$`), aiSpec{title: "PRICE", min: 4, max: 18, charset: numeric}},
+}
+
+// Element strings only need a separator after variable length fields. The
+// set of AI prefixes with a predefined length is fixed by the GS1 General
+// Specifications and does not change when new AIs are added.
+var predefinedLengths = map[string]int{
+	"00": 20, "01": 16, "02": 16, "03": 16, "04": 18,
+	"11": 8, "12": 8, "13": 8, "14": 8, "15": 8, "16": 8, "17": 8, "18": 8, "19": 8,
+	"20": 4, "31": 10, "32": 10, "33": 10, "34": 10, "35": 10, "36": 10, "41": 16,
+}
+
+func lookup(ai string) (aiSpec, bool) {
+	if spec, ok := applicationIdentifiers[ai]; ok {
+		return spec, true
+	}
+	for _, decimal := range decimalIdentifiers {
+		if decimal.pattern.MatchString(ai) {
+			return decimal.spec, true
+		}
+	}
+	return aiSpec{}, false
+}
+
+func Title(ai string) string {
+	spec, _ := lookup(ai)
+	return spec.title
+}
+
+var encodable = regexp.MustCompile(`^[!"%&'()*+,\-./0-9:;<=>?A-Z_a-z]*$`)
+
+func validate(ai, value string) error {
+	spec, ok := lookup(ai)
+	if !ok {
+		return fmt.Errorf("unknown application identifier (%s)", ai)
+	}
+
+	if len(value) < spec.min || len(value) > spec.max {
+		if spec.min == spec.max {
+			return fmt.Errorf("(%s) must be %d characters, got %d", ai, spec.min, len(value))
+		}
+		return fmt.Errorf("(%s) must be %d to %d characters, got %d", ai, spec.min, spec.max, len(value))
+	}
+
+	switch {
+	case spec.charset == numeric && strings.Trim(value, "0123456789") != "":
+		return fmt.Errorf("(%s) must be numeric, got %q", ai, value)
+	case !encodable.MatchString(value):
+		return fmt.Errorf("(%s) contains characters outside the GS1 character set", ai)
+	case spec.numericPrefix > 0 && strings.Trim(value[:spec.numericPrefix], "0123456789") != "":
+		return fmt.Errorf("(%s) must start with %d digits", ai, spec.numericPrefix)
+	}
+
+	if spec.checked > 0 && !validCheckDigit(value[:spec.checked]) {
+		return fmt.Errorf("(%s) %s has an invalid check digit", ai, value[:spec.checked])
+	}
+
+	if spec.date {
+		if err := validateDate(value); err != nil {
+			return fmt.Errorf("(%s) %v", ai, err)
+		}
+	}
+
+	return nil
+}
+
+// Dates are YYMMDD; a day of 00 means the last day of the month.
+func validateDate(value string) error {
+	if value[4:] == "00" {
+		value = value[:4] + "01"
+	}
+	if _, err := time.Parse("060102", value); err != nil {
+		return fmt.Errorf("%q is not a valid YYMMDD date", value)
+	}
+	return nil
+}
+
+// CheckDigit computes the GS1 mod 10 check digit for the given digits.
+func CheckDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func validCheckDigit(digits string) bool {
+	return CheckDigit(digits[:len(digits)-1]) == digits[len(digits)-1]
+}
@@ -0,0 +1,92 @@
+package gs1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Element struct {
+	AI    string `json:"ai"`
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type Builder struct {
+	elements []Element
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// GTIN accepts GTIN-8, GTIN-12, GTIN-13 and GTIN-14 and pads them to the 14
+// digits used in element strings and Digital Link URIs.
+func (builder *Builder) GTIN(gtin string) *Builder {
+	gtin = strings.TrimSpace(gtin)
+	if len(gtin) < 14 {
+		gtin = strings.Repeat("0", 14-len(gtin)) + gtin
+	}
+	return builder.Add("01", gtin)
+}
+
+func (builder *Builder) Batch(batch string) *Builder {
+	return builder.Add("10", batch)
+}
+
+func (builder *Builder) Expiry(expiry time.Time) *Builder {
+	return builder.Add("17", expiry.Format("060102"))
+}
+
+func (builder *Builder) Serial(serial string) *Builder {
+	return builder.Add("21", serial)
+}
+
+func (builder *Builder) Add(ai, value string) *Builder {
+	builder.elements = append(builder.elements, Element{AI: ai, Title: Title(ai), Value: value})
+	return builder
+}
+
+func (builder *Builder) Elements() ([]Element, error) {
+	if len(builder.elements) == 0 {
+		return nil, fmt.Errorf("no application identifiers were given")
+	}
+
+	seen := map[string]bool{}
+	for _, element := range builder.elements {
+		if seen[element.AI] {
+			return nil, fmt.Errorf("(%s) is given more than once", element.AI)
+		}
+		seen[element.AI] = true
+
+		if err := validate(element.AI, element.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.elements, nil
+}
+
+func (builder *Builder) ElementString() (string, error) {
+	elements, err := builder.Elements()
+	if err != nil {
+		return "", err
+	}
+	return ElementString(elements), nil
+}
+
+func (builder *Builder) HumanReadable() (string, error) {
+	elements, err := builder.Elements()
+	if err != nil {
+		return "", err
+	}
+	return HumanReadable(elements), nil
+}
+
+func (builder *Builder) DigitalLink(domain string) (string, error) {
+	elements, err := builder.Elements()
+	if err != nil {
+		return "", err
+	}
+	return DigitalLink(domain, elements)
+}
@@ -0,0 +1,160 @@
+package gs1
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const DefaultDomain = "https://id.gs1.org"
+
+// Primary keys that may start a Digital Link path, with the key qualifiers
+// that may follow them in the order they must appear.
+var primaryKeys = map[string][]string{
+	"01":   {"22", "10", "21"},
+	"00":   nil,
+	"253":  nil,
+	"255":  nil,
+	"401":  nil,
+	"402":  nil,
+	"414":  {"254", "7040"},
+	"417":  {"7040"},
+	"8003": nil,
+	"8004": {"7040"},
+	"8006": {"22", "10", "21"},
+	"8010": {"8011"},
+	"8013": nil,
+	"8017": {"8019"},
+	"8018": {"8019"},
+}
+
+// Short names from Digital Link 1.0, still accepted when parsing.
+var legacyNames = map[string]string{
+	"gtin": "01", "itip": "8006", "gmn": "8013", "cpid": "8010", "shipTo": "410",
+	"billTo": "411", "purchasedFrom": "412", "shipFor": "413", "gln": "414",
+	"payTo": "415", "glnProd": "416", "gsrnp": "8017", "gsrn": "8018",
+	"gcn": "255", "sscc": "00", "gdti": "253", "ginc": "401", "gsin": "402",
+	"grai": "8003", "giai": "8004", "cpv": "22", "lot": "10", "ser": "21",
+	"cpsn": "8011", "glnx": "254", "srin": "8019", "exp": "17", "bestBefore": "15",
+	"sellBy": "16", "prodDate": "11",
+}
+
+func DigitalLink(domain string, elements []Element) (string, error) {
+	if domain == "" {
+		domain = DefaultDomain
+	}
+	domain = strings.TrimSuffix(domain, "/")
+
+	values := map[string]string{}
+	for _, element := range elements {
+		values[element.AI] = element.Value
+	}
+
+	var primary string
+	for _, element := range elements {
+		if _, ok := primaryKeys[element.AI]; ok {
+			primary = element.AI
+			break
+		}
+	}
+	if primary == "" {
+		return "", fmt.Errorf("a Digital Link needs a primary key such as a GTIN (01) or SSCC (00)")
+	}
+
+	path := []string{domain, primary, url.PathEscape(values[primary])}
+	inPath := map[string]bool{primary: true}
+	for _, qualifier := range primaryKeys[primary] {
+		if value, ok := values[qualifier]; ok {
+			path = append(path, qualifier, url.PathEscape(value))
+			inPath[qualifier] = true
+		}
+	}
+
+	var params []string
+	for _, element := range elements {
+		if inPath[element.AI] {
+			continue
+		}
+		if _, ok := primaryKeys[element.AI]; ok {
+			return "", fmt.Errorf("(%s) and (%s) are both primary keys", primary, element.AI)
+		}
+		params = append(params, element.AI+"="+url.QueryEscape(element.Value))
+	}
+
+	link := strings.Join(path, "/")
+	if len(params) > 0 {
+		link += "?" + strings.Join(params, "&")
+	}
+	return link, nil
+}
+
+// ParseDigitalLink extracts the elements from a Digital Link URI. The domain
+// and any path prefix before the primary key are ignored, as are query
+// parameters that are not AIs.
+func ParseDigitalLink(link string) ([]Element, error) {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse Digital Link: %v", err)
+	}
+
+	segments := strings.Split(strings.Trim(parsed.EscapedPath(), "/"), "/")
+	start := -1
+	for i := 0; i+1 < len(segments); i++ {
+		if _, ok := primaryKeys[resolveName(segments[i])]; ok && (len(segments)-i)%2 == 0 {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("no primary key found in %q", parsed.Path)
+	}
+
+	primary := resolveName(segments[start])
+	builder := NewBuilder()
+	for i := start; i < len(segments); i += 2 {
+		ai := resolveName(segments[i])
+		if i > start && !isQualifier(primary, ai) {
+			return nil, fmt.Errorf("(%s) is not a key qualifier of (%s)", ai, primary)
+		}
+		value, err := url.PathUnescape(segments[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("could not decode (%s): %v", ai, err)
+		}
+		if ai == "01" && len(value) < 14 {
+			builder.GTIN(value)
+			continue
+		}
+		builder.Add(ai, value)
+	}
+
+	for _, param := range strings.Split(parsed.RawQuery, "&") {
+		name, value, _ := strings.Cut(param, "=")
+		ai := resolveName(name)
+		if _, ok := lookup(ai); !ok {
+			continue
+		}
+		value, err := url.QueryUnescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode (%s): %v", ai, err)
+		}
+		builder.Add(ai, value)
+	}
+
+	return builder.Elements()
+}
+
+func resolveName(segment string) string {
+	if ai, ok := legacyNames[segment]; ok {
+		return ai
+	}
+	return segment
+}
+
+func isQualifier(primary, ai string) bool {
+	for _, qualifier := range primaryKeys[primary] {
+		if qualifier == ai {
+			return true
+		}
+	}
+	return false
+}
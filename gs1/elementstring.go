@@ -0,0 +1,112 @@
+package gs1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GroupSeparator terminates variable length fields in an element string. It
+// is what scanners transmit in place of an FNC1 that is not in first
+// position.
+const GroupSeparator = "\x1d"
+
+// ElementString concatenates the elements for encoding in FNC1 mode. Fields
+// with a predefined length are moved to the front so that as few separators
+// as possible are needed.
+func ElementString(elements []Element) string {
+	ordered := append([]Element(nil), elements...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return hasPredefinedLength(ordered[i].AI) && !hasPredefinedLength(ordered[j].AI)
+	})
+
+	var result strings.Builder
+	for i, element := range ordered {
+		result.WriteString(element.AI)
+		result.WriteString(element.Value)
+		if i < len(ordered)-1 && !hasPredefinedLength(element.AI) {
+			result.WriteString(GroupSeparator)
+		}
+	}
+	return result.String()
+}
+
+func HumanReadable(elements []Element) string {
+	var result strings.Builder
+	for _, element := range elements {
+		fmt.Fprintf(&result, "(%s)%s", element.AI, element.Value)
+	}
+	return result.String()
+}
+
+func hasPredefinedLength(ai string) bool {
+	_, ok := predefinedLengths[ai[:2]]
+	return ok
+}
+
+// ParseElementString splits an element string, as transmitted by a scanner,
+// back into its elements. A leading symbology identifier such as "]Q3" and a
+// leading FNC1 are ignored.
+func ParseElementString(data string) ([]Element, error) {
+	for _, prefix := range []string{"]Q3", "]C1", "]d2", "]e0"} {
+		data = strings.TrimPrefix(data, prefix)
+	}
+	data = strings.TrimPrefix(data, GroupSeparator)
+
+	var elements []Element
+	for len(data) > 0 {
+		ai, err := matchAI(data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[len(ai):]
+
+		spec, _ := lookup(ai)
+		var value string
+		if length, ok := predefinedLengths[ai[:2]]; ok {
+			length -= len(ai)
+			if len(data) < length {
+				return nil, fmt.Errorf("(%s) is truncated", ai)
+			}
+			value, data = data[:length], data[length:]
+		} else {
+			end := strings.Index(data, GroupSeparator)
+			if end < 0 {
+				end = len(data)
+			}
+			if end > spec.max {
+				end = spec.max
+			}
+			value, data = data[:end], data[end:]
+		}
+		data = strings.TrimPrefix(data, GroupSeparator)
+
+		if err := validate(ai, value); err != nil {
+			return nil, err
+		}
+		elements = append(elements, Element{AI: ai, Title: spec.title, Value: value})
+	}
+
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("element string is empty")
+	}
+	return elements, nil
+}
+
+// AIs are prefix free, so the first length that matches a known AI is the
+// right one.
+func matchAI(data string) (string, error) {
+	for length := 2; length <= 4 && length <= len(data); length++ {
+		if _, ok := lookup(data[:length]); ok {
+			return data[:length], nil
+		}
+	}
+	return "", fmt.Errorf("unknown application identifier at %q", truncate(data, 8))
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length] + "..."
+}
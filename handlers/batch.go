@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/batch"
+	"qr-code-generator/dataset"
+	"qr-code-generator/qrcode"
+)
+
+func HandleBatch(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(32 << 20)
+
+	data, header, err := uploadedFile(request, "data")
+	if err != nil {
+		writeError(writer, 400, "Could not read the uploaded data file.")
+		return
+	}
+	var table *dataset.Table
+	if strings.ToLower(path.Ext(header.Filename)) == ".xlsx" {
+		table, err = dataset.ReadXLSX(data, request.FormValue("sheet"), request.FormValue("header") != "false")
+	} else {
+		table, err = dataset.Read(header.Filename, data)
+	}
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not read the data. %v", err))
+		return
+	}
+	if len(table.Rows) == 0 {
+		writeError(writer, 400, "The data file has no rows.")
+		return
+	}
+
+	output, err := batch.ParseOutput(request.FormValue("output"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the output. %v", err))
+		return
+	}
+	options := batch.Options{
+		Fields:   request.Form,
+		Filename: request.FormValue("filename"),
+		Label:    request.FormValue("label"),
+	}
+	if options.Size, err = strconv.Atoi(formDefault(request, "size", "256")); err != nil {
+		writeError(writer, 400, "Could not determine the desired QR code size.")
+		return
+	}
+	if columns := request.FormValue("columns"); columns != "" {
+		if options.Columns, err = strconv.Atoi(columns); err != nil {
+			writeError(writer, 400, "Could not determine the number of columns of the sheet.")
+			return
+		}
+	}
+	if options.Symbology, err = qrcode.ParseSymbology(request.FormValue("symbology")); err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the desired symbology. %v", err))
+		return
+	}
+
+	codes, err := batch.Generate(table, options)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not generate the codes. %v", err))
+		return
+	}
+	result, err := batch.Write(output, table, codes, options)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not write the %s output. %v", output, err))
+		return
+	}
+
+	name := strings.TrimSuffix(header.Filename, path.Ext(header.Filename)) + "-codes." + string(output)
+	writer.Header().Set("Content-Type", output.ContentType())
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	writer.Write(result)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"qr-code-generator/bcbp"
+	"qr-code-generator/qrcode"
+)
+
+func HandleBoardingPass(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	qrCodeSize, err := strconv.Atoi(request.FormValue("size"))
+	if err != nil {
+		writeError(writer, 400, "Could not determine the desired QR code size.")
+		return
+	}
+
+	symbology, err := qrcode.ParseSymbology(request.FormValue("symbology"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the desired symbology. %v", err))
+		return
+	}
+
+	pass := &bcbp.BoardingPass{}
+	if err := json.Unmarshal([]byte(request.FormValue("pass")), pass); err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not read the boarding pass. %v", err))
+		return
+	}
+
+	content, err := pass.Encode()
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Invalid boarding pass. %v", err))
+		return
+	}
+
+	qrCode := &qrcode.SimpleQRCode{Content: content, Size: qrCodeSize, Symbology: symbology}
+	codeData, err := qrCode.Generate()
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not generate the boarding pass code. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "image/png")
+	writer.Write(codeData)
+}
+
+func HandleBoardingPassParse(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	pass, err := bcbp.Parse(request.FormValue("data"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not parse the boarding pass. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(pass)
+}
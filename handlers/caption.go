@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/png"
+	"net/http"
+
+	"qr-code-generator/caption"
+	"qr-code-generator/qrcode"
+)
+
+// captionCode adds the caption form value under a PNG code, in the code's
+// colours. The code is returned as it is when there is no caption.
+func captionCode(request *http.Request, code *qrcode.SimpleQRCode, codeData []byte) ([]byte, error) {
+	text := request.FormValue("caption")
+	if text == "" {
+		return codeData, nil
+	}
+	img, err := png.Decode(bytes.NewReader(codeData))
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the QR code. %v", err)
+	}
+	var foreground, background color.Color = color.Black, color.White
+	if code.Foreground != nil {
+		foreground = code.Foreground
+	}
+	if code.Background != nil {
+		background = code.Background
+	}
+	captioned, err := caption.Below(img, text, foreground, background)
+	if err != nil {
+		return nil, fmt.Errorf("Could not draw the caption. %v", err)
+	}
+	encoded := bytes.NewBuffer(nil)
+	if err := png.Encode(encoded, captioned); err != nil {
+		return nil, fmt.Errorf("Could not encode the QR code. %v", err)
+	}
+	return encoded.Bytes(), nil
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"strconv"
+
+	"qr-code-generator/cdp"
+	"qr-code-generator/payloads"
+	"qr-code-generator/qrcode"
+)
+
+const cdpSecretVariable = "CDP_SECRET"
+
+const maxPhotocopies = 5
+
+// requestPattern derives the copy-detection pattern for the serial form
+// value from the secret in CDP_SECRET.
+func requestPattern(request *http.Request) (cdp.Pattern, int, error) {
+	secret := os.Getenv(cdpSecretVariable)
+	if secret == "" {
+		return nil, 500, fmt.Errorf("Copy-detection patterns are not configured, set %s.", cdpSecretVariable)
+	}
+	pattern, err := cdp.New([]byte(secret), request.FormValue("serial"))
+	if err != nil {
+		return nil, 400, fmt.Errorf("Could not make the copy-detection pattern. %v", err)
+	}
+	return pattern, 0, nil
+}
+
+// requestPhotocopies reads how many generations of photocopying to
+// simulate, none by default.
+func requestPhotocopies(request *http.Request) (int, error) {
+	generations, err := strconv.Atoi(formDefault(request, "photocopy", "0"))
+	if err != nil || generations < 0 || generations > maxPhotocopies {
+		return 0, fmt.Errorf("Photocopy must be a number of copies from 0 to %d.", maxPhotocopies)
+	}
+	return generations, nil
+}
+
+// HandleCopyDetection renders an anti-counterfeit label: a code with the
+// copy-detection pattern for its serial number beside it. With photocopy
+// it returns the label as it would look after that many copies.
+func HandleCopyDetection(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	pattern, status, err := requestPattern(request)
+	if err != nil {
+		writeError(writer, status, err.Error())
+		return
+	}
+	generations, err := requestPhotocopies(request)
+	if err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+	content, err := payloads.Content(request.Form)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not build the QR code content. %v", err))
+		return
+	}
+	if content == "" {
+		writeError(writer, 400, "Could not determine the desired QR code content.")
+		return
+	}
+	size, err := strconv.Atoi(formDefault(request, "size", "600"))
+	if err != nil {
+		writeError(writer, 400, "Could not determine the desired QR code size.")
+		return
+	}
+	level, err := qrcode.ParseErrorCorrection(request.FormValue("error_correction"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the error correction. %v", err))
+		return
+	}
+
+	code := &qrcode.SimpleQRCode{Content: content, Size: size, ErrorCorrection: level}
+	codeData, err := code.Generate()
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not generate QR code. %v", err))
+		return
+	}
+	rendered, err := png.Decode(bytes.NewReader(codeData))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not read the QR code. %v", err))
+		return
+	}
+	var label image.Image
+	label, err = cdp.Label(rendered, pattern)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not draw the label. %v", err))
+		return
+	}
+	if generations > 0 {
+		label = cdp.Photocopy(label, generations)
+	}
+
+	encoded := bytes.NewBuffer(nil)
+	if err := png.Encode(encoded, label); err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not encode the label. %v", err))
+		return
+	}
+	writer.Header().Set("Content-Type", "image/png")
+	writer.Write(encoded.Bytes())
+}
+
+type verification struct {
+	Score     float64 `json:"score"`
+	Threshold float64 `json:"threshold"`
+	Genuine   bool    `json:"genuine"`
+}
+
+// HandleCopyDetectionVerify scores a capture of a label, or of its pattern
+// alone, against the pattern expected for the serial number.
+func HandleCopyDetectionVerify(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	pattern, status, err := requestPattern(request)
+	if err != nil {
+		writeError(writer, status, err.Error())
+		return
+	}
+	generations, err := requestPhotocopies(request)
+	if err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+	threshold, err := strconv.ParseFloat(formDefault(request, "threshold", strconv.FormatFloat(cdp.Threshold, 'f', -1, 64)), 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		writeError(writer, 400, "Threshold must be a score above 0 and up to 1.")
+		return
+	}
+	data, _, err := uploadedFile(request, "image")
+	if err != nil {
+		writeError(writer, 400, "Could not read the uploaded capture.")
+		return
+	}
+	capture, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not decode the capture, expected a PNG or JPEG. %v", err))
+		return
+	}
+	if generations > 0 {
+		capture = cdp.Photocopy(capture, generations)
+	}
+
+	score, err := cdp.Score(capture, pattern)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not find the pattern in the capture. %v", err))
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(verification{score, threshold, score >= threshold})
+}
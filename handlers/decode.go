@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"qr-code-generator/dataset"
+	"qr-code-generator/scan"
+)
+
+type decodeResult struct {
+	Codes []scan.Code `json:"codes"`
+}
+
+// HandleDecode reads the codes in an uploaded image or PDF document and
+// recognises their payloads, such as the payment slips of inbound invoices.
+func HandleDecode(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	data, _, err := uploadedFile(request, "file")
+	if err != nil {
+		writeError(writer, 400, "Could not read the uploaded file.")
+		return
+	}
+	codes, err := scan.File(data)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not read codes from the file. %v", err))
+		return
+	}
+	if codes == nil {
+		codes = []scan.Code{}
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(decodeResult{codes})
+}
+
+type bulkDecodeResult struct {
+	Entries []scan.Entry `json:"entries"`
+}
+
+// HandleBulkDecode reads every image in an uploaded ZIP archive, such as
+// photos of printed labels, and reports their codes and print quality as
+// JSON or CSV. An optional manifest gives the content each file should
+// have.
+func HandleBulkDecode(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(32 << 20)
+
+	format := formDefault(request, "format", "json")
+	if format != "json" && format != "csv" {
+		writeError(writer, 400, fmt.Sprintf("Format must be json or csv, got %q.", format))
+		return
+	}
+	archive, _, err := uploadedFile(request, "archive")
+	if err != nil {
+		writeError(writer, 400, "Could not read the uploaded ZIP archive.")
+		return
+	}
+	var expected map[string]string
+	if manifestData, manifestHeader, err := uploadedFile(request, "manifest"); err == nil {
+		table, err := dataset.Read(manifestHeader.Filename, manifestData)
+		if err != nil {
+			writeError(writer, 400, fmt.Sprintf("Could not read the manifest. %v", err))
+			return
+		}
+		if expected, err = scan.Manifest(table); err != nil {
+			writeError(writer, 400, fmt.Sprintf("Could not read the manifest. %v", err))
+			return
+		}
+	}
+
+	entries, err := scan.Archive(archive, expected)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not decode the archive. %v", err))
+		return
+	}
+	if format == "csv" {
+		writer.Header().Set("Content-Type", "text/csv")
+		writer.Header().Set("Content-Disposition", `attachment; filename="decoded.csv"`)
+		scan.WriteCSV(writer, entries)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(bulkDecodeResult{entries})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strconv"
+
+	"qr-code-generator/embroidery"
+	"qr-code-generator/payloads"
+	"qr-code-generator/qrcode"
+)
+
+func HandleEmbroidery(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	content, err := payloads.Content(request.Form)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not build the QR code content. %v", err))
+		return
+	}
+	if content == "" {
+		writeError(writer, 400, "Could not determine the desired QR code content.")
+		return
+	}
+	symbology, err := qrcode.ParseSymbology(request.FormValue("symbology"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the desired symbology. %v", err))
+		return
+	}
+
+	var options embroidery.Options
+	for _, length := range []struct {
+		name, defaultValue string
+		value              *float64
+	}{
+		{"size", "50", &options.Size},
+		{"density", "0.4", &options.Density},
+	} {
+		if *length.value, err = strconv.ParseFloat(formDefault(request, length.name, length.defaultValue), 64); err != nil {
+			writeError(writer, 400, fmt.Sprintf("Could not determine %s, which is given in millimetres.", length.name))
+			return
+		}
+	}
+	previewWidth, err := strconv.Atoi(formDefault(request, "preview_width", "1000"))
+	if err != nil || previewWidth < 100 || previewWidth > 4000 {
+		writeError(writer, 400, "Preview width must be from 100 to 4000 pixels.")
+		return
+	}
+
+	code := &qrcode.SimpleQRCode{Content: content, Symbology: symbology}
+	modules, err := code.Modules()
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not generate QR code. %v", err))
+		return
+	}
+	pattern, err := embroidery.FromModules(modules, symbology.QuietZone(), options)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not build the stitches. %v", err))
+		return
+	}
+
+	preview := func() []byte {
+		encoded := bytes.NewBuffer(nil)
+		png.Encode(encoded, pattern.Preview(previewWidth))
+		return encoded.Bytes()
+	}
+	switch request.FormValue("output") {
+	case "", "dst":
+		writer.Header().Set("Content-Type", "application/octet-stream")
+		writer.Header().Set("Content-Disposition", `attachment; filename="code.dst"`)
+		writer.Write(pattern.DST("QR code"))
+	case "preview":
+		writer.Header().Set("Content-Type", "image/png")
+		writer.Write(preview())
+	case "zip":
+		writeArchive(writer, "embroidery.zip", []archiveFile{
+			{"code.dst", pattern.DST("QR code")},
+			{"preview.png", preview()},
+		})
+	default:
+		writeError(writer, 400, "Output must be dst, preview or zip.")
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"qr-code-generator/envelope"
+)
+
+// envelopeContent packs the content into a compressed envelope when the
+// envelope form value names a compression, and reports the savings in
+// X-Envelope-Savings. JSON content is compacted first.
+func envelopeContent(writer http.ResponseWriter, request *http.Request, content string) (string, error) {
+	name := request.FormValue("envelope")
+	if name == "" {
+		return content, nil
+	}
+	algorithm, err := envelope.ParseAlgorithm(name)
+	if err != nil {
+		return "", fmt.Errorf("Could not determine the envelope. %v", err)
+	}
+	data := []byte(content)
+	compact := bytes.NewBuffer(nil)
+	if json.Compact(compact, data) == nil {
+		data = compact.Bytes()
+	}
+	packed, err := envelope.Encode(data, algorithm)
+	if err != nil {
+		return "", fmt.Errorf("Could not build the envelope. %v", err)
+	}
+	savings := envelope.Compare([]byte(content), packed)
+	change := fmt.Sprintf("%.0f%% fewer", savings.Percent)
+	if savings.Percent < 0 {
+		change = fmt.Sprintf("%.0f%% more", -savings.Percent)
+	}
+	writer.Header().Set("X-Envelope-Savings", fmt.Sprintf(
+		"%d bytes take %d bits in byte mode, the %d character envelope takes %d bits in alphanumeric mode, %s",
+		savings.OriginalBytes, savings.OriginalBits, savings.Characters, savings.Bits, change,
+	))
+	return packed, nil
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+
+	"qr-code-generator/forensic"
+)
+
+const forensicKeyVariable = "FORENSIC_WATERMARK_KEY"
+
+// forensicID is the identifier to hide in the code: forensic_id when given,
+// such as a tenant or order, or a random request ID with forensic=true.
+// It is empty when no forensic watermark was asked for.
+func forensicID(request *http.Request) (string, error) {
+	if id := request.FormValue("forensic_id"); id != "" {
+		return id, forensic.Validate(id)
+	}
+	if request.FormValue("forensic") != "true" {
+		return "", nil
+	}
+	random := make([]byte, forensic.MaxLength/2)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
+
+func forensicKey() ([]byte, error) {
+	key := os.Getenv(forensicKeyVariable)
+	if key == "" {
+		return nil, fmt.Errorf("Forensic watermarks are not configured, set %s.", forensicKeyVariable)
+	}
+	return []byte(key), nil
+}
+
+// markCode hides the forensic identifier in a PNG code and reports it in
+// X-Forensic-ID, so that it can be recorded against the request. The code
+// is returned as it is when no watermark was asked for.
+func markCode(writer http.ResponseWriter, request *http.Request, codeData []byte) ([]byte, int, error) {
+	id, err := forensicID(request)
+	if err != nil {
+		return nil, 400, fmt.Errorf("Could not determine the forensic watermark ID. %v", err)
+	}
+	if id == "" {
+		return codeData, 0, nil
+	}
+	key, err := forensicKey()
+	if err != nil {
+		return nil, 500, err
+	}
+	img, err := png.Decode(bytes.NewReader(codeData))
+	if err != nil {
+		return nil, 400, fmt.Errorf("Could not read the QR code. %v", err)
+	}
+	marked, err := forensic.Embed(img, id, key)
+	if err != nil {
+		return nil, 400, fmt.Errorf("Could not add the forensic watermark. %v", err)
+	}
+	encoded := bytes.NewBuffer(nil)
+	if err := png.Encode(encoded, marked); err != nil {
+		return nil, 400, fmt.Errorf("Could not encode the QR code. %v", err)
+	}
+	writer.Header().Set("X-Forensic-ID", id)
+	return encoded.Bytes(), 0, nil
+}
+
+type forensicResult struct {
+	ID string `json:"id"`
+}
+
+// HandleForensicExtract reads the forensic watermark from an uploaded copy
+// of a code, as a PNG or JPEG image.
+func HandleForensicExtract(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	key, err := forensicKey()
+	if err != nil {
+		writeError(writer, 500, err.Error())
+		return
+	}
+	data, _, err := uploadedFile(request, "image")
+	if err != nil {
+		writeError(writer, 400, "Could not read the uploaded image.")
+		return
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not decode the image, expected a PNG or JPEG. %v", err))
+		return
+	}
+
+	id, err := forensic.Extract(img, key)
+	if errors.Is(err, forensic.ErrNotFound) {
+		writeError(writer, 404, "No forensic watermark was found in the image.")
+		return
+	}
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not read the forensic watermark. %v", err))
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(forensicResult{id})
+}
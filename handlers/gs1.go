@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"qr-code-generator/gs1"
+	"qr-code-generator/qrcode"
+)
+
+func HandleGS1(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	qrCodeSize, err := strconv.Atoi(request.FormValue("size"))
+	if err != nil {
+		writeError(writer, 400, "Could not determine the desired QR code size.")
+		return
+	}
+
+	builder := gs1.NewBuilder()
+	if gtin := request.FormValue("gtin"); gtin != "" {
+		builder.GTIN(gtin)
+	}
+	if batch := request.FormValue("batch"); batch != "" {
+		builder.Batch(batch)
+	}
+	if expiry := request.FormValue("expiry"); expiry != "" {
+		date, err := time.Parse("2006-01-02", expiry)
+		if err != nil {
+			writeError(writer, 400, "Expiry must be a date in the form YYYY-MM-DD.")
+			return
+		}
+		builder.Expiry(date)
+	}
+	if serial := request.FormValue("serial"); serial != "" {
+		builder.Serial(serial)
+	}
+
+	// Any other AI is given as a field named after it, such as ai_3103.
+	var extra []string
+	for name := range request.Form {
+		if strings.HasPrefix(name, "ai_") {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		builder.Add(strings.TrimPrefix(name, "ai_"), request.FormValue(name))
+	}
+
+	qrCode := &qrcode.SimpleQRCode{Size: qrCodeSize}
+	switch request.FormValue("mode") {
+	case "", "digital_link":
+		qrCode.Content, err = builder.DigitalLink(request.FormValue("domain"))
+	case "element_string":
+		qrCode.Content, err = builder.ElementString()
+		qrCode.GS1 = true
+	default:
+		writeError(writer, 400, "Mode must be digital_link or element_string.")
+		return
+	}
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Invalid GS1 data. %v", err))
+		return
+	}
+
+	codeData, err := qrCode.Generate()
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not generate QR code. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "image/png")
+	writer.Write(codeData)
+}
+
+type resolvedLink struct {
+	Elements      []gs1.Element `json:"elements"`
+	HumanReadable string        `json:"human_readable"`
+}
+
+// HandleGS1Resolve parses a Digital Link, either from the request path, as a
+// resolver would receive it, or from the url parameter.
+func HandleGS1Resolve(writer http.ResponseWriter, request *http.Request) {
+	link := request.FormValue("url")
+	if link == "" {
+		link = strings.TrimPrefix(request.URL.EscapedPath(), "/gs1/resolve")
+		if request.URL.RawQuery != "" {
+			link += "?" + request.URL.RawQuery
+		}
+	}
+
+	elements, err := gs1.ParseDigitalLink(link)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not resolve the Digital Link. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(resolvedLink{
+		Elements:      elements,
+		HumanReadable: gs1.HumanReadable(elements),
+	})
+}
@@ -1,23 +1,31 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"qr-code-generator/bitmap"
+	"qr-code-generator/payloads"
 	"qr-code-generator/qrcode"
-	"qr-code-generator/utils"
 )
 
 func HandleRequest(writer http.ResponseWriter, request *http.Request) {
 	request.ParseMultipartForm(10 << 20)
-	var size, content string = request.FormValue("size"), request.FormValue("content")
+	var size string = request.FormValue("size")
 	var codeData []byte
 
 	writer.Header().Set("Content-Type", "application/json")
 
+	content, err := payloads.Content(request.Form)
+	if err != nil {
+		writer.WriteHeader(400)
+		json.NewEncoder(writer).Encode(fmt.Sprintf("Could not build the QR code content. %v", err))
+		return
+	}
 	if content == "" {
 		writer.WriteHeader(400)
 		json.NewEncoder(writer).Encode(
@@ -26,6 +34,11 @@ func HandleRequest(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	if content, err = envelopeContent(writer, request, content); err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+
 	qrCodeSize, err := strconv.Atoi(size)
 	if err != nil || size == "" {
 		writer.WriteHeader(400)
@@ -33,9 +46,63 @@ func HandleRequest(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	qrCode := &qrcode.SimpleQRCode{Content: content, Size: qrCodeSize}
-	watermarkFile, _, err := request.FormFile("watermark")
-	if err != nil && errors.Is(err, http.ErrMissingFile) {
+	format := request.FormValue("format")
+	bitmapFormat, isBitmap := bitmap.Formats[format]
+	if format != "" && format != "png" && format != "ndef" && !isBitmap {
+		writer.WriteHeader(400)
+		json.NewEncoder(writer).Encode(fmt.Sprintf("Unknown format %q, expected png, ndef, bmp, bmp1, pbm, pgm, xbm, ico, tiff, zpl or escpos.", format))
+		return
+	}
+
+	symbology, err := qrcode.ParseSymbology(request.FormValue("symbology"))
+	if err != nil {
+		writer.WriteHeader(400)
+		json.NewEncoder(writer).Encode(fmt.Sprintf("Could not determine the desired symbology. %v", err))
+		return
+	}
+
+	level, err := qrcode.ParseErrorCorrection(request.FormValue("error_correction"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the error correction. %v", err))
+		return
+	}
+
+	qrCode := &qrcode.SimpleQRCode{Content: content, Size: qrCodeSize, Symbology: symbology, ErrorCorrection: level}
+	warnings, strict, err := lintCode(request, qrCode)
+	if err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+	if isBitmap && (qrCode.Foreground != nil || qrCode.Background != nil) {
+		writeError(writer, 400, "Colors are only supported for PNG codes.")
+		return
+	}
+	if isBitmap && request.FormValue("caption") != "" {
+		writeError(writer, 400, "Captions are only supported for PNG codes.")
+		return
+	}
+	if isBitmap && (request.FormValue("forensic_id") != "" || request.FormValue("forensic") == "true") {
+		writeError(writer, 400, "Forensic watermarks are only supported for PNG codes.")
+		return
+	}
+	if strict && len(warnings) > 0 {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(422)
+		json.NewEncoder(writer).Encode(lintResult{warnings})
+		return
+	}
+	writeDesignWarnings(writer, warnings)
+	if err := writeSizeWarning(writer, request, qrCode, isBitmap); err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+	if isBitmap {
+		writeBitmap(writer, request, qrCode, bitmapFormat)
+		return
+	}
+
+	watermark, err := requestWatermark(request, qrCode)
+	if errors.Is(err, http.ErrMissingFile) {
 		codeData, err = qrCode.Generate()
 		if err != nil {
 			writer.WriteHeader(400)
@@ -44,17 +111,13 @@ func HandleRequest(writer http.ResponseWriter, request *http.Request) {
 			)
 			return
 		}
-		writer.Header().Add("Content-Type", "image/png")
-		writer.Write(codeData)
+		finishCode(writer, request, qrCode, content, codeData)
 		return
 	}
 
-	watermark, err := utils.UploadFile(watermarkFile)
 	if err != nil {
 		writer.WriteHeader(400)
-		json.NewEncoder(writer).Encode(
-			fmt.Sprint("Could not upload the watermark image.", err),
-		)
+		json.NewEncoder(writer).Encode(err.Error())
 		return
 	}
 
@@ -80,6 +143,97 @@ func HandleRequest(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	writer.Header().Set("Content-Type", "image/png")
-	writer.Write(codeData)
+	finishCode(writer, request, qrCode, content, codeData)
+}
+
+// finishCode adds the caption and the forensic watermark to a PNG code, in
+// that order so that the watermark covers the caption too, and sends it.
+func finishCode(writer http.ResponseWriter, request *http.Request, qrCode *qrcode.SimpleQRCode, content string, codeData []byte) {
+	codeData, err := captionCode(request, qrCode, codeData)
+	if err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+	codeData, status, err := markCode(writer, request, codeData)
+	if err != nil {
+		writeError(writer, status, err.Error())
+		return
+	}
+	writeCode(writer, request, content, codeData)
+}
+
+// writeCode sends the PNG, or with format=ndef a ZIP archive of the PNG and
+// the NDEF message for an NFC tag with the same payload.
+func writeCode(writer http.ResponseWriter, request *http.Request, content string, codeData []byte) {
+	if request.FormValue("format") != "ndef" {
+		writer.Header().Set("Content-Type", "image/png")
+		writer.Write(codeData)
+		return
+	}
+
+	message, err := payloads.NDEF(request.Form, content)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not build the NDEF message. %v", err))
+		return
+	}
+	ndefData, err := message.Encode()
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not encode the NDEF message. %v", err))
+		return
+	}
+
+	writeArchive(writer, "code.zip", []archiveFile{
+		{"code.png", codeData},
+		{"payload.ndef", ndefData},
+	})
+}
+
+// writeBitmap sends the code in one of the uncompressed bitmap formats,
+// scaled by a whole number of pixels per module. A watermark is read back
+// afterwards and the outcome reported in X-Scan-Verification, since reducing
+// it to black and white can cover modules the code needs.
+func writeBitmap(writer http.ResponseWriter, request *http.Request, qrCode *qrcode.SimpleQRCode, format bitmap.Format) {
+	var options bitmap.Options
+	watermark, err := requestWatermark(request, qrCode)
+	if err == nil {
+		options.Watermark = watermark
+	} else if !errors.Is(err, http.ErrMissingFile) {
+		writeError(writer, 400, err.Error())
+		return
+	}
+	if options.Dithering, err = bitmap.ParseDithering(request.FormValue("dithering")); err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the dithering. %v", err))
+		return
+	}
+	threshold, err := strconv.ParseUint(formDefault(request, "threshold", "128"), 10, 8)
+	if err != nil {
+		writeError(writer, 400, "Threshold must be a grey level from 0 to 255.")
+		return
+	}
+	options.Threshold = uint8(threshold)
+
+	rendered, err := bitmap.Render(qrCode, format, options)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not generate QR code. %v", err))
+		return
+	}
+	if options.Watermark != nil {
+		switch err := qrCode.Verify(rendered); {
+		case err == nil:
+			writer.Header().Set("X-Scan-Verification", "passed")
+		case errors.Is(err, qrcode.ErrNotVerifiable):
+			writer.Header().Set("X-Scan-Verification", "not checked")
+		default:
+			writer.Header().Set("X-Scan-Verification", fmt.Sprintf("failed: %v", err))
+		}
+	}
+
+	encoded := bytes.NewBuffer(nil)
+	if err := format.Encode(encoded, rendered, "qr_code"); err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not encode the QR code. %v", err))
+		return
+	}
+	writer.Header().Set("Content-Type", format.ContentType)
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "qr_code"+format.Extension))
+	writer.Write(encoded.Bytes())
 }
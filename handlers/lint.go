@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/design"
+	"qr-code-generator/payloads"
+	"qr-code-generator/qrcode"
+)
+
+type lintResult struct {
+	Warnings []design.Warning `json:"warnings"`
+}
+
+// HandleLint checks the options of a code, the same as /generate takes,
+// without rendering it.
+func HandleLint(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	content, err := payloads.Content(request.Form)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not build the QR code content. %v", err))
+		return
+	}
+	if content == "" {
+		writeError(writer, 400, "Could not determine the desired QR code content.")
+		return
+	}
+	symbology, err := qrcode.ParseSymbology(request.FormValue("symbology"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the desired symbology. %v", err))
+		return
+	}
+	level, err := qrcode.ParseErrorCorrection(request.FormValue("error_correction"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the error correction. %v", err))
+		return
+	}
+
+	code := &qrcode.SimpleQRCode{Content: content, Symbology: symbology, ErrorCorrection: level}
+	warnings, strict, err := lintCode(request, code)
+	if err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if strict && len(warnings) > 0 {
+		writer.WriteHeader(422)
+	}
+	json.NewEncoder(writer).Encode(lintResult{warnings})
+}
+
+// lintCode sets the code's colors from the request and checks its design.
+// strict asks for warnings to be treated as errors.
+func lintCode(request *http.Request, code *qrcode.SimpleQRCode) ([]design.Warning, bool, error) {
+	strict, err := strconv.ParseBool(formDefault(request, "strict", "false"))
+	if err != nil {
+		return nil, false, fmt.Errorf("Strict must be true or false.")
+	}
+
+	foreground, background, err := designColors(request, code)
+	if err != nil {
+		return nil, false, err
+	}
+
+	modules, err := code.Modules()
+	if err != nil {
+		return nil, false, fmt.Errorf("Could not generate QR code. %v", err)
+	}
+	level, _ := qrcode.ParseErrorCorrection(string(code.ErrorCorrection))
+	codeDesign := &design.Design{
+		Symbology:       code.Symbology,
+		ErrorCorrection: level,
+		Width:           len(modules[0]),
+		Height:          len(modules),
+		Foreground:      foreground,
+		Background:      background,
+	}
+	if _, _, err := request.FormFile("watermark"); err == nil || request.FormValue("logo_text") != "" {
+		codeDesign.Logo = qrcode.WatermarkScale
+	}
+	return design.Lint(codeDesign), strict, nil
+}
+
+// designColors sets the code's foreground and background colors from the
+// request and returns them, black and white when they are not given.
+func designColors(request *http.Request, code *qrcode.SimpleQRCode) (foreground, background color.NRGBA, err error) {
+	foreground, background = color.NRGBA{0, 0, 0, 0xff}, color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	for _, option := range []struct {
+		name   string
+		value  *color.NRGBA
+		target *color.Color
+	}{
+		{"foreground", &foreground, &code.Foreground},
+		{"background", &background, &code.Background},
+	} {
+		value := request.FormValue(option.name)
+		if value == "" {
+			continue
+		}
+		if *option.value, err = design.ParseColor(value); err != nil {
+			return foreground, background, fmt.Errorf("Could not determine the %s color. %v", option.name, err)
+		}
+		*option.target = *option.value
+	}
+	return foreground, background, nil
+}
+
+// writeDesignWarnings reports warnings alongside an image in
+// X-Design-Warning, as rule: message pairs separated by semicolons.
+func writeDesignWarnings(writer http.ResponseWriter, warnings []design.Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	messages := make([]string, len(warnings))
+	for i, warning := range warnings {
+		messages[i] = warning.Rule + ": " + warning.Message
+	}
+	writer.Header().Set("X-Design-Warning", strings.Join(messages, "; "))
+}
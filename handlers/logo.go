@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"net/http"
+
+	"qr-code-generator/design"
+	"qr-code-generator/logo"
+	"qr-code-generator/qrcode"
+)
+
+// requestWatermark returns the uploaded watermark image, or a text logo
+// drawn from logo_text, and http.ErrMissingFile when there is neither. The
+// logo's colors default to the code's.
+func requestWatermark(request *http.Request, code *qrcode.SimpleQRCode) ([]byte, error) {
+	watermark, _, err := uploadedFile(request, "watermark")
+	text := request.FormValue("logo_text")
+	switch {
+	case text == "" && errors.Is(err, http.ErrMissingFile):
+		return nil, err
+	case text == "" && err != nil:
+		return nil, fmt.Errorf("Could not upload the watermark image. %v", err)
+	case text == "":
+		return watermark, nil
+	case err == nil:
+		return nil, fmt.Errorf("Give either a watermark image or logo_text, not both.")
+	}
+
+	shape, err := logo.ParseShape(request.FormValue("logo_shape"))
+	if err != nil {
+		return nil, fmt.Errorf("Could not determine the logo shape. %v", err)
+	}
+	textLogo := &logo.Text{Text: text, Shape: shape, Color: code.Foreground, Plate: code.Background}
+	for _, option := range []struct {
+		name   string
+		target *color.Color
+	}{
+		{"logo_color", &textLogo.Color},
+		{"logo_background", &textLogo.Plate},
+	} {
+		if value := request.FormValue(option.name); value != "" {
+			parsed, err := design.ParseColor(value)
+			if err != nil {
+				return nil, fmt.Errorf("Could not determine %s. %v", option.name, err)
+			}
+			*option.target = parsed
+		}
+	}
+	data, err := textLogo.PNG()
+	if err != nil {
+		return nil, fmt.Errorf("Could not draw the text logo. %v", err)
+	}
+	return data, nil
+}
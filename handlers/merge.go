@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/dataset"
+	"qr-code-generator/merge"
+	"qr-code-generator/utils"
+)
+
+var unsafeFilename = regexp.MustCompile(`[^\pL\pN._\- ]+`)
+
+func uploadedFile(request *http.Request, field string) ([]byte, *multipart.FileHeader, error) {
+	file, header, err := request.FormFile(field)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	data, err := utils.UploadFile(file)
+	return data, header, err
+}
+
+func HandleMerge(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(32 << 20)
+
+	templateData, templateHeader, err := uploadedFile(request, "template")
+	if err != nil {
+		writeError(writer, 400, "Could not read the uploaded DOCX or ODT template.")
+		return
+	}
+	template, err := merge.OpenTemplate(templateData)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not open the template. %v", err))
+		return
+	}
+
+	data, dataHeader, err := uploadedFile(request, "data")
+	if err != nil {
+		writeError(writer, 400, "Could not read the uploaded data file.")
+		return
+	}
+	table, err := dataset.Read(dataHeader.Filename, data)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not read the data. %v", err))
+		return
+	}
+	if len(table.Rows) == 0 {
+		writeError(writer, 400, "The data file has no rows.")
+		return
+	}
+
+	columns := map[string]bool{}
+	for _, column := range table.Columns {
+		columns[column] = true
+	}
+	var missing []string
+	for _, name := range template.Placeholders() {
+		if !columns[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		writeError(writer, 400, fmt.Sprintf("The template uses placeholders that are not columns of the data: %s.", strings.Join(missing, ", ")))
+		return
+	}
+
+	options := merge.Options{Content: request.FormValue("content")}
+	if size := request.FormValue("size"); size != "" {
+		if options.Size, err = strconv.Atoi(size); err != nil {
+			writeError(writer, 400, "Could not determine the desired QR code size.")
+			return
+		}
+	}
+
+	extension := "." + string(template.Format)
+	switch request.FormValue("output") {
+	case "", "documents":
+		filenameTemplate := formDefault(request, "filename", "document-{row}")
+		var files []archiveFile
+		used := map[string]bool{}
+		for i, row := range table.Rows {
+			merged, err := template.Document(row, options)
+			if err != nil {
+				writeError(writer, 400, fmt.Sprintf("Could not merge row %d. %v", i+1, err))
+				return
+			}
+			name := strings.ReplaceAll(filenameTemplate, "{row}", strconv.Itoa(i+1))
+			name = strings.TrimSpace(unsafeFilename.ReplaceAllString(row.Expand(name), "_"))
+			if name == "" || used[name] {
+				name = fmt.Sprintf("%s-%d", name, i+1)
+			}
+			used[name] = true
+			files = append(files, archiveFile{name + extension, merged})
+		}
+		writeArchive(writer, "merged.zip", files)
+	case "combined":
+		merged, err := template.Combined(table.Rows, options)
+		if err != nil {
+			writeError(writer, 400, fmt.Sprintf("Could not merge the rows. %v", err))
+			return
+		}
+		contentType := "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		if template.Format == merge.ODT {
+			contentType = "application/vnd.oasis.opendocument.text"
+		}
+		name := strings.TrimSuffix(templateHeader.Filename, path.Ext(templateHeader.Filename)) + "-merged" + extension
+		writer.Header().Set("Content-Type", contentType)
+		writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		writer.Write(merged)
+	default:
+		writeError(writer, 400, "Output must be documents or combined.")
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"qr-code-generator/onboarding"
+)
+
+// HandleOnboardingParse decodes a scanned Matter or Wi-Fi Easy Connect code,
+// so that factory lines can check what was printed on a device.
+func HandleOnboardingParse(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	data := strings.TrimSpace(request.FormValue("data"))
+
+	var (
+		result interface{}
+		err    error
+	)
+	switch {
+	case strings.HasPrefix(data, "MT:"):
+		result, err = onboarding.ParseMatter(data)
+	case strings.HasPrefix(data, "DPP:"):
+		result, err = onboarding.ParseDPP(data)
+	default:
+		err = fmt.Errorf("expected a Matter (MT:) or Wi-Fi Easy Connect (DPP:) payload")
+	}
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not parse the onboarding payload. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(result)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+func writeError(writer http.ResponseWriter, status int, message string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(message)
+}
+
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+// writeArchive sends the files as a ZIP archive, in the order given.
+func writeArchive(writer http.ResponseWriter, filename string, files []archiveFile) {
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	for _, file := range files {
+		entry, err := zipWriter.Create(file.name)
+		if err == nil {
+			_, err = entry.Write(file.data)
+		}
+		if err != nil {
+			writeError(writer, 500, fmt.Sprintf("Could not write the archive. %v", err))
+			return
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		writeError(writer, 500, fmt.Sprintf("Could not write the archive. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/zip")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	writer.Write(archive.Bytes())
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strconv"
+
+	"qr-code-generator/design"
+	"qr-code-generator/payloads"
+	"qr-code-generator/qrcode"
+)
+
+type simulationReport struct {
+	Views []design.View `json:"views"`
+}
+
+// HandleSimulate renders a code as /generate does and shows it as people
+// with colour vision deficiencies and grayscale cameras see it.
+func HandleSimulate(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	content, err := payloads.Content(request.Form)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not build the QR code content. %v", err))
+		return
+	}
+	if content == "" {
+		writeError(writer, 400, "Could not determine the desired QR code content.")
+		return
+	}
+	size, err := strconv.Atoi(formDefault(request, "size", "512"))
+	if err != nil {
+		writeError(writer, 400, "Could not determine the desired QR code size.")
+		return
+	}
+	symbology, err := qrcode.ParseSymbology(request.FormValue("symbology"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the desired symbology. %v", err))
+		return
+	}
+	level, err := qrcode.ParseErrorCorrection(request.FormValue("error_correction"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the error correction. %v", err))
+		return
+	}
+
+	code := &qrcode.SimpleQRCode{Content: content, Size: size, Symbology: symbology, ErrorCorrection: level}
+	foreground, background, err := designColors(request, code)
+	if err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+	report := simulationReport{design.Views(foreground, background)}
+
+	output := formDefault(request, "output", "zip")
+	switch output {
+	case "json":
+		writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(writer).Encode(report)
+		return
+	case "zip":
+	default:
+		writeError(writer, 400, "Output must be zip or json.")
+		return
+	}
+
+	var codeData []byte
+	watermark, err := requestWatermark(request, code)
+	switch {
+	case err == nil:
+		codeData, err = code.GenerateWithWatermark(watermark)
+	case errors.Is(err, http.ErrMissingFile):
+		codeData, err = code.Generate()
+	default:
+		writeError(writer, 400, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not generate QR code. %v", err))
+		return
+	}
+	rendered, err := png.Decode(bytes.NewReader(codeData))
+	if err != nil {
+		writeError(writer, 500, fmt.Sprintf("Could not read the QR code back. %v", err))
+		return
+	}
+
+	reportData, _ := json.MarshalIndent(report, "", "  ")
+	files := []archiveFile{{"report.json", reportData}, {"code.png", codeData}}
+	for _, deficiency := range design.Simulations {
+		simulated := bytes.NewBuffer(nil)
+		png.Encode(simulated, design.SimulateImage(rendered, deficiency))
+		files = append(files, archiveFile{string(deficiency) + ".png", simulated.Bytes()})
+	}
+	writeArchive(writer, "simulation.zip", files)
+}
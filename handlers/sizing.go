@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/payloads"
+	"qr-code-generator/qrcode"
+	"qr-code-generator/sizing"
+)
+
+// HandleSizing recommends how large to print a code so that it scans from
+// the given distance with the given printing method.
+func HandleSizing(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	content, err := payloads.Content(request.Form)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not build the QR code content. %v", err))
+		return
+	}
+	if content == "" {
+		writeError(writer, 400, "Could not determine the desired QR code content.")
+		return
+	}
+	symbology, err := qrcode.ParseSymbology(request.FormValue("symbology"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the desired symbology. %v", err))
+		return
+	}
+	level, err := qrcode.ParseErrorCorrection(request.FormValue("error_correction"))
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not determine the error correction. %v", err))
+		return
+	}
+
+	code := &qrcode.SimpleQRCode{Content: content, Symbology: symbology, ErrorCorrection: level}
+	if symbology != qrcode.QR {
+		level = ""
+	}
+	sizingRequest, err := sizingRequest(request, code, level)
+	if err != nil {
+		writeError(writer, 400, err.Error())
+		return
+	}
+	advice, err := sizing.Advise(*sizingRequest)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not size the code. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(advice)
+}
+
+// sizingRequest reads the distance in metres, the printing method, the
+// printed size in millimetres and the printer's DPI.
+func sizingRequest(request *http.Request, code *qrcode.SimpleQRCode, level qrcode.ErrorCorrection) (*sizing.Request, error) {
+	method, err := sizing.ParseMethod(request.FormValue("method"))
+	if err != nil {
+		return nil, fmt.Errorf("Could not determine the printing method. %v", err)
+	}
+	modules, err := code.Modules()
+	if err != nil {
+		return nil, fmt.Errorf("Could not generate QR code. %v", err)
+	}
+
+	sizingRequest := &sizing.Request{
+		Modules:         len(modules[0]),
+		QuietZone:       code.Symbology.QuietZone(),
+		Method:          method,
+		ErrorCorrection: level,
+	}
+	for _, number := range []struct {
+		name, unit string
+		value      *float64
+	}{
+		{"distance", "metres", &sizingRequest.Distance},
+		{"print_size", "millimetres", &sizingRequest.Size},
+		{"dpi", "dots per inch", &sizingRequest.DPI},
+	} {
+		if value := request.FormValue(number.name); value != "" {
+			if *number.value, err = strconv.ParseFloat(value, 64); err != nil || *number.value < 0 {
+				return nil, fmt.Errorf("Could not determine %s, which is given in %s.", number.name, number.unit)
+			}
+		}
+	}
+	return sizingRequest, nil
+}
+
+// writeSizeWarning checks a code that /generate was asked to print at a
+// physical size, given by print_size or by the image's pixels at dpi, and
+// reports anything that makes it unreliable in X-Size-Warning.
+func writeSizeWarning(writer http.ResponseWriter, request *http.Request, code *qrcode.SimpleQRCode, exactModules bool) error {
+	if request.FormValue("print_size") == "" && request.FormValue("dpi") == "" {
+		return nil
+	}
+	level, err := qrcode.ParseErrorCorrection(string(code.ErrorCorrection))
+	if err != nil || code.GS1 || code.Symbology != qrcode.QR {
+		level = ""
+	}
+	sizingRequest, err := sizingRequest(request, code, level)
+	if err != nil {
+		return err
+	}
+
+	// Without a printed size every image pixel is one printer dot.
+	if sizingRequest.Size == 0 {
+		if sizingRequest.DPI == 0 {
+			return fmt.Errorf("Could not determine print_size or dpi, which must be more than zero.")
+		}
+		width := sizingRequest.Modules + 2*sizingRequest.QuietZone
+		sizingRequest.Size = modulePixels(code, width, exactModules) * float64(width) * 25.4 / sizingRequest.DPI
+	}
+	advice, err := sizing.Advise(*sizingRequest)
+	if err != nil {
+		return fmt.Errorf("Could not size the code. %v", err)
+	}
+	if len(advice.Warnings) > 0 {
+		writer.Header().Set("X-Size-Warning", strings.Join(advice.Warnings, "; "))
+	}
+	return nil
+}
+
+// modulePixels is how many pixels wide a module is drawn. go-qrcode and the
+// bitmap formats use a whole number and pad the rest, while other symbols
+// are scaled to fill the image.
+func modulePixels(code *qrcode.SimpleQRCode, width int, exact bool) float64 {
+	switch {
+	case code.Size < 0:
+		return float64(-code.Size)
+	case exact || (!code.GS1 && code.Symbology == qrcode.QR):
+		return float64(max(1, code.Size/width))
+	}
+	return float64(max(width, code.Size)) / float64(width)
+}
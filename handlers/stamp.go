@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"qr-code-generator/stamp"
+	"qr-code-generator/utils"
+)
+
+func HandleStamp(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(32 << 20)
+
+	file, header, err := request.FormFile("document")
+	if err != nil {
+		writeError(writer, 400, "Could not read the uploaded PDF document.")
+		return
+	}
+	defer file.Close()
+	document, err := utils.UploadFile(file)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not upload the PDF document. %v", err))
+		return
+	}
+
+	options := stamp.Options{
+		Content:  request.FormValue("content"),
+		Filename: header.Filename,
+		Pages:    stamp.Pages(formDefault(request, "pages", string(stamp.AllPages))),
+		Placement: stamp.Placement{
+			Corner: stamp.Corner(formDefault(request, "corner", string(stamp.BottomRight))),
+		},
+	}
+	for _, length := range []struct {
+		name, defaultValue string
+		value              *float64
+	}{
+		{"offset_x", "10", &options.Placement.OffsetX},
+		{"offset_y", "10", &options.Placement.OffsetY},
+		{"size", "25", &options.Placement.Size},
+	} {
+		if *length.value, err = strconv.ParseFloat(formDefault(request, length.name, length.defaultValue), 64); err != nil {
+			writeError(writer, 400, fmt.Sprintf("Could not determine %s, which is given in millimetres.", length.name))
+			return
+		}
+	}
+
+	stamped, err := stamp.Stamp(document, options)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not stamp the document. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/pdf")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", header.Filename))
+	writer.Write(stamped)
+}
+
+func formDefault(request *http.Request, name, defaultValue string) string {
+	if value := request.FormValue(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/swissqr"
+)
+
+func HandleSwissQRBill(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	bill := &swissqr.Bill{
+		Account:         request.FormValue("account"),
+		Creditor:        formAddress(request, "creditor"),
+		Amount:          request.FormValue("amount"),
+		Currency:        strings.ToUpper(request.FormValue("currency")),
+		ReferenceType:   swissqr.ReferenceType(strings.ToUpper(request.FormValue("reference_type"))),
+		Reference:       request.FormValue("reference"),
+		Message:         request.FormValue("message"),
+		BillInformation: request.FormValue("bill_information"),
+		Language:        request.FormValue("language"),
+	}
+	if request.FormValue("debtor_name") != "" {
+		debtor := formAddress(request, "debtor")
+		bill.Debtor = &debtor
+	}
+	if bill.ReferenceType == "" {
+		bill.ReferenceType = swissqr.NoReference
+	}
+	for _, scheme := range request.Form["alternative_scheme"] {
+		if scheme != "" {
+			bill.AlternativeSchemes = append(bill.AlternativeSchemes, scheme)
+		}
+	}
+
+	if err := bill.Validate(); err != nil {
+		writeError(writer, 400, fmt.Sprintf("Invalid QR-bill. %v", err))
+		return
+	}
+
+	var (
+		data        []byte
+		contentType string
+		err         error
+	)
+	switch request.FormValue("format") {
+	case "", "png":
+		size, convErr := strconv.Atoi(request.FormValue("size"))
+		if convErr != nil {
+			writeError(writer, 400, "Could not determine the desired QR code size.")
+			return
+		}
+		data, err = bill.GeneratePNG(size)
+		contentType = "image/png"
+	case "pdf":
+		data, err = bill.GeneratePDF()
+		contentType = "application/pdf"
+	case "svg":
+		data, err = bill.GenerateSVG()
+		contentType = "image/svg+xml"
+	default:
+		writeError(writer, 400, "Format must be one of png, pdf or svg.")
+		return
+	}
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not generate the QR-bill. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", contentType)
+	writer.Write(data)
+}
+
+func formAddress(request *http.Request, prefix string) swissqr.Address {
+	return swissqr.Address{
+		Name:           request.FormValue(prefix + "_name"),
+		Street:         request.FormValue(prefix + "_street"),
+		BuildingNumber: request.FormValue(prefix + "_building_number"),
+		PostalCode:     request.FormValue(prefix + "_postal_code"),
+		Town:           request.FormValue(prefix + "_town"),
+		Country:        strings.ToUpper(request.FormValue(prefix + "_country")),
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"qr-code-generator/utils"
+	"qr-code-generator/wallet"
+)
+
+// Wallet signing keys are local files named by environment variables, so
+// passes can be built without network access.
+const (
+	appleCertificateVariable     = "WALLET_APPLE_CERTIFICATE"
+	appleKeyVariable             = "WALLET_APPLE_KEY"
+	appleIntermediateVariable    = "WALLET_APPLE_WWDR"
+	googleServiceAccountVariable = "WALLET_GOOGLE_SERVICE_ACCOUNT"
+	googleIssuerVariable         = "WALLET_GOOGLE_ISSUER_ID"
+)
+
+func readPass(request *http.Request) (*wallet.Pass, error) {
+	pass := &wallet.Pass{}
+	if err := json.Unmarshal([]byte(request.FormValue("pass")), pass); err != nil {
+		return nil, err
+	}
+	return pass, nil
+}
+
+func HandleApplePass(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	certificate, key := os.Getenv(appleCertificateVariable), os.Getenv(appleKeyVariable)
+	if certificate == "" || key == "" {
+		writeError(writer, 500, fmt.Sprintf("Apple Wallet signing is not configured, set %s and %s.", appleCertificateVariable, appleKeyVariable))
+		return
+	}
+	signer, err := wallet.LoadAppleSigner(certificate, key, os.Getenv(appleIntermediateVariable))
+	if err != nil {
+		writeError(writer, 500, fmt.Sprintf("Could not load the Apple Wallet certificate. %v", err))
+		return
+	}
+
+	pass, err := readPass(request)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not read the pass. %v", err))
+		return
+	}
+
+	images := map[string][]byte{}
+	if request.MultipartForm != nil {
+		for _, header := range request.MultipartForm.File["image"] {
+			file, err := header.Open()
+			if err != nil {
+				writeError(writer, 400, fmt.Sprintf("Could not upload the image %s. %v", header.Filename, err))
+				return
+			}
+			data, err := utils.UploadFile(file)
+			file.Close()
+			if err != nil {
+				writeError(writer, 400, fmt.Sprintf("Could not upload the image %s. %v", header.Filename, err))
+				return
+			}
+			if contentType := http.DetectContentType(data); contentType != "image/png" {
+				writeError(writer, 400, fmt.Sprintf("Image %s is a %s, not a PNG.", header.Filename, contentType))
+				return
+			}
+			images[header.Filename] = data
+		}
+	}
+
+	pkpass, err := pass.ApplePass(signer, images, time.Now())
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not create the pass. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/vnd.apple.pkpass")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "pass.pkpass"))
+	writer.Write(pkpass)
+}
+
+func HandleGooglePass(writer http.ResponseWriter, request *http.Request) {
+	request.ParseMultipartForm(10 << 20)
+
+	keyFile, issuerID := os.Getenv(googleServiceAccountVariable), os.Getenv(googleIssuerVariable)
+	if keyFile == "" || issuerID == "" {
+		writeError(writer, 500, fmt.Sprintf("Google Wallet signing is not configured, set %s and %s.", googleServiceAccountVariable, googleIssuerVariable))
+		return
+	}
+	account, err := wallet.LoadGoogleServiceAccount(keyFile)
+	if err != nil {
+		writeError(writer, 500, fmt.Sprintf("Could not load the Google service account. %v", err))
+		return
+	}
+
+	pass, err := readPass(request)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not read the pass. %v", err))
+		return
+	}
+	object, err := pass.GoogleObject(issuerID)
+	if err != nil {
+		writeError(writer, 400, fmt.Sprintf("Could not create the pass. %v", err))
+		return
+	}
+
+	var origins []string
+	if value := request.FormValue("origins"); value != "" {
+		origins = strings.Split(value, ",")
+	}
+	token, err := account.GoogleSaveJWT(object, origins, time.Now())
+	if err != nil {
+		writeError(writer, 500, fmt.Sprintf("Could not sign the save link. %v", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(map[string]interface{}{
+		"object":   object,
+		"jwt":      token,
+		"save_url": wallet.GoogleSaveURL + token,
+	})
+}
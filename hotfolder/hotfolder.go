@@ -0,0 +1,292 @@
+// Package hotfolder watches input folders for data files, runs each one
+// through batch generation and files it away as done or failed.
+package hotfolder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"qr-code-generator/batch"
+	"qr-code-generator/dataset"
+)
+
+type Config struct {
+	Inputs []string
+	Output string
+	// Done and Failed are where inputs are moved once processed. Relative
+	// paths are taken from each input folder.
+	Done   string
+	Failed string
+	// Interval is the time between scans of the input folders.
+	Interval time.Duration
+	Format   batch.Output
+	Batch    batch.Options
+	// Sheet chooses the sheet of XLSX workbooks, the first by default.
+	Sheet  string
+	Logger *log.Logger
+}
+
+// fileState is what a scan saw of a file. A file is only read once a scan
+// finds it unchanged since the previous one, so that files still being
+// copied into a folder are left alone.
+type fileState struct {
+	size     int64
+	modified time.Time
+}
+
+type Watcher struct {
+	config Config
+	seen   map[string]fileState
+	// stuck holds processed files that could not be moved out of their
+	// input folder. They are left alone until they change.
+	stuck map[string]fileState
+}
+
+func New(config Config) (*Watcher, error) {
+	if len(config.Inputs) == 0 {
+		return nil, fmt.Errorf("no input folders given")
+	}
+	if config.Done == "" {
+		config.Done = "done"
+	}
+	if config.Failed == "" {
+		config.Failed = "failed"
+	}
+	if config.Interval <= 0 {
+		config.Interval = 2 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	for _, input := range config.Inputs {
+		if info, err := os.Stat(input); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("input %s is not a folder", input)
+		}
+		for _, folder := range []string{config.folder(input, config.Done), config.folder(input, config.Failed)} {
+			if err := os.MkdirAll(folder, 0o755); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := os.MkdirAll(config.Output, 0o755); err != nil {
+		return nil, err
+	}
+	return &Watcher{config: config, seen: map[string]fileState{}, stuck: map[string]fileState{}}, nil
+}
+
+func (config *Config) folder(input, folder string) string {
+	if filepath.IsAbs(folder) {
+		return folder
+	}
+	return filepath.Join(input, folder)
+}
+
+// Run scans the input folders until the context is cancelled.
+func (watcher *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(watcher.config.Interval)
+	defer ticker.Stop()
+	for {
+		watcher.Scan()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan processes the data files that have not changed since the previous
+// scan and returns how many files are still waiting to settle.
+func (watcher *Watcher) Scan() int {
+	waiting := 0
+	current := map[string]fileState{}
+	stuck := map[string]fileState{}
+	for _, input := range watcher.config.Inputs {
+		entries, err := os.ReadDir(input)
+		if err != nil {
+			watcher.config.Logger.Printf("could not read %s: %v", input, err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !isDataFile(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			path := filepath.Join(input, entry.Name())
+			state := fileState{info.Size(), info.ModTime()}
+			if previous, ok := watcher.stuck[path]; ok && previous == state {
+				stuck[path] = state
+				continue
+			}
+			if previous, ok := watcher.seen[path]; !ok || previous != state {
+				current[path] = state
+				waiting++
+				continue
+			}
+			if !watcher.process(input, path) {
+				stuck[path] = state
+			}
+		}
+	}
+	watcher.seen = current
+	watcher.stuck = stuck
+	return waiting
+}
+
+// isDataFile skips hidden files and the temporary files that editors and
+// copy tools write before renaming them into place.
+func isDataFile(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~$") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".json", ".xlsx":
+		return true
+	}
+	return false
+}
+
+// process generates the codes of a data file and moves it to the done or
+// failed folder. It returns false if the file could not be moved.
+func (watcher *Watcher) process(input, path string) bool {
+	config := &watcher.config
+	temporary, codes, err := watcher.generate(path)
+	if err != nil {
+		return watcher.fail(input, path, err)
+	}
+	defer os.Remove(temporary)
+
+	// The input is moved before the output is put in place, so that an
+	// input that cannot be moved leaves no output behind.
+	done := config.folder(input, config.Done)
+	moved, err := moveUnused(path, done, filepath.Base(path))
+	if err != nil {
+		config.Logger.Printf("could not move %s to %s, leaving it until it changes: %v", path, done, err)
+		return false
+	}
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	output, err := moveUnused(temporary, config.Output, base+"-codes."+string(config.Format))
+	if err != nil {
+		watcher.fail(input, moved, fmt.Errorf("could not write the output: %v", err))
+		return true
+	}
+	config.Logger.Printf("processed %s: %d codes written to %s", path, codes, output)
+	return true
+}
+
+// fail moves a file to the failed folder with an .error.txt file giving the
+// reason. It returns false if the file could not be moved.
+func (watcher *Watcher) fail(input, path string, reason error) bool {
+	config := &watcher.config
+	config.Logger.Printf("failed %s: %v", path, reason)
+	failed := config.folder(input, config.Failed)
+	moved, err := moveUnused(path, failed, filepath.Base(path))
+	if err != nil {
+		config.Logger.Printf("could not move %s to %s, leaving it until it changes: %v", path, failed, err)
+		return false
+	}
+	report := strings.TrimSuffix(moved, filepath.Ext(moved)) + ".error.txt"
+	os.WriteFile(report, []byte(reason.Error()+"\n"), 0o644)
+	return true
+}
+
+// generate writes the batch output of a data file to a temporary file in the
+// output folder and returns its path and the number of codes. The caller
+// renames the file into place, so that it never appears half written.
+func (watcher *Watcher) generate(path string) (string, int, error) {
+	config := &watcher.config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	var table *dataset.Table
+	if strings.ToLower(filepath.Ext(path)) == ".xlsx" {
+		table, err = dataset.ReadXLSX(data, config.Sheet, true)
+	} else {
+		table, err = dataset.Read(path, data)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if len(table.Rows) == 0 {
+		return "", 0, fmt.Errorf("the data file has no rows")
+	}
+
+	codes, err := batch.Generate(table, config.Batch)
+	if err != nil {
+		return "", 0, err
+	}
+	result, err := batch.Write(config.Format, table, codes, config.Batch)
+	if err != nil {
+		return "", 0, err
+	}
+
+	temporary, err := os.CreateTemp(config.Output, ".batch-*")
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := temporary.Write(result); err != nil {
+		temporary.Close()
+		os.Remove(temporary.Name())
+		return "", 0, err
+	}
+	if err := temporary.Close(); err != nil {
+		os.Remove(temporary.Name())
+		return "", 0, err
+	}
+	return temporary.Name(), len(codes), nil
+}
+
+// moveUnused renames a file into a folder, adding a number to its name if
+// the folder already has a file of that name. A file that cannot be renamed,
+// such as one on another device, is copied and then deleted.
+func moveUnused(path, folder, name string) (string, error) {
+	extension := filepath.Ext(name)
+	base := strings.TrimSuffix(name, extension)
+	target := filepath.Join(folder, name)
+	for i := 2; ; i++ {
+		// Any other error, such as the folder not being one, is left for
+		// the rename to report.
+		if _, err := os.Lstat(target); err != nil {
+			break
+		}
+		target = filepath.Join(folder, fmt.Sprintf("%s-%d%s", base, i, extension))
+	}
+	err := os.Rename(path, target)
+	if err == nil {
+		return target, nil
+	}
+	if copyErr := copyFile(path, target); copyErr != nil {
+		return "", err
+	}
+	if removeErr := os.Remove(path); removeErr != nil {
+		os.Remove(target)
+		return "", removeErr
+	}
+	return target, nil
+}
+
+func copyFile(path, target string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, data, info.Mode().Perm()); err != nil {
+		os.Remove(target)
+		return err
+	}
+	return nil
+}
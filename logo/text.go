@@ -0,0 +1,312 @@
+package logo
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-text/typesetting/di"
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// Shape is the plate that text is drawn on.
+type Shape string
+
+const (
+	Circle  Shape = "circle"
+	Rounded Shape = "rounded"
+	Square  Shape = "square"
+)
+
+func ParseShape(name string) (Shape, error) {
+	switch Shape(name) {
+	case "":
+		return Circle, nil
+	case Circle, Rounded, Square:
+		return Shape(name), nil
+	}
+	return "", fmt.Errorf("shape must be circle, rounded or square, got %q", name)
+}
+
+// The corner radius of the plate, and the share of its width and height the
+// text may take up, as fractions of the plate's size. Text in a circle is
+// kept inside it rather than inside the square around it.
+func (shape Shape) layout() (radius, width, height float64) {
+	switch shape {
+	case Circle:
+		return 0.5, 0.72, 0.42
+	case Rounded:
+		return 0.2, 0.8, 0.5
+	}
+	return 0, 0.8, 0.5
+}
+
+// Text is a logo of a few characters, such as initials or an emoji, in the
+// bundled Go Bold and Noto Color Emoji fonts on a plain plate. Color and Plate are black and white when nil.
+type Text struct {
+	Text  string
+	Shape Shape
+	Color color.Color
+	Plate color.Color
+}
+
+const maxLength = 12
+
+func (text *Text) Validate() error {
+	value := strings.TrimSpace(text.Text)
+	if value == "" {
+		return fmt.Errorf("text is required")
+	}
+	if utf8.RuneCountInString(value) > maxLength {
+		return fmt.Errorf("text must be at most %d characters, got %d", maxLength, utf8.RuneCountInString(value))
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return fmt.Errorf("text must be a single line")
+	}
+	if _, err := ParseShape(string(text.Shape)); err != nil {
+		return err
+	}
+	return nil
+}
+
+var (
+	//go:embed fonts/NotoColorEmoji.ttf
+	notoColorEmoji []byte
+
+	loadFonts sync.Once
+	fonts     fallback
+	fontsErr  error
+)
+
+// fallback is the chain of bundled fonts: Go Bold for Latin, Greek and
+// Cyrillic, then Noto Color Emoji, whose glyphs are PNG images.
+type fallback []*font.Face
+
+func (chain fallback) ResolveFace(char rune) *font.Face {
+	for _, face := range chain {
+		if _, ok := face.NominalGlyph(char); ok {
+			return face
+		}
+	}
+	return chain[0]
+}
+
+func bundledFonts() (fallback, error) {
+	loadFonts.Do(func() {
+		for _, data := range [][]byte{gobold.TTF, notoColorEmoji} {
+			face, err := font.ParseTTF(bytes.NewReader(data))
+			if err != nil {
+				fontsErr = fmt.Errorf("could not read a bundled font: %v", err)
+				return
+			}
+			fonts = append(fonts, face)
+		}
+	})
+	return fonts, fontsErr
+}
+
+// Image draws the logo on a plate size pixels across. Outside a circular or
+// rounded plate the image is transparent. Emoji keep their own colours.
+func (text *Text) Image(size int) (*image.RGBA, error) {
+	if err := text.Validate(); err != nil {
+		return nil, err
+	}
+	value := strings.TrimSpace(text.Text)
+	chain, err := bundledFonts()
+	if err != nil {
+		return nil, err
+	}
+	for _, char := range value {
+		// Joiners, variation selectors and tags only combine the emoji
+		// around them.
+		if unicode.IsSpace(char) || unicode.In(char, unicode.Cf, unicode.Variation_Selector) {
+			continue
+		}
+		if _, ok := chain.ResolveFace(char).NominalGlyph(char); !ok {
+			return nil, fmt.Errorf("the bundled fonts have no glyph for %q; they cover Latin, Greek and Cyrillic text and emoji", char)
+		}
+	}
+
+	shape, _ := ParseShape(string(text.Shape))
+	radius, width, height := shape.layout()
+	textColor, plateColor := text.Color, text.Plate
+	if textColor == nil {
+		textColor = color.Black
+	}
+	if plateColor == nil {
+		plateColor = color.White
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	plate := vector.NewRasterizer(size, size)
+	roundedSquare(plate, float32(size), float32(radius*float64(size)))
+	plate.Draw(img, img.Bounds(), image.NewUniform(plateColor), image.Point{})
+
+	// Glyphs scale linearly without hinting, so the text is shaped once at
+	// a reference size and scaled to the size that fills the box.
+	const reference = 100
+	runes := []rune(value)
+	input := shaping.Input{
+		Text:      runes,
+		RunEnd:    len(runes),
+		Direction: di.DirectionLTR,
+		Size:      fixed.I(reference),
+	}
+	var (
+		segmenter shaping.Segmenter
+		shaper    shaping.HarfbuzzShaper
+		runs      []shaping.Output
+	)
+	for _, run := range segmenter.Split(input, chain) {
+		runs = append(runs, shaper.Shape(run))
+	}
+
+	// Centre the ink rather than the advance, so that capitals and digits
+	// sit in the middle of the plate.
+	left, top, right, bottom := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	x := 0.0
+	for _, run := range runs {
+		for _, glyph := range run.Glyphs {
+			ink := inkBounds(glyph, x)
+			left, top = min(left, ink.left), min(top, ink.top)
+			right, bottom = max(right, ink.right), max(bottom, ink.bottom)
+			x += toFloat(glyph.XAdvance)
+		}
+	}
+	if right <= left || bottom <= top {
+		return nil, fmt.Errorf("text has nothing to draw")
+	}
+	scale := min(width*float64(size)/(right-left), height*float64(size)/(bottom-top))
+	originX := float64(size)/2 - scale*(left+right)/2
+	originY := float64(size)/2 - scale*(top+bottom)/2
+
+	outlines := vector.NewRasterizer(size, size)
+	x = 0
+	for _, run := range runs {
+		for _, glyph := range run.Glyphs {
+			switch data := run.Face.GlyphData(glyph.GlyphID).(type) {
+			case font.GlyphOutline:
+				drawOutline(outlines, data, originX+scale*(x+toFloat(glyph.XOffset)), originY-scale*toFloat(glyph.YOffset),
+					scale*toFloat(run.Size)/float64(run.Face.Upem()))
+			case font.GlyphBitmap:
+				if err := drawBitmap(img, data, inkBounds(glyph, x), originX, originY, scale); err != nil {
+					return nil, err
+				}
+			}
+			x += toFloat(glyph.XAdvance)
+		}
+	}
+	outlines.Draw(img, img.Bounds(), image.NewUniform(textColor), image.Point{})
+	return img, nil
+}
+
+type box struct {
+	left, top, right, bottom float64
+}
+
+// inkBounds is where a glyph at the pen position x draws, with y
+// increasing downwards from the baseline.
+func inkBounds(glyph shaping.Glyph, x float64) box {
+	left := x + toFloat(glyph.XOffset+glyph.XBearing)
+	top := -toFloat(glyph.YOffset + glyph.YBearing)
+	return box{left, top, left + toFloat(glyph.Width), top - toFloat(glyph.Height)}
+}
+
+// drawOutline fills a glyph in font units, with y increasing upwards, at
+// the pen position x, y.
+func drawOutline(z *vector.Rasterizer, outline font.GlyphOutline, x, y, scale float64) {
+	point := func(p ot.SegmentPoint) (float32, float32) {
+		return float32(x + float64(p.X)*scale), float32(y - float64(p.Y)*scale)
+	}
+	started := false
+	for _, segment := range outline.Segments {
+		switch segment.Op {
+		case ot.SegmentOpMoveTo:
+			if started {
+				z.ClosePath()
+			}
+			z.MoveTo(point(segment.Args[0]))
+			started = true
+		case ot.SegmentOpLineTo:
+			z.LineTo(point(segment.Args[0]))
+		case ot.SegmentOpQuadTo:
+			x1, y1 := point(segment.Args[0])
+			x2, y2 := point(segment.Args[1])
+			z.QuadTo(x1, y1, x2, y2)
+		case ot.SegmentOpCubeTo:
+			x1, y1 := point(segment.Args[0])
+			x2, y2 := point(segment.Args[1])
+			x3, y3 := point(segment.Args[2])
+			z.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	if started {
+		z.ClosePath()
+	}
+}
+
+// drawBitmap scales a colour glyph's image into its ink box.
+func drawBitmap(img *image.RGBA, bitmap font.GlyphBitmap, ink box, originX, originY, scale float64) error {
+	if bitmap.Format != font.PNG {
+		return fmt.Errorf("emoji glyphs in bitmap format %d are not supported", bitmap.Format)
+	}
+	glyph, err := png.Decode(bytes.NewReader(bitmap.Data))
+	if err != nil {
+		return fmt.Errorf("could not decode an emoji glyph: %v", err)
+	}
+	target := image.Rect(
+		int(math.Round(originX+scale*ink.left)), int(math.Round(originY+scale*ink.top)),
+		int(math.Round(originX+scale*ink.right)), int(math.Round(originY+scale*ink.bottom)),
+	)
+	draw.CatmullRom.Scale(img, target, glyph, glyph.Bounds(), draw.Over, nil)
+	return nil
+}
+
+// PNG draws the logo large enough to be scaled down as a watermark.
+func (text *Text) PNG() ([]byte, error) {
+	img, err := text.Image(512)
+	if err != nil {
+		return nil, err
+	}
+	encoded := bytes.NewBuffer(nil)
+	if err := png.Encode(encoded, img); err != nil {
+		return nil, fmt.Errorf("could not encode the logo: %v", err)
+	}
+	return encoded.Bytes(), nil
+}
+
+func toFloat(value fixed.Int26_6) float64 {
+	return float64(value) / 64
+}
+
+// roundedSquare outlines a square of the given size with rounded corners,
+// which is a circle when the radius is half the size. Corners are cubic
+// Bézier approximations of quarter circles.
+func roundedSquare(z *vector.Rasterizer, size, radius float32) {
+	const kappa = 0.5523
+	k := radius * kappa
+	z.MoveTo(radius, 0)
+	z.LineTo(size-radius, 0)
+	z.CubeTo(size-radius+k, 0, size, radius-k, size, radius)
+	z.LineTo(size, size-radius)
+	z.CubeTo(size, size-radius+k, size-radius+k, size, size-radius, size)
+	z.LineTo(radius, size)
+	z.CubeTo(radius-k, size, 0, size-radius+k, 0, size-radius)
+	z.LineTo(0, radius)
+	z.CubeTo(0, radius-k, radius-k, 0, radius, 0)
+	z.ClosePath()
+}
@@ -2,10 +2,37 @@ package main
 
 import (
 	"net/http"
+	"os"
+	"qr-code-generator/cli"
 	"qr-code-generator/handlers"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	http.HandleFunc("/generate", handlers.HandleRequest)
+	http.HandleFunc("/swissqr", handlers.HandleSwissQRBill)
+	http.HandleFunc("/gs1", handlers.HandleGS1)
+	http.HandleFunc("/gs1/resolve", handlers.HandleGS1Resolve)
+	http.HandleFunc("/gs1/resolve/", handlers.HandleGS1Resolve)
+	http.HandleFunc("/bcbp", handlers.HandleBoardingPass)
+	http.HandleFunc("/bcbp/parse", handlers.HandleBoardingPassParse)
+	http.HandleFunc("/onboarding/parse", handlers.HandleOnboardingParse)
+	http.HandleFunc("/wallet/apple", handlers.HandleApplePass)
+	http.HandleFunc("/wallet/google", handlers.HandleGooglePass)
+	http.HandleFunc("/stamp", handlers.HandleStamp)
+	http.HandleFunc("/merge", handlers.HandleMerge)
+	http.HandleFunc("/batch", handlers.HandleBatch)
+	http.HandleFunc("/embroidery", handlers.HandleEmbroidery)
+	http.HandleFunc("/sizing", handlers.HandleSizing)
+	http.HandleFunc("/lint", handlers.HandleLint)
+	http.HandleFunc("/simulate", handlers.HandleSimulate)
+	http.HandleFunc("/forensic/extract", handlers.HandleForensicExtract)
+	http.HandleFunc("/cdp", handlers.HandleCopyDetection)
+	http.HandleFunc("/cdp/verify", handlers.HandleCopyDetectionVerify)
+	http.HandleFunc("/decode", handlers.HandleDecode)
+	http.HandleFunc("/decode/bulk", handlers.HandleBulkDecode)
 	http.ListenAndServe(":8080", nil)
 }
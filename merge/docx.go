@@ -0,0 +1,148 @@
+package merge
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/dataset"
+)
+
+var (
+	drawingPattern  = regexp.MustCompile(`(?s)<w:drawing>.*?</w:drawing>`)
+	docPrPattern    = regexp.MustCompile(`<wp:docPr\b[^>]*>`)
+	docPrIDPattern  = regexp.MustCompile(`(<wp:docPr\b[^>]*?\bid=")\d+"`)
+	embedPattern    = regexp.MustCompile(`r:embed="[^"]*"`)
+	bodyPattern     = regexp.MustCompile(`<w:body\b[^>]*>`)
+	bookmarkPattern = regexp.MustCompile(`(<w:bookmark(?:Start|End)\b[^>]*?\bw:id=")(\d+)"`)
+	pngTypePattern  = regexp.MustCompile(`(?i)Extension="png"`)
+)
+
+const (
+	imageRelationship = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	docxPageBreak     = `<w:p><w:r><w:br w:type="page"/></w:r></w:p>`
+)
+
+// qrPlaceholder finds {{qr}} or {{qr:column}} in text.
+func qrPlaceholder(text string) (string, bool) {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		if match[1] == "qr" || strings.HasPrefix(match[1], "qr:") {
+			return match[1], true
+		}
+	}
+	return "", false
+}
+
+// replaceQRPlaceholders swaps the placeholder in alternative text for the
+// content of the code, which is what a screen reader should announce.
+func replaceQRPlaceholders(text, content string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if _, ok := qrPlaceholder(match); ok {
+			return html.EscapeString(content)
+		}
+		return match
+	})
+}
+
+// replaceDOCXImages points each placeholder drawing at a new PNG of its
+// code. The placeholder image stays in the package, since other drawings may
+// share it.
+func (doc *document) replaceDOCXImages(part, xml string, row dataset.Row) (string, error) {
+	var err error
+	result := drawingPattern.ReplaceAllStringFunc(xml, func(drawing string) string {
+		placeholder, ok := qrPlaceholder(html.UnescapeString(docPrPattern.FindString(drawing)))
+		if err != nil || !ok {
+			return drawing
+		}
+		var (
+			image   []byte
+			content string
+		)
+		if image, content, err = doc.code(placeholder, row); err != nil {
+			return drawing
+		}
+
+		doc.images++
+		target := fmt.Sprintf("media/qr%d.png", doc.images)
+		id := fmt.Sprintf("rIdQr%d", doc.images)
+		doc.add(path.Join(path.Dir(part), target), image)
+		doc.addRelationship(part, id, target)
+
+		drawing = embedPattern.ReplaceAllString(drawing, `r:embed="`+id+`"`)
+		return docPrPattern.ReplaceAllStringFunc(drawing, func(docPr string) string {
+			return replaceQRPlaceholders(docPr, content)
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	if doc.images > 0 {
+		doc.ensurePNGContentType()
+	}
+	return result, nil
+}
+
+func (doc *document) addRelationship(part, id, target string) {
+	name := path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+	rels := string(doc.files[name])
+	if rels == "" {
+		rels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"></Relationships>`
+	}
+	relationship := fmt.Sprintf(`<Relationship Id="%s" Type="%s" Target="%s"/>`, id, imageRelationship, target)
+	doc.add(name, []byte(strings.Replace(rels, "</Relationships>", relationship+"</Relationships>", 1)))
+}
+
+func (doc *document) ensurePNGContentType() {
+	types := string(doc.files["[Content_Types].xml"])
+	if pngTypePattern.MatchString(types) {
+		return
+	}
+	png := `<Default Extension="png" ContentType="image/png"/>`
+	doc.files["[Content_Types].xml"] = []byte(strings.Replace(types, "</Types>", png+"</Types>", 1))
+}
+
+// combineDOCX repeats the body for each row, with page breaks between and
+// the section properties of the template at the end.
+func (doc *document) combineDOCX(rows []dataset.Row) (string, error) {
+	xml := string(doc.template.data["word/document.xml"])
+	open := bodyPattern.FindStringIndex(xml)
+	end := strings.LastIndex(xml, "</w:body>")
+	if open == nil || end < open[1] {
+		return "", fmt.Errorf("word/document.xml has no body")
+	}
+	body, section := xml[open[1]:end], ""
+	if start := strings.LastIndex(body, "<w:sectPr"); start >= 0 {
+		body, section = body[:start], body[start:]
+	}
+
+	var combined strings.Builder
+	for i, row := range rows {
+		merged, err := doc.mergePart("word/document.xml", body, row)
+		if err != nil {
+			return "", fmt.Errorf("row %d: %v", i+1, err)
+		}
+		// Bookmark IDs must stay unique across the copies.
+		merged = bookmarkPattern.ReplaceAllStringFunc(merged, func(match string) string {
+			parts := bookmarkPattern.FindStringSubmatch(match)
+			id, _ := strconv.Atoi(parts[2])
+			return fmt.Sprintf(`%s%d"`, parts[1], id+i*100000)
+		})
+		if i > 0 {
+			combined.WriteString(docxPageBreak)
+		}
+		combined.WriteString(merged)
+	}
+
+	result := xml[:open[1]] + combined.String() + section + xml[end:]
+	// So must drawing IDs, or Word reports the document as damaged.
+	next := 0
+	result = docPrIDPattern.ReplaceAllStringFunc(result, func(match string) string {
+		next++
+		return fmt.Sprintf(`%s%d"`, docPrIDPattern.FindStringSubmatch(match)[1], next)
+	})
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package merge
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"qr-code-generator/dataset"
+)
+
+var (
+	framePattern    = regexp.MustCompile(`(?s)<draw:frame\b.*?</draw:frame>`)
+	imageTagPattern = regexp.MustCompile(`<draw:image\b[^>]*>`)
+	hrefPattern     = regexp.MustCompile(`xlink:href="[^"]*"`)
+	mimeTypePattern = regexp.MustCompile(`(draw|loext):mime-type="[^"]*"`)
+	officeText      = regexp.MustCompile(`<office:text\b[^>]*>`)
+	declarations    = regexp.MustCompile(`(?s)<text:(sequence|variable|user-field)-decls\b.*?</text:(sequence|variable|user-field)-decls>|<office:forms\b[^>]*/>|<office:forms\b.*?</office:forms>`)
+	automaticStyles = regexp.MustCompile(`<office:automatic-styles\s*/>`)
+)
+
+const odtPageBreakName = "MergePageBreak"
+
+// replaceODTImages points each placeholder frame at a new PNG of its code.
+func (doc *document) replaceODTImages(xml string, row dataset.Row) (string, error) {
+	var err error
+	result := framePattern.ReplaceAllStringFunc(xml, func(frame string) string {
+		placeholder, ok := qrPlaceholder(html.UnescapeString(frame))
+		if err != nil || !ok || !imageTagPattern.MatchString(frame) {
+			return frame
+		}
+		var (
+			image   []byte
+			content string
+		)
+		if image, content, err = doc.code(placeholder, row); err != nil {
+			return frame
+		}
+
+		doc.images++
+		name := fmt.Sprintf("Pictures/qr%d.png", doc.images)
+		doc.add(name, image)
+		doc.addManifestEntry(name)
+
+		frame = imageTagPattern.ReplaceAllStringFunc(frame, func(tag string) string {
+			tag = hrefPattern.ReplaceAllString(tag, `xlink:href="`+name+`"`)
+			return mimeTypePattern.ReplaceAllString(tag, `$1:mime-type="image/png"`)
+		})
+		return replaceQRPlaceholders(frame, content)
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func (doc *document) addManifestEntry(name string) {
+	manifest := string(doc.files["META-INF/manifest.xml"])
+	entry := fmt.Sprintf(`<manifest:file-entry manifest:full-path="%s" manifest:media-type="image/png"/>`, name)
+	doc.files["META-INF/manifest.xml"] = []byte(strings.Replace(manifest, "</manifest:manifest>", entry+"</manifest:manifest>", 1))
+}
+
+// combineODT repeats the text for each row, each after a page break.
+// Declarations of variables and forms are kept from the first copy only.
+func (doc *document) combineODT(rows []dataset.Row) (string, error) {
+	xml := string(doc.template.data["content.xml"])
+	open := officeText.FindStringIndex(xml)
+	end := strings.LastIndex(xml, "</office:text>")
+	if open == nil || end < open[1] {
+		return "", fmt.Errorf("content.xml has no text body")
+	}
+	body := xml[open[1]:end]
+
+	var combined strings.Builder
+	for i, row := range rows {
+		text := body
+		if i > 0 {
+			text = declarations.ReplaceAllString(text, "")
+			fmt.Fprintf(&combined, `<text:p text:style-name="%s"/>`, odtPageBreakName)
+		}
+		merged, err := doc.mergePart("content.xml", text, row)
+		if err != nil {
+			return "", fmt.Errorf("row %d: %v", i+1, err)
+		}
+		combined.WriteString(merged)
+	}
+
+	head := xml[:open[1]]
+	style := fmt.Sprintf(
+		`<style:style style:name="%s" style:family="paragraph"><style:paragraph-properties fo:break-before="page"/></style:style>`,
+		odtPageBreakName,
+	)
+	switch {
+	case strings.Contains(head, "</office:automatic-styles>"):
+		head = strings.Replace(head, "</office:automatic-styles>", style+"</office:automatic-styles>", 1)
+	case automaticStyles.MatchString(head):
+		head = automaticStyles.ReplaceAllLiteralString(head, "<office:automatic-styles>"+style+"</office:automatic-styles>")
+	default:
+		head = strings.Replace(head, "<office:body>", "<office:automatic-styles>"+style+"</office:automatic-styles><office:body>", 1)
+	}
+	return head + combined.String() + xml[end:], nil
+}
@@ -0,0 +1,310 @@
+package merge
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"qr-code-generator/dataset"
+	"qr-code-generator/qrcode"
+)
+
+type Format string
+
+const (
+	DOCX Format = "docx"
+	ODT  Format = "odt"
+)
+
+// Template is a Word or OpenDocument text document with placeholders. Text
+// placeholders are written {{column}}. Images whose alternative text or name
+// contains {{qr}} are replaced with a QR code of the content template, and
+// {{qr:column}} with a code of that column.
+type Template struct {
+	Format Format
+	names  []string
+	files  map[string]*zip.File
+	data   map[string][]byte
+}
+
+// Options control the codes that replace placeholder images.
+type Options struct {
+	// Content is expanded with the {column} values of each row.
+	Content string
+	// Size is the width of the generated images in pixels. The code is
+	// shown at the size of the placeholder image.
+	Size int
+}
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+	docxPartPattern    = regexp.MustCompile(`^word/((header|footer)\d*|document)\.xml$`)
+)
+
+// Parts are read whole to be merged, so they are limited to keep a small
+// zip bomb from exhausting memory. Other entries, such as images, are
+// copied to the output without being unzipped.
+const (
+	maxPartBytes     = 32 << 20
+	maxTemplateBytes = 64 << 20
+)
+
+var docxRelsPattern = regexp.MustCompile(`^word/_rels/((header|footer)\d*|document)\.xml\.rels$`)
+
+// merged reports whether an entry of a template is read or rewritten when
+// documents are merged.
+func merged(name string) bool {
+	switch name {
+	case "mimetype", "[Content_Types].xml", "META-INF/manifest.xml", "content.xml", "styles.xml":
+		return true
+	}
+	return docxPartPattern.MatchString(name) || docxRelsPattern.MatchString(name)
+}
+
+func OpenTemplate(data []byte) (*Template, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("template is not a DOCX or ODT file: %v", err)
+	}
+
+	template := &Template{files: map[string]*zip.File{}, data: map[string][]byte{}}
+	total := 0
+	for _, file := range reader.File {
+		template.names = append(template.names, file.Name)
+		template.files[file.Name] = file
+		if !merged(file.Name) {
+			continue
+		}
+		content, err := readFile(file, min(maxPartBytes, maxTemplateBytes-total))
+		if err != nil {
+			return nil, err
+		}
+		total += len(content)
+		template.data[file.Name] = content
+	}
+
+	switch {
+	case template.data["word/document.xml"] != nil:
+		template.Format = DOCX
+	case strings.HasPrefix(string(template.data["mimetype"]), "application/vnd.oasis.opendocument.text"):
+		template.Format = ODT
+	default:
+		return nil, fmt.Errorf("template is neither a Word document nor an OpenDocument text")
+	}
+	return template, nil
+}
+
+func readFile(file *zip.File, limit int) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("could not unzip %s: %v", file.Name, err)
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("%s is too large once unzipped: parts may be up to %d MB and %d MB together", file.Name, maxPartBytes>>20, maxTemplateBytes>>20)
+	}
+	return data, nil
+}
+
+// document is one output document being assembled from the template.
+type document struct {
+	template *Template
+	options  Options
+	files    map[string][]byte
+	added    []string
+	images   int
+}
+
+func (template *Template) newDocument(options Options) *document {
+	files := map[string][]byte{}
+	for name, data := range template.data {
+		files[name] = data
+	}
+	return &document{template: template, options: options, files: files}
+}
+
+func (doc *document) add(name string, data []byte) {
+	if _, ok := doc.template.files[name]; !ok {
+		if _, ok := doc.files[name]; !ok {
+			doc.added = append(doc.added, name)
+		}
+	}
+	doc.files[name] = data
+}
+
+// code returns the QR code for a placeholder such as qr or qr:column, and
+// the content it encodes.
+func (doc *document) code(placeholder string, row dataset.Row) ([]byte, string, error) {
+	content := row.Expand(doc.options.Content)
+	if column, ok := strings.CutPrefix(placeholder, "qr:"); ok {
+		value, found := row[strings.TrimSpace(column)]
+		if !found {
+			return nil, "", fmt.Errorf("image placeholder {{%s}} names a column that is not in the data", placeholder)
+		}
+		content = value
+	} else if doc.options.Content == "" {
+		return nil, "", fmt.Errorf("image placeholder {{qr}} needs a content template")
+	}
+	if content == "" {
+		return nil, "", fmt.Errorf("the QR code content is empty")
+	}
+
+	size := doc.options.Size
+	if size <= 0 {
+		size = 600
+	}
+	image, err := (&qrcode.SimpleQRCode{Content: content, Size: size}).Generate()
+	return image, content, err
+}
+
+// bytes writes the document with the original order of the template's
+// files, copying those that were not merged as they are. For ODT, the
+// mimetype entry stays first and uncompressed.
+func (doc *document) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, name := range append(append([]string{}, doc.template.names...), doc.added...) {
+		original, ok := doc.template.files[name]
+		if _, read := doc.files[name]; ok && !read {
+			if err := writer.Copy(original); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		if ok {
+			header.Method = original.Method
+			header.Modified = original.Modified
+		}
+		if name == "mimetype" {
+			header.Method = zip.Store
+		}
+		entry, err := writer.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(doc.files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Placeholders lists the text placeholders of the template, so that missing
+// columns are reported before any document is merged.
+func (template *Template) Placeholders() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, part := range template.parts() {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(plainText(string(template.data[part])), -1) {
+			if name := match[1]; !seen[name] && name != "qr" && !strings.HasPrefix(name, "qr:") {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// parts are the XML files that may hold placeholders.
+func (template *Template) parts() []string {
+	var parts []string
+	for _, name := range template.names {
+		switch template.Format {
+		case DOCX:
+			if docxPartPattern.MatchString(name) {
+				parts = append(parts, name)
+			}
+		case ODT:
+			if name == "content.xml" || name == "styles.xml" {
+				parts = append(parts, name)
+			}
+		}
+	}
+	return parts
+}
+
+// Document merges one row into the template.
+func (template *Template) Document(row dataset.Row, options Options) ([]byte, error) {
+	doc := template.newDocument(options)
+	for _, part := range template.parts() {
+		merged, err := doc.mergePart(part, string(template.data[part]), row)
+		if err != nil {
+			return nil, err
+		}
+		doc.files[part] = []byte(merged)
+	}
+	return doc.bytes()
+}
+
+func (doc *document) mergePart(part, xml string, row dataset.Row) (string, error) {
+	var err error
+	switch doc.template.Format {
+	case DOCX:
+		xml, err = doc.replaceDOCXImages(part, xml, row)
+	case ODT:
+		xml, err = doc.replaceODTImages(xml, row)
+	}
+	if err != nil {
+		return "", err
+	}
+	return mergeText(xml, doc.template.paragraphEnds(), row)
+}
+
+func (template *Template) paragraphEnds() []string {
+	if template.Format == DOCX {
+		return []string{"</w:p>"}
+	}
+	return []string{"</text:p>", "</text:h>"}
+}
+
+// Combined merges every row into one document, each starting on a new
+// page. Headers and footers are merged with the first row.
+func (template *Template) Combined(rows []dataset.Row, options Options) ([]byte, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("there are no rows to merge")
+	}
+	doc := template.newDocument(options)
+	for _, part := range template.parts() {
+		if part == template.bodyPart() {
+			continue
+		}
+		merged, err := doc.mergePart(part, string(template.data[part]), rows[0])
+		if err != nil {
+			return nil, err
+		}
+		doc.files[part] = []byte(merged)
+	}
+
+	var (
+		merged string
+		err    error
+	)
+	if template.Format == DOCX {
+		merged, err = doc.combineDOCX(rows)
+	} else {
+		merged, err = doc.combineODT(rows)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc.files[template.bodyPart()] = []byte(merged)
+	return doc.bytes()
+}
+
+func (template *Template) bodyPart() string {
+	if template.Format == DOCX {
+		return "word/document.xml"
+	}
+	return "content.xml"
+}
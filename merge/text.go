@@ -0,0 +1,126 @@
+package merge
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"qr-code-generator/dataset"
+)
+
+// segment is a run of character data between two tags.
+type segment struct {
+	start, end int
+	text       string
+}
+
+// paragraphs splits the character data of xml into paragraphs, ending each
+// at one of the given closing tags. Word splits text into runs as it is
+// edited, so a placeholder may be spread over several segments.
+func paragraphs(xml string, ends []string) [][]segment {
+	var (
+		result  [][]segment
+		current []segment
+	)
+	for i := 0; i < len(xml); {
+		if xml[i] == '<' {
+			end := strings.IndexByte(xml[i:], '>')
+			if end < 0 {
+				break
+			}
+			tag := xml[i : i+end+1]
+			for _, paragraphEnd := range ends {
+				if tag == paragraphEnd {
+					result = append(result, current)
+					current = nil
+				}
+			}
+			i += end + 1
+			continue
+		}
+		end := strings.IndexByte(xml[i:], '<')
+		if end < 0 {
+			end = len(xml) - i
+		}
+		current = append(current, segment{start: i, end: i + end, text: html.UnescapeString(xml[i : i+end])})
+		i += end
+	}
+	return append(result, current)
+}
+
+func plainText(xml string) string {
+	var lines []string
+	for _, paragraph := range paragraphs(xml, []string{"</w:p>", "</text:p>", "</text:h>"}) {
+		var line strings.Builder
+		for _, seg := range paragraph {
+			line.WriteString(seg.text)
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// mergeText replaces the {{column}} placeholders in the character data of
+// xml. The value goes into the segment where the placeholder starts, and the
+// rest of the placeholder is removed from the segments after it.
+func mergeText(xml string, ends []string, row dataset.Row) (string, error) {
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+
+	for _, paragraph := range paragraphs(xml, ends) {
+		var (
+			text  strings.Builder
+			owner []int
+		)
+		// owner gives the segment of each byte of the paragraph's text.
+		for i, seg := range paragraph {
+			text.WriteString(seg.text)
+			for j := 0; j < len(seg.text); j++ {
+				owner = append(owner, i)
+			}
+		}
+
+		matches := placeholderPattern.FindAllStringSubmatchIndex(text.String(), -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		replaced := make([]strings.Builder, len(paragraph))
+		position := 0
+		for _, match := range matches {
+			name := text.String()[match[2]:match[3]]
+			value, ok := row[name]
+			if !ok {
+				return "", fmt.Errorf("the template uses {{%s}}, which is not a column of the data", name)
+			}
+			for ; position < match[0]; position++ {
+				replaced[owner[position]].WriteByte(text.String()[position])
+			}
+			replaced[owner[match[0]]].WriteString(value)
+			position = match[1]
+		}
+		for ; position < text.Len(); position++ {
+			replaced[owner[position]].WriteByte(text.String()[position])
+		}
+
+		for i, seg := range paragraph {
+			if replaced[i].String() != seg.text {
+				edits = append(edits, edit{seg.start, seg.end, xmlEscaper.Replace(replaced[i].String())})
+			}
+		}
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		xml = xml[:e.start] + e.text + xml[e.end:]
+	}
+	// Values may start or end with spaces, which Word drops unless told
+	// to keep them.
+	return strings.ReplaceAll(xml, "<w:t>", `<w:t xml:space="preserve">`), nil
+}
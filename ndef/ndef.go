@@ -0,0 +1,95 @@
+package ndef
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// TNF is the type name format of a record, which says how its type is to be
+// read.
+type TNF byte
+
+const (
+	Empty       TNF = 0x00
+	WellKnown   TNF = 0x01
+	MIMEMedia   TNF = 0x02
+	AbsoluteURI TNF = 0x03
+	External    TNF = 0x04
+)
+
+const (
+	flagMessageBegin = 0x80
+	flagMessageEnd   = 0x40
+	flagShortRecord  = 0x10
+	flagIDLength     = 0x08
+)
+
+type Record struct {
+	TNF     TNF
+	Type    []byte
+	ID      []byte
+	Payload []byte
+}
+
+// Message is an NDEF message, the unit written to an NFC tag.
+type Message []Record
+
+func (record *Record) validate() error {
+	if record.TNF > External {
+		return fmt.Errorf("type name format %d is not supported", record.TNF)
+	}
+	if len(record.Type) > 0xff {
+		return fmt.Errorf("record type must not exceed 255 bytes")
+	}
+	if len(record.ID) > 0xff {
+		return fmt.Errorf("record ID must not exceed 255 bytes")
+	}
+	if record.TNF == Empty && (len(record.Type) > 0 || len(record.Payload) > 0) {
+		return fmt.Errorf("empty records must not have a type or payload")
+	}
+	return nil
+}
+
+// Encode writes the records one after the other, marking the first and last,
+// and uses short records for payloads under 256 bytes.
+func (message Message) Encode() ([]byte, error) {
+	if len(message) == 0 {
+		return nil, fmt.Errorf("an NDEF message needs at least one record")
+	}
+
+	var data []byte
+	for i, record := range message {
+		if err := record.validate(); err != nil {
+			return nil, fmt.Errorf("record %d: %v", i+1, err)
+		}
+
+		header := byte(record.TNF)
+		if i == 0 {
+			header |= flagMessageBegin
+		}
+		if i == len(message)-1 {
+			header |= flagMessageEnd
+		}
+		short := len(record.Payload) <= 0xff
+		if short {
+			header |= flagShortRecord
+		}
+		if len(record.ID) > 0 {
+			header |= flagIDLength
+		}
+
+		data = append(data, header, byte(len(record.Type)))
+		if short {
+			data = append(data, byte(len(record.Payload)))
+		} else {
+			data = binary.BigEndian.AppendUint32(data, uint32(len(record.Payload)))
+		}
+		if len(record.ID) > 0 {
+			data = append(data, byte(len(record.ID)))
+		}
+		data = append(data, record.Type...)
+		data = append(data, record.ID...)
+		data = append(data, record.Payload...)
+	}
+	return data, nil
+}
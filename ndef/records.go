@@ -0,0 +1,85 @@
+package ndef
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// uriPrefixes are the abbreviations of the NFC Forum URI record type. The
+// index is the identifier code that replaces the prefix.
+var uriPrefixes = []string{
+	"",
+	"http://www.",
+	"https://www.",
+	"http://",
+	"https://",
+	"tel:",
+	"mailto:",
+	"ftp://anonymous:anonymous@",
+	"ftp://ftp.",
+	"ftps://",
+	"sftp://",
+	"smb://",
+	"nfs://",
+	"ftp://",
+	"dav://",
+	"news:",
+	"telnet://",
+	"imap:",
+	"rtsp://",
+	"urn:",
+	"pop:",
+	"sip:",
+	"sips:",
+	"tftp:",
+	"btspp://",
+	"btl2cap://",
+	"btgoep://",
+	"tcpobex://",
+	"irdaobex://",
+	"file://",
+	"urn:epc:id:",
+	"urn:epc:tag:",
+	"urn:epc:pat:",
+	"urn:epc:raw:",
+	"urn:epc:",
+	"urn:nfc:",
+}
+
+// URI returns a well-known URI record, replacing the longest known prefix
+// with its identifier code.
+func URI(uri string) Record {
+	code := 0
+	for i, prefix := range uriPrefixes {
+		if strings.HasPrefix(uri, prefix) && len(prefix) > len(uriPrefixes[code]) {
+			code = i
+		}
+	}
+	payload := append([]byte{byte(code)}, uri[len(uriPrefixes[code]):]...)
+	return Record{TNF: WellKnown, Type: []byte("U"), Payload: payload}
+}
+
+// Text returns a well-known text record in UTF-8. The language is an IANA
+// language tag, such as en or pt-BR.
+func Text(text, language string) (Record, error) {
+	if language == "" {
+		language = "en"
+	}
+	if len(language) > 0x3f {
+		return Record{}, fmt.Errorf("language code must not exceed 63 characters")
+	}
+	payload := append([]byte{byte(len(language))}, language...)
+	payload = append(payload, text...)
+	return Record{TNF: WellKnown, Type: []byte("T"), Payload: payload}, nil
+}
+
+// MIME returns a record holding data of the given media type, such as
+// text/vcard.
+func MIME(mediaType string, data []byte) (Record, error) {
+	parsed, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return Record{}, fmt.Errorf("%q is not a media type: %v", mediaType, err)
+	}
+	return Record{TNF: MIMEMedia, Type: []byte(parsed), Payload: data}, nil
+}
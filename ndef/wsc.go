@@ -0,0 +1,88 @@
+package ndef
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// WSCMediaType is the media type of Wi-Fi Simple Configuration records,
+// which Android and iOS read to join a network from a tag.
+const WSCMediaType = "application/vnd.wfa.wsc"
+
+type Authentication uint16
+
+const (
+	OpenAuthentication   Authentication = 0x0001
+	WPAPersonal          Authentication = 0x0002
+	SharedAuthentication Authentication = 0x0004
+	WPA2Personal         Authentication = 0x0020
+)
+
+type Encryption uint16
+
+const (
+	NoEncryption  Encryption = 0x0001
+	WEPEncryption Encryption = 0x0002
+	TKIP          Encryption = 0x0004
+	AES           Encryption = 0x0008
+)
+
+// WiFiCredential is the network part of a WSC credential.
+type WiFiCredential struct {
+	SSID           string
+	NetworkKey     string
+	Authentication Authentication
+	Encryption     Encryption
+}
+
+// WSC attribute types.
+const (
+	attributeAuthenticationType = 0x1003
+	attributeCredential         = 0x100e
+	attributeEncryptionType     = 0x100f
+	attributeMACAddress         = 0x1020
+	attributeNetworkIndex       = 0x1026
+	attributeNetworkKey         = 0x1027
+	attributeSSID               = 0x1045
+	attributeVendorExtension    = 0x1049
+	attributeVersion            = 0x104a
+)
+
+// The WFA vendor extension carries version 2.0 of the specification.
+var wfaVersion2 = []byte{0x00, 0x37, 0x2a, 0x00, 0x01, 0x20}
+
+func attribute(kind uint16, value []byte) []byte {
+	data := binary.BigEndian.AppendUint16(nil, kind)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(value)))
+	return append(data, value...)
+}
+
+func uint16Value(value uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, value)
+}
+
+// WiFi returns a WSC configuration token for the network, as written to
+// Wi-Fi connection tags.
+func WiFi(credential WiFiCredential) (Record, error) {
+	if credential.SSID == "" || len(credential.SSID) > 32 {
+		return Record{}, fmt.Errorf("SSID must be 1 to 32 bytes")
+	}
+	if len(credential.NetworkKey) > 64 {
+		return Record{}, fmt.Errorf("network key must not exceed 64 bytes")
+	}
+
+	var network []byte
+	network = append(network, attribute(attributeNetworkIndex, []byte{1})...)
+	network = append(network, attribute(attributeSSID, []byte(credential.SSID))...)
+	network = append(network, attribute(attributeAuthenticationType, uint16Value(uint16(credential.Authentication)))...)
+	network = append(network, attribute(attributeEncryptionType, uint16Value(uint16(credential.Encryption)))...)
+	network = append(network, attribute(attributeNetworkKey, []byte(credential.NetworkKey))...)
+	network = append(network, attribute(attributeMACAddress, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})...)
+
+	var payload []byte
+	payload = append(payload, attribute(attributeVersion, []byte{0x10})...)
+	payload = append(payload, attribute(attributeCredential, network)...)
+	payload = append(payload, attribute(attributeVendorExtension, wfaVersion2)...)
+
+	return MIME(WSCMediaType, payload)
+}
@@ -0,0 +1,72 @@
+package onboarding
+
+import (
+	"fmt"
+	"strings"
+)
+
+const base38Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ-."
+
+// Base38 encodes bytes in little-endian groups of three, each written as five
+// characters, least significant first. A trailing group of two bytes takes
+// four characters and a single byte two.
+var base38Chars = map[int]int{1: 2, 2: 4, 3: 5}
+
+func base38Encode(data []byte) string {
+	var result strings.Builder
+	for i := 0; i < len(data); i += 3 {
+		end := i + 3
+		if end > len(data) {
+			end = len(data)
+		}
+		value := 0
+		for j := end - 1; j >= i; j-- {
+			value = value<<8 | int(data[j])
+		}
+		for k := 0; k < base38Chars[end-i]; k++ {
+			result.WriteByte(base38Alphabet[value%38])
+			value /= 38
+		}
+	}
+	return result.String()
+}
+
+func base38Decode(text string) ([]byte, error) {
+	var result []byte
+	for i := 0; i < len(text); i += 5 {
+		end := i + 5
+		if end > len(text) {
+			end = len(text)
+		}
+		chunk := text[i:end]
+
+		var size int
+		switch len(chunk) {
+		case 5:
+			size = 3
+		case 4:
+			size = 2
+		case 2:
+			size = 1
+		default:
+			return nil, fmt.Errorf("base38 text has an invalid length %d", len(text))
+		}
+
+		value := 0
+		for k := len(chunk) - 1; k >= 0; k-- {
+			digit := strings.IndexByte(base38Alphabet, chunk[k])
+			if digit < 0 {
+				return nil, fmt.Errorf("%q is not a base38 character", chunk[k])
+			}
+			value = value*38 + digit
+		}
+		if value >= 1<<(8*size) {
+			return nil, fmt.Errorf("base38 chunk %q is out of range", chunk)
+		}
+		for k := 0; k < size; k++ {
+			result = append(result, byte(value))
+			value >>= 8
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,179 @@
+package onboarding
+
+import (
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+)
+
+const dppPrefix = "DPP:"
+
+// DPPURI is a Wi-Fi Easy Connect bootstrapping URI. Only the public key is
+// required.
+type DPPURI struct {
+	Channels    []string `json:"channels,omitempty"`
+	MAC         string   `json:"mac,omitempty"`
+	Information string   `json:"information,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Host        string   `json:"host,omitempty"`
+	PublicKey   string   `json:"public_key"`
+}
+
+var (
+	channelPattern = regexp.MustCompile(`^\d{1,3}/\d{1,3}$`)
+	macPattern     = regexp.MustCompile(`^[0-9a-fA-F]{12}$`)
+	versionPattern = regexp.MustCompile(`^\d+$`)
+	hostPattern    = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9\-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9\-]*[A-Za-z0-9])?)*$`)
+)
+
+func (uri *DPPURI) Validate() error {
+	for _, channel := range uri.Channels {
+		if !channelPattern.MatchString(channel) {
+			return fmt.Errorf("channel %q must be an operating class and channel, such as 81/1", channel)
+		}
+	}
+	if uri.MAC != "" && !macPattern.MatchString(uri.MAC) {
+		return fmt.Errorf("MAC address must be 12 hexadecimal digits without separators")
+	}
+	for _, char := range uri.Information {
+		if char < 0x20 || char > 0x7e || char == ';' {
+			return fmt.Errorf("information must be printable ASCII without semicolons")
+		}
+	}
+	if uri.Version != "" && !versionPattern.MatchString(uri.Version) {
+		return fmt.Errorf("version must be a number, got %q", uri.Version)
+	}
+	if uri.Host != "" && net.ParseIP(uri.Host) == nil && !hostPattern.MatchString(uri.Host) {
+		return fmt.Errorf("host %q is not a host name or IP address", uri.Host)
+	}
+
+	return validatePublicKey(uri.PublicKey)
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm struct {
+		Algorithm  asn1.ObjectIdentifier
+		Parameters asn1.ObjectIdentifier
+	}
+	PublicKey asn1.BitString
+}
+
+var (
+	ecPublicKeyOID = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	namedCurves    = map[string]elliptic.Curve{
+		"1.2.840.10045.3.1.7": elliptic.P256(),
+		"1.3.132.0.34":        elliptic.P384(),
+		"1.3.132.0.35":        elliptic.P521(),
+	}
+)
+
+// validatePublicKey checks the bootstrapping key by hand rather than with
+// x509.ParsePKIXPublicKey, since DPP keys are usually compressed points,
+// which crypto/x509 does not accept.
+func validatePublicKey(encoded string) error {
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("public key is not base64: %v", err)
+	}
+
+	var info subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil || len(rest) > 0 {
+		return fmt.Errorf("public key is not a DER SubjectPublicKeyInfo")
+	}
+	if !info.Algorithm.Algorithm.Equal(ecPublicKeyOID) {
+		return fmt.Errorf("public key must be an elliptic curve key")
+	}
+	curve, ok := namedCurves[info.Algorithm.Parameters.String()]
+	if !ok {
+		return fmt.Errorf("public key curve %s is not supported", info.Algorithm.Parameters)
+	}
+
+	point := info.PublicKey.RightAlign()
+	var x *big.Int
+	if len(point) > 0 && point[0] == 4 {
+		x, _ = elliptic.Unmarshal(curve, point)
+	} else {
+		x, _ = elliptic.UnmarshalCompressed(curve, point)
+	}
+	if x == nil {
+		return fmt.Errorf("public key is not a point on %s", curve.Params().Name)
+	}
+	return nil
+}
+
+func (uri *DPPURI) Encode() (string, error) {
+	if err := uri.Validate(); err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(dppPrefix)
+	if len(uri.Channels) > 0 {
+		result.WriteString("C:" + strings.Join(uri.Channels, ",") + ";")
+	}
+	if uri.MAC != "" {
+		result.WriteString("M:" + strings.ToLower(uri.MAC) + ";")
+	}
+	if uri.Information != "" {
+		result.WriteString("I:" + uri.Information + ";")
+	}
+	if uri.Version != "" {
+		result.WriteString("V:" + uri.Version + ";")
+	}
+	if uri.Host != "" {
+		result.WriteString("H:" + uri.Host + ";")
+	}
+	result.WriteString("K:" + uri.PublicKey + ";;")
+	return result.String(), nil
+}
+
+func ParseDPP(text string) (*DPPURI, error) {
+	if !strings.HasPrefix(text, dppPrefix) {
+		return nil, fmt.Errorf("Wi-Fi Easy Connect URIs start with %s", dppPrefix)
+	}
+	if !strings.HasSuffix(text, ";;") {
+		return nil, fmt.Errorf("Wi-Fi Easy Connect URIs end with ;;")
+	}
+
+	uri := &DPPURI{}
+	seen := map[string]bool{}
+	for _, token := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(text, dppPrefix), ";;"), ";") {
+		name, value, ok := strings.Cut(token, ":")
+		if !ok || len(name) != 1 {
+			return nil, fmt.Errorf("malformed token %q", token)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("token %s appears more than once", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "C":
+			uri.Channels = strings.Split(value, ",")
+		case "M":
+			uri.MAC = value
+		case "I":
+			uri.Information = value
+		case "V":
+			uri.Version = value
+		case "H":
+			uri.Host = value
+		case "K":
+			uri.PublicKey = value
+		}
+	}
+	if uri.PublicKey == "" {
+		return nil, fmt.Errorf("public key (K) is required")
+	}
+
+	if err := uri.Validate(); err != nil {
+		return nil, err
+	}
+	return uri, nil
+}
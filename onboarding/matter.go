@@ -0,0 +1,191 @@
+package onboarding
+
+import (
+	"fmt"
+	"strings"
+)
+
+type CommissioningFlow int
+
+const (
+	StandardFlow   CommissioningFlow = 0
+	UserIntentFlow CommissioningFlow = 1
+	CustomFlow     CommissioningFlow = 2
+)
+
+// Discovery capabilities, combined as a bitmask.
+const (
+	SoftAP    = 1 << 0
+	BLE       = 1 << 1
+	OnNetwork = 1 << 2
+	WiFiPAF   = 1 << 3
+)
+
+// Context tags of the optional data defined by the Matter specification.
+const (
+	serialNumberTag         = 0x00
+	commissioningTimeoutTag = 0x04
+)
+
+const matterPrefix = "MT:"
+
+// MatterPayload is the onboarding payload carried in a Matter QR code.
+type MatterPayload struct {
+	Version               int               `json:"version"`
+	VendorID              uint16            `json:"vendor_id"`
+	ProductID             uint16            `json:"product_id"`
+	Flow                  CommissioningFlow `json:"commissioning_flow"`
+	DiscoveryCapabilities uint8             `json:"discovery_capabilities"`
+	Discriminator         uint16            `json:"discriminator"`
+	Passcode              uint32            `json:"passcode"`
+	SerialNumber          string            `json:"serial_number,omitempty"`
+	CommissioningTimeout  uint16            `json:"commissioning_timeout,omitempty"`
+}
+
+// Passcodes that are trivially guessable are not allowed.
+var invalidPasscodes = map[uint32]bool{
+	0: true, 11111111: true, 22222222: true, 33333333: true, 44444444: true,
+	55555555: true, 66666666: true, 77777777: true, 88888888: true, 99999999: true,
+	12345678: true, 87654321: true,
+}
+
+func ValidPasscode(passcode uint32) bool {
+	return passcode >= 1 && passcode <= 99999998 && !invalidPasscodes[passcode]
+}
+
+func (payload *MatterPayload) Validate() error {
+	if payload.Version != 0 {
+		return fmt.Errorf("only payload version 0 is supported, got %d", payload.Version)
+	}
+	if payload.Flow < StandardFlow || payload.Flow > CustomFlow {
+		return fmt.Errorf("commissioning flow must be 0, 1 or 2, got %d", payload.Flow)
+	}
+	if payload.DiscoveryCapabilities == 0 || payload.DiscoveryCapabilities&^0x0f != 0 {
+		return fmt.Errorf("discovery capabilities must combine SoftAP (1), BLE (2), on-network (4) and Wi-Fi PAF (8)")
+	}
+	if payload.Discriminator > 0xfff {
+		return fmt.Errorf("discriminator must fit in 12 bits, got %d", payload.Discriminator)
+	}
+	if !ValidPasscode(payload.Passcode) {
+		return fmt.Errorf("passcode %08d is not allowed", payload.Passcode)
+	}
+	if len(payload.SerialNumber) > 32 {
+		return fmt.Errorf("serial number must not exceed 32 characters")
+	}
+	return nil
+}
+
+type bitWriter struct {
+	data   []byte
+	offset int
+}
+
+func (w *bitWriter) write(value uint64, bits int) {
+	for i := 0; i < bits; i++ {
+		if w.offset/8 >= len(w.data) {
+			w.data = append(w.data, 0)
+		}
+		if value&(1<<i) != 0 {
+			w.data[w.offset/8] |= 1 << (w.offset % 8)
+		}
+		w.offset++
+	}
+}
+
+type bitReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *bitReader) read(bits int) uint64 {
+	var value uint64
+	for i := 0; i < bits; i++ {
+		if r.data[r.offset/8]&(1<<(r.offset%8)) != 0 {
+			value |= 1 << i
+		}
+		r.offset++
+	}
+	return value
+}
+
+const matterBaseBytes = 11
+
+func (payload *MatterPayload) Encode() (string, error) {
+	if err := payload.Validate(); err != nil {
+		return "", err
+	}
+
+	w := &bitWriter{}
+	w.write(uint64(payload.Version), 3)
+	w.write(uint64(payload.VendorID), 16)
+	w.write(uint64(payload.ProductID), 16)
+	w.write(uint64(payload.Flow), 2)
+	w.write(uint64(payload.DiscoveryCapabilities), 8)
+	w.write(uint64(payload.Discriminator), 12)
+	w.write(uint64(payload.Passcode), 27)
+	w.write(0, 4)
+
+	data := w.data
+	if payload.SerialNumber != "" || payload.CommissioningTimeout != 0 {
+		tlv := &tlvWriter{data: []byte{tlvStructure}}
+		if payload.SerialNumber != "" {
+			tlv.text(serialNumberTag, payload.SerialNumber)
+		}
+		if payload.CommissioningTimeout != 0 {
+			tlv.unsigned(commissioningTimeoutTag, uint64(payload.CommissioningTimeout))
+		}
+		data = append(data, append(tlv.data, tlvEndOfContainer)...)
+	}
+
+	return matterPrefix + base38Encode(data), nil
+}
+
+func ParseMatter(text string) (*MatterPayload, error) {
+	if !strings.HasPrefix(text, matterPrefix) {
+		return nil, fmt.Errorf("Matter payloads start with %s", matterPrefix)
+	}
+
+	data, err := base38Decode(strings.TrimPrefix(text, matterPrefix))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < matterBaseBytes {
+		return nil, fmt.Errorf("Matter payload is %d bytes, at least %d are required", len(data), matterBaseBytes)
+	}
+
+	r := &bitReader{data: data}
+	payload := &MatterPayload{
+		Version:               int(r.read(3)),
+		VendorID:              uint16(r.read(16)),
+		ProductID:             uint16(r.read(16)),
+		Flow:                  CommissioningFlow(r.read(2)),
+		DiscoveryCapabilities: uint8(r.read(8)),
+		Discriminator:         uint16(r.read(12)),
+		Passcode:              uint32(r.read(27)),
+	}
+	if r.read(4) != 0 {
+		return nil, fmt.Errorf("Matter payload padding is not zero")
+	}
+
+	if len(data) > matterBaseBytes {
+		elements, err := parseTLV(data[matterBaseBytes:])
+		if err != nil {
+			return nil, err
+		}
+		for _, element := range elements {
+			switch {
+			case element.tag == serialNumberTag && element.isText:
+				payload.SerialNumber = element.text
+			case element.tag == serialNumberTag:
+				payload.SerialNumber = fmt.Sprint(element.unsigned)
+			case element.tag == commissioningTimeoutTag && !element.isText:
+				payload.CommissioningTimeout = uint16(element.unsigned)
+			}
+		}
+	}
+
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
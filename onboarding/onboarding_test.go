@@ -0,0 +1,243 @@
+package onboarding
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+// matterVectors are the example payloads of the Matter specification and
+// the connectedhomeip test suite.
+var matterVectors = []struct {
+	text    string
+	payload MatterPayload
+}{
+	{"MT:Y.K9042C00KA0648G00", MatterPayload{
+		VendorID: 0xfff1, ProductID: 0x8000, Flow: StandardFlow,
+		DiscoveryCapabilities: BLE, Discriminator: 3840, Passcode: 20202021,
+	}},
+	{"MT:-24J0AFN00KA0648G00", MatterPayload{
+		VendorID: 0xfff1, ProductID: 0x8001, Flow: StandardFlow,
+		DiscoveryCapabilities: OnNetwork, Discriminator: 3840, Passcode: 20202021,
+	}},
+	{"MT:M5L90MP500K64J00000", MatterPayload{
+		VendorID: 12, ProductID: 1, Flow: StandardFlow,
+		DiscoveryCapabilities: SoftAP, Discriminator: 128, Passcode: 2048,
+	}},
+}
+
+func TestParseMatterVectors(t *testing.T) {
+	for _, vector := range matterVectors {
+		payload, err := ParseMatter(vector.text)
+		if err != nil {
+			t.Errorf("ParseMatter(%q): %v", vector.text, err)
+			continue
+		}
+		if *payload != vector.payload {
+			t.Errorf("ParseMatter(%q) = %+v, expected %+v", vector.text, *payload, vector.payload)
+		}
+		if text, err := payload.Encode(); err != nil || text != vector.text {
+			t.Errorf("%q encodes back to %q, %v", vector.text, text, err)
+		}
+	}
+}
+
+func TestMatterOptionalData(t *testing.T) {
+	base := matterVectors[0].payload
+	for _, optional := range []struct {
+		serial  string
+		timeout uint16
+	}{
+		{"ABC123", 0},
+		{"", 900},
+		{"0123456789abcdef0123456789abcdef", 0xffff},
+		{"S", 60},
+	} {
+		payload := base
+		payload.SerialNumber = optional.serial
+		payload.CommissioningTimeout = optional.timeout
+		text, err := payload.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseMatter(text)
+		if err != nil {
+			t.Errorf("ParseMatter(%q): %v", text, err)
+			continue
+		}
+		if *parsed != payload {
+			t.Errorf("%q parses to %+v, expected %+v", text, *parsed, payload)
+		}
+	}
+}
+
+func TestParseMatterRejects(t *testing.T) {
+	for _, text := range []string{
+		"Y.K9042C00KA0648G00",
+		"MT:Y.K9042C00KA0648G0",
+		"MT:Y.K9042C00KA0648G0a",
+		"MT:Y.K9042C00KA06",
+		"MT:.....C00KA0648G00",
+	} {
+		if payload, err := ParseMatter(text); err == nil {
+			t.Errorf("ParseMatter(%q) = %+v, expected an error", text, *payload)
+		}
+	}
+}
+
+func TestMatterRejectsPasscodes(t *testing.T) {
+	for _, passcode := range []uint32{0, 11111111, 12345678, 87654321, 99999999, 1 << 27} {
+		payload := matterVectors[0].payload
+		payload.Passcode = passcode
+		if _, err := payload.Encode(); err == nil {
+			t.Errorf("passcode %d should be rejected", passcode)
+		}
+	}
+}
+
+func TestBase38(t *testing.T) {
+	for _, data := range [][]byte{
+		{}, {0}, {0xff}, {0, 0}, {0xff, 0xff}, {0xff, 0xff, 0xff},
+		{1, 2, 3, 4}, {1, 2, 3, 4, 5}, {0xde, 0xad, 0xbe, 0xef, 0x00, 0x01, 0x02},
+	} {
+		text := base38Encode(data)
+		if want := len(data)/3*5 + base38Chars[len(data)%3]; len(text) != want {
+			t.Errorf("%x encodes to %d characters, expected %d", data, len(text), want)
+		}
+		decoded, err := base38Decode(text)
+		if err != nil {
+			t.Errorf("base38Decode(%q): %v", text, err)
+			continue
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("%x encodes to %q, which decodes to %x", data, text, decoded)
+		}
+	}
+	for _, text := range []string{"A", "ABC", "ABCDEF", "abcde", "....."} {
+		if _, err := base38Decode(text); err == nil {
+			t.Errorf("base38Decode(%q) should fail", text)
+		}
+	}
+}
+
+// dppVectors are bootstrapping URIs from the hostapd DPP tests.
+var dppVectors = []struct {
+	text string
+	uri  DPPURI
+}{
+	{
+		"DPP:C:81/1,115/36;K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADM2206avxHJaHXgLMkq/24e0rsrfMP9K1Tm8gx+ovP0I=;;",
+		DPPURI{
+			Channels:  []string{"81/1", "115/36"},
+			PublicKey: "MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADM2206avxHJaHXgLMkq/24e0rsrfMP9K1Tm8gx+ovP0I=",
+		},
+	},
+	{
+		"DPP:C:81/1,115/36;M:5254005828e5;V:2;K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADURzxmttZoIRIPWGoQMV00XHWCAQIhXruVWOz0NjlkIA=;;",
+		DPPURI{
+			Channels:  []string{"81/1", "115/36"},
+			MAC:       "5254005828e5",
+			Version:   "2",
+			PublicKey: "MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADURzxmttZoIRIPWGoQMV00XHWCAQIhXruVWOz0NjlkIA=",
+		},
+	},
+}
+
+func TestParseDPPVectors(t *testing.T) {
+	for _, vector := range dppVectors {
+		uri, err := ParseDPP(vector.text)
+		if err != nil {
+			t.Errorf("ParseDPP(%q): %v", vector.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(*uri, vector.uri) {
+			t.Errorf("ParseDPP(%q) = %+v, expected %+v", vector.text, *uri, vector.uri)
+		}
+		if text, err := uri.Encode(); err != nil || text != vector.text {
+			t.Errorf("%q encodes back to %q, %v", vector.text, text, err)
+		}
+	}
+}
+
+// compressedKey returns a fresh P-256 public key as DPP writes it: a
+// compressed point in a SubjectPublicKeyInfo.
+func compressedKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := elliptic.Unmarshal(elliptic.P256(), key.PublicKey().Bytes())
+	var info subjectPublicKeyInfo
+	info.Algorithm.Algorithm = ecPublicKeyOID
+	info.Algorithm.Parameters = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	point := elliptic.MarshalCompressed(elliptic.P256(), x, y)
+	info.PublicKey = asn1.BitString{Bytes: point, BitLength: 8 * len(point)}
+	der, err := asn1.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func TestDPPRoundTrip(t *testing.T) {
+	key := compressedKey(t)
+	for _, uri := range []DPPURI{
+		{PublicKey: key},
+		{Channels: []string{"81/6"}, MAC: "0a1b2c3d4e5f", PublicKey: key},
+		{Information: "Kitchen light, v1.2", Version: "3", Host: "192.168.1.10", PublicKey: key},
+		{Channels: []string{"81/1", "81/6", "81/11"}, Host: "configurator.example.com", PublicKey: key},
+	} {
+		text, err := uri.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseDPP(text)
+		if err != nil {
+			t.Errorf("ParseDPP(%q): %v", text, err)
+			continue
+		}
+		if !reflect.DeepEqual(*parsed, uri) {
+			t.Errorf("%q parses to %+v, expected %+v", text, *parsed, uri)
+		}
+	}
+}
+
+func TestDPPUncompressedKey(t *testing.T) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri := DPPURI{PublicKey: base64.StdEncoding.EncodeToString(der)}
+	if err := uri.Validate(); err != nil {
+		t.Errorf("uncompressed key is rejected: %v", err)
+	}
+}
+
+func TestParseDPPRejects(t *testing.T) {
+	key := compressedKey(t)
+	for _, text := range []string{
+		"K:" + key + ";;",
+		"DPP:K:" + key + ";",
+		"DPP:C:81/1;;",
+		"DPP:K:" + key + ";K:" + key + ";;",
+		"DPP:M:52:54:00:58:28:e5;K:" + key + ";;",
+		"DPP:C:channel1;K:" + key + ";;",
+		"DPP:K:bm90IGEga2V5;;",
+		"DPP:K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgACAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE=;;",
+	} {
+		if uri, err := ParseDPP(text); err == nil {
+			t.Errorf("ParseDPP(%q) = %+v, expected an error", text, *uri)
+		}
+	}
+}
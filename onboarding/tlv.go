@@ -0,0 +1,94 @@
+package onboarding
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Just enough Matter TLV for the optional data in setup payloads: an
+// anonymous structure holding context-tagged unsigned integers and UTF-8
+// strings.
+const (
+	tlvContextTag     = 0x20
+	tlvUnsigned1      = 0x04
+	tlvUnsigned2      = 0x05
+	tlvUnsigned4      = 0x06
+	tlvUnsigned8      = 0x07
+	tlvUTF8String1    = 0x0c
+	tlvStructure      = 0x15
+	tlvEndOfContainer = 0x18
+)
+
+type tlvWriter struct {
+	data []byte
+}
+
+func (w *tlvWriter) unsigned(tag byte, value uint64) {
+	switch {
+	case value <= 0xff:
+		w.data = append(w.data, tlvContextTag|tlvUnsigned1, tag, byte(value))
+	case value <= 0xffff:
+		w.data = append(w.data, tlvContextTag|tlvUnsigned2, tag)
+		w.data = binary.LittleEndian.AppendUint16(w.data, uint16(value))
+	case value <= 0xffffffff:
+		w.data = append(w.data, tlvContextTag|tlvUnsigned4, tag)
+		w.data = binary.LittleEndian.AppendUint32(w.data, uint32(value))
+	default:
+		w.data = append(w.data, tlvContextTag|tlvUnsigned8, tag)
+		w.data = binary.LittleEndian.AppendUint64(w.data, value)
+	}
+}
+
+func (w *tlvWriter) text(tag byte, value string) {
+	w.data = append(w.data, tlvContextTag|tlvUTF8String1, tag, byte(len(value)))
+	w.data = append(w.data, value...)
+}
+
+type tlvElement struct {
+	tag      byte
+	unsigned uint64
+	text     string
+	isText   bool
+}
+
+func parseTLV(data []byte) ([]tlvElement, error) {
+	if len(data) < 2 || data[0] != tlvStructure || data[len(data)-1] != tlvEndOfContainer {
+		return nil, fmt.Errorf("optional data is not a TLV structure")
+	}
+	data = data[1 : len(data)-1]
+
+	var elements []tlvElement
+	for len(data) > 0 {
+		if len(data) < 2 || data[0]&0xe0 != tlvContextTag {
+			return nil, fmt.Errorf("optional data element is not context tagged")
+		}
+		element := tlvElement{tag: data[1]}
+		kind := data[0] & 0x1f
+		data = data[2:]
+
+		var size int
+		switch kind {
+		case tlvUnsigned1, tlvUnsigned2, tlvUnsigned4, tlvUnsigned8:
+			size = 1 << (kind - tlvUnsigned1)
+			if len(data) < size {
+				return nil, fmt.Errorf("optional data element %d is truncated", element.tag)
+			}
+			buf := make([]byte, 8)
+			copy(buf, data[:size])
+			element.unsigned = binary.LittleEndian.Uint64(buf)
+		case tlvUTF8String1:
+			if len(data) < 1 || len(data) < 1+int(data[0]) {
+				return nil, fmt.Errorf("optional data element %d is truncated", element.tag)
+			}
+			size = 1 + int(data[0])
+			element.text = string(data[1:size])
+			element.isText = true
+		default:
+			return nil, fmt.Errorf("optional data element %d has unsupported type 0x%02x", element.tag, kind)
+		}
+
+		elements = append(elements, element)
+		data = data[size:]
+	}
+	return elements, nil
+}
@@ -0,0 +1,208 @@
+package payloads
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/ndef"
+	"qr-code-generator/onboarding"
+	"qr-code-generator/payment"
+)
+
+// Form gives the fields of a payload by name. url.Values, and so the form of
+// an HTTP request once it is parsed, satisfies it.
+type Form interface {
+	Get(name string) string
+}
+
+// contentTypes build the QR code content for the type parameter of /generate
+// from the remaining form fields.
+var contentTypes = map[string]func(form Form) (string, error){
+	"text": func(form Form) (string, error) {
+		return form.Get("content"), nil
+	},
+	"url": func(form Form) (string, error) {
+		link := form.Get("url")
+		parsed, err := url.Parse(link)
+		if err != nil || parsed.Scheme == "" || (parsed.Host == "" && parsed.Opaque == "") {
+			return "", fmt.Errorf("%q is not an absolute URL", link)
+		}
+		return link, nil
+	},
+	"vcard": func(form Form) (string, error) {
+		card := vcardFromForm(form)
+		if err := card.Validate(); err != nil {
+			return "", err
+		}
+		return card.String(), nil
+	},
+	"wifi": func(form Form) (string, error) {
+		wifi, err := wifiFromForm(form)
+		if err != nil {
+			return "", err
+		}
+		return wifi.String(), nil
+	},
+	"upi": func(form Form) (string, error) {
+		upi := &payment.UPI{
+			PayeeAddress: form.Get("payee_address"),
+			PayeeName:    form.Get("payee_name"),
+			Amount:       form.Get("amount"),
+			Currency:     form.Get("currency"),
+			Note:         form.Get("note"),
+			Reference:    form.Get("reference"),
+			MerchantCode: form.Get("merchant_code"),
+		}
+		return upi.URI()
+	},
+	"pix": func(form Form) (string, error) {
+		pix := &payment.PIX{
+			Key:           form.Get("key"),
+			MerchantName:  form.Get("merchant_name"),
+			MerchantCity:  form.Get("merchant_city"),
+			Amount:        form.Get("amount"),
+			Description:   form.Get("description"),
+			TransactionID: form.Get("transaction_id"),
+		}
+		return pix.Payload()
+	},
+	"bitcoin": func(form Form) (string, error) {
+		bitcoin := &payment.Bitcoin{
+			Address: form.Get("address"),
+			Amount:  form.Get("amount"),
+			Label:   form.Get("label"),
+			Message: form.Get("message"),
+		}
+		return bitcoin.URI()
+	},
+	"matter": func(form Form) (string, error) {
+		payload := &onboarding.MatterPayload{SerialNumber: form.Get("serial_number")}
+		fields := []struct {
+			name     string
+			bits     int
+			optional bool
+			set      func(uint64)
+		}{
+			{"vendor_id", 16, false, func(v uint64) { payload.VendorID = uint16(v) }},
+			{"product_id", 16, false, func(v uint64) { payload.ProductID = uint16(v) }},
+			{"discovery_capabilities", 8, false, func(v uint64) { payload.DiscoveryCapabilities = uint8(v) }},
+			{"discriminator", 16, false, func(v uint64) { payload.Discriminator = uint16(v) }},
+			{"passcode", 32, false, func(v uint64) { payload.Passcode = uint32(v) }},
+			{"commissioning_flow", 8, true, func(v uint64) { payload.Flow = onboarding.CommissioningFlow(v) }},
+			{"commissioning_timeout", 16, true, func(v uint64) { payload.CommissioningTimeout = uint16(v) }},
+		}
+		for _, field := range fields {
+			value := form.Get(field.name)
+			if value == "" && field.optional {
+				continue
+			}
+			number, err := strconv.ParseUint(value, 0, field.bits)
+			if err != nil {
+				return "", fmt.Errorf("%s must be a number, got %q", field.name, value)
+			}
+			field.set(number)
+		}
+		return payload.Encode()
+	},
+	"dpp": func(form Form) (string, error) {
+		uri := &onboarding.DPPURI{
+			MAC:         form.Get("mac"),
+			Information: form.Get("information"),
+			Version:     form.Get("version"),
+			Host:        form.Get("host"),
+			PublicKey:   form.Get("public_key"),
+		}
+		if channels := form.Get("channels"); channels != "" {
+			uri.Channels = strings.Split(channels, ",")
+		}
+		return uri.Encode()
+	},
+}
+
+// ndefTypes turn the content of a payload type into an NDEF record for NFC
+// tags. Types that are not listed are URIs.
+var ndefTypes = map[string]func(form Form, content string) (ndef.Record, error){
+	"text": func(form Form, content string) (ndef.Record, error) {
+		return ndef.Text(content, form.Get("language"))
+	},
+	"pix": func(form Form, content string) (ndef.Record, error) {
+		return ndef.Text(content, "pt-BR")
+	},
+	"vcard": func(form Form, content string) (ndef.Record, error) {
+		return ndef.MIME(VCardMediaType, []byte(content))
+	},
+	"wifi": func(form Form, content string) (ndef.Record, error) {
+		wifi, err := wifiFromForm(form)
+		if err != nil {
+			return ndef.Record{}, err
+		}
+		return ndef.WiFi(wifi.Credential())
+	},
+}
+
+func vcardFromForm(form Form) *VCard {
+	return &VCard{
+		FirstName:    form.Get("first_name"),
+		LastName:     form.Get("last_name"),
+		Organization: form.Get("organization"),
+		Title:        form.Get("title"),
+		Phone:        form.Get("phone"),
+		Email:        form.Get("email"),
+		URL:          form.Get("url"),
+		Address:      form.Get("address"),
+		Note:         form.Get("note"),
+	}
+}
+
+func wifiFromForm(form Form) (*WiFi, error) {
+	wifi := &WiFi{
+		SSID:     form.Get("ssid"),
+		Password: form.Get("password"),
+		Security: form.Get("security"),
+	}
+	if hidden := form.Get("hidden"); hidden != "" {
+		var err error
+		if wifi.Hidden, err = strconv.ParseBool(hidden); err != nil {
+			return nil, fmt.Errorf("hidden must be true or false, got %q", hidden)
+		}
+	}
+	if err := wifi.Validate(); err != nil {
+		return nil, err
+	}
+	return wifi, nil
+}
+
+func typeName(form Form) string {
+	if payloadType := form.Get("type"); payloadType != "" {
+		return payloadType
+	}
+	return "text"
+}
+
+// Content builds the QR code content for the type field of the form.
+func Content(form Form) (string, error) {
+	build, ok := contentTypes[typeName(form)]
+	if !ok {
+		return "", fmt.Errorf("unknown payload type %q", typeName(form))
+	}
+	return build(form)
+}
+
+// NDEF builds the NFC counterpart of content, which Content returned for the
+// same form.
+func NDEF(form Form, content string) (ndef.Message, error) {
+	record := func(form Form, content string) (ndef.Record, error) {
+		return ndef.URI(content), nil
+	}
+	if build, ok := ndefTypes[typeName(form)]; ok {
+		record = build
+	}
+
+	built, err := record(form, content)
+	if err != nil {
+		return nil, err
+	}
+	return ndef.Message{built}, nil
+}
@@ -0,0 +1,67 @@
+package payloads
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VCard is a contact card in vCard 3.0, which both phone cameras and NFC
+// readers offer to save to the address book.
+type VCard struct {
+	FirstName    string
+	LastName     string
+	Organization string
+	Title        string
+	Phone        string
+	Email        string
+	URL          string
+	Address      string
+	Note         string
+}
+
+const VCardMediaType = "text/vcard"
+
+func (card *VCard) Validate() error {
+	if card.FirstName == "" && card.LastName == "" && card.Organization == "" {
+		return fmt.Errorf("a contact needs a name or an organization")
+	}
+	return nil
+}
+
+func (card *VCard) String() string {
+	lines := []string{"BEGIN:VCARD", "VERSION:3.0"}
+	lines = append(lines, "N:"+vcardText(card.LastName)+";"+vcardText(card.FirstName)+";;;")
+
+	name := strings.TrimSpace(card.FirstName + " " + card.LastName)
+	if name == "" {
+		name = card.Organization
+	}
+	lines = append(lines, "FN:"+vcardText(name))
+
+	for _, property := range []struct{ name, value string }{
+		{"ORG", card.Organization},
+		{"TITLE", card.Title},
+		{"TEL", card.Phone},
+		{"EMAIL", card.Email},
+		{"URL", card.URL},
+		{"ADR", card.Address},
+		{"NOTE", card.Note},
+	} {
+		if property.value == "" {
+			continue
+		}
+		value := vcardText(property.value)
+		if property.name == "ADR" {
+			// The street address is the third of the seven address parts.
+			value = ";;" + value + ";;;;"
+		}
+		lines = append(lines, property.name+":"+value)
+	}
+
+	lines = append(lines, "END:VCARD")
+	return strings.Join(lines, "\r\n")
+}
+
+func vcardText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
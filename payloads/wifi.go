@@ -0,0 +1,81 @@
+package payloads
+
+import (
+	"fmt"
+	"strings"
+
+	"qr-code-generator/ndef"
+)
+
+// WiFi is a network to join, written in the WIFI: format that phone cameras
+// recognise.
+type WiFi struct {
+	SSID     string
+	Password string
+	// Security is WPA, WEP or nopass. WPA covers WPA2 and WPA3 personal.
+	Security string
+	Hidden   bool
+}
+
+func (wifi *WiFi) security() string {
+	switch {
+	case wifi.Security != "":
+		return wifi.Security
+	case wifi.Password == "":
+		return "nopass"
+	}
+	return "WPA"
+}
+
+func (wifi *WiFi) Validate() error {
+	if wifi.SSID == "" || len(wifi.SSID) > 32 {
+		return fmt.Errorf("SSID must be 1 to 32 bytes")
+	}
+	switch wifi.security() {
+	case "WPA":
+		if len(wifi.Password) < 8 || len(wifi.Password) > 63 {
+			return fmt.Errorf("WPA passwords must be 8 to 63 characters")
+		}
+	case "WEP":
+		if wifi.Password == "" {
+			return fmt.Errorf("WEP networks need a password")
+		}
+	case "nopass":
+		if wifi.Password != "" {
+			return fmt.Errorf("open networks must not have a password")
+		}
+	default:
+		return fmt.Errorf("security must be WPA, WEP or nopass, got %q", wifi.Security)
+	}
+	return nil
+}
+
+func (wifi *WiFi) String() string {
+	fields := []string{"T:" + wifi.security(), "S:" + wifiText(wifi.SSID)}
+	if wifi.Password != "" {
+		fields = append(fields, "P:"+wifiText(wifi.Password))
+	}
+	if wifi.Hidden {
+		fields = append(fields, "H:true")
+	}
+	return "WIFI:" + strings.Join(fields, ";") + ";;"
+}
+
+// Credential returns the network in the form used by Wi-Fi Simple
+// Configuration.
+func (wifi *WiFi) Credential() ndef.WiFiCredential {
+	credential := ndef.WiFiCredential{SSID: wifi.SSID, NetworkKey: wifi.Password}
+	switch wifi.security() {
+	case "WPA":
+		credential.Authentication, credential.Encryption = ndef.WPA2Personal, ndef.AES
+	case "WEP":
+		credential.Authentication, credential.Encryption = ndef.OpenAuthentication, ndef.WEPEncryption
+	default:
+		credential.Authentication, credential.Encryption = ndef.OpenAuthentication, ndef.NoEncryption
+	}
+	return credential
+}
+
+func wifiText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, ":", `\:`, `"`, `\"`).Replace(value)
+}
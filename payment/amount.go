@@ -0,0 +1,22 @@
+package payment
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var amountPatterns = map[int]*regexp.Regexp{
+	2: regexp.MustCompile(`^\d{1,10}(\.\d{1,2})?$`),
+	8: regexp.MustCompile(`^\d{1,8}(\.\d{1,8})?$`),
+}
+
+func validateAmount(amount string, decimals int) error {
+	if !amountPatterns[decimals].MatchString(amount) {
+		return fmt.Errorf("amount %q must be a decimal with at most %d places", amount, decimals)
+	}
+	if value, _ := strconv.ParseFloat(amount, 64); value <= 0 {
+		return fmt.Errorf("amount must be greater than zero")
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package payment
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Bitcoin builds BIP21 bitcoin: URIs.
+type Bitcoin struct {
+	Address string
+	Amount  string
+	Label   string
+	Message string
+}
+
+func (bitcoin *Bitcoin) Validate() error {
+	if err := validateBitcoinAddress(bitcoin.Address); err != nil {
+		return err
+	}
+	if bitcoin.Amount != "" {
+		if err := validateAmount(bitcoin.Amount, 8); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bitcoin *Bitcoin) URI() (string, error) {
+	if err := bitcoin.Validate(); err != nil {
+		return "", err
+	}
+
+	var params []string
+	if bitcoin.Amount != "" {
+		params = append(params, "amount="+bitcoin.Amount)
+	}
+	if bitcoin.Label != "" {
+		params = append(params, "label="+escape(bitcoin.Label))
+	}
+	if bitcoin.Message != "" {
+		params = append(params, "message="+escape(bitcoin.Message))
+	}
+
+	uri := "bitcoin:" + bitcoin.Address
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri, nil
+}
+
+func validateBitcoinAddress(address string) error {
+	lower := strings.ToLower(address)
+	if strings.HasPrefix(lower, "bc1") || strings.HasPrefix(lower, "tb1") {
+		if address != lower && address != strings.ToUpper(address) {
+			return fmt.Errorf("bitcoin address %q mixes upper and lower case", address)
+		}
+		return validateSegwitAddress(lower)
+	}
+	return validateBase58Address(address)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Legacy P2PKH and P2SH addresses are Base58Check encoded: a version byte,
+// a 20 byte hash and a four byte double SHA-256 checksum.
+func validateBase58Address(address string) error {
+	value := new(big.Int)
+	for _, char := range address {
+		index := strings.IndexRune(base58Alphabet, char)
+		if index < 0 {
+			return fmt.Errorf("bitcoin address %q contains %q, which is not Base58", address, char)
+		}
+		value.Mul(value, big.NewInt(58))
+		value.Add(value, big.NewInt(int64(index)))
+	}
+
+	decoded := value.Bytes()
+	for _, char := range address {
+		if char != '1' {
+			break
+		}
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) != 25 {
+		return fmt.Errorf("bitcoin address %q has the wrong length", address)
+	}
+	switch decoded[0] {
+	case 0x00, 0x05, 0x6f, 0xc4:
+	default:
+		return fmt.Errorf("bitcoin address %q has an unknown version", address)
+	}
+
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], decoded[21:]) {
+		return fmt.Errorf("bitcoin address %q has an invalid checksum", address)
+	}
+	return nil
+}
+
+const bech32Alphabet = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Segwit addresses are bech32 (witness version 0) or bech32m (version 1 and
+// later), as defined in BIP173 and BIP350.
+func validateSegwitAddress(address string) error {
+	// The data part holds at least the witness version and a six character
+	// checksum.
+	separator := strings.LastIndexByte(address, '1')
+	if separator < 1 || separator+8 > len(address) || len(address) > 90 {
+		return fmt.Errorf("bitcoin address %q is not a valid bech32 string", address)
+	}
+
+	hrp := address[:separator]
+	if hrp != "bc" && hrp != "tb" {
+		return fmt.Errorf("bitcoin address %q is not for mainnet (bc) or testnet (tb)", address)
+	}
+	var data []byte
+	for _, char := range address[separator+1:] {
+		index := strings.IndexRune(bech32Alphabet, char)
+		if index < 0 {
+			return fmt.Errorf("bitcoin address %q contains %q, which is not bech32", address, char)
+		}
+		data = append(data, byte(index))
+	}
+
+	checksum := bech32Polymod(append(expandHRP(hrp), data...))
+	version := data[0]
+	switch {
+	case version == 0 && checksum != 1:
+		return fmt.Errorf("bitcoin address %q has an invalid bech32 checksum", address)
+	case version > 0 && version <= 16 && checksum != 0x2bc830a3:
+		return fmt.Errorf("bitcoin address %q has an invalid bech32m checksum", address)
+	case version > 16:
+		return fmt.Errorf("bitcoin address %q has an unknown witness version", address)
+	}
+
+	program := convertBits(data[1:len(data)-6], 5, 8)
+	if len(program) < 2 || len(program) > 40 || (version == 0 && len(program) != 20 && len(program) != 32) {
+		return fmt.Errorf("bitcoin address %q has an invalid witness program length", address)
+	}
+	return nil
+}
+
+func expandHRP(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := uint32(1)
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+func convertBits(data []byte, from, to uint) []byte {
+	var (
+		accumulator uint32
+		bits        uint
+		result      []byte
+	)
+	for _, value := range data {
+		accumulator = accumulator<<from | uint32(value)
+		bits += from
+		for bits >= to {
+			bits -= to
+			result = append(result, byte(accumulator>>bits)&(1<<to-1))
+		}
+	}
+	return result
+}
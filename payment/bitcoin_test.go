@@ -0,0 +1,38 @@
+package payment
+
+import "testing"
+
+func TestValidateBitcoinAddress(t *testing.T) {
+	// Valid addresses are from BIP173 and BIP350.
+	for _, address := range []string{
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+		"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7",
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",
+	} {
+		if err := validateBitcoinAddress(address); err != nil {
+			t.Errorf("validateBitcoinAddress(%q): %v", address, err)
+		}
+	}
+}
+
+func TestValidateBitcoinAddressRejects(t *testing.T) {
+	for _, address := range []string{
+		"",
+		"tb1dclvmr",
+		"bc1",
+		"bc1qqqqqq",
+		"bc1q1w508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",
+		"bc1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+		"bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du",
+		"bc1gmk9yu",
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3",
+		"0BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+	} {
+		if err := validateBitcoinAddress(address); err == nil {
+			t.Errorf("validateBitcoinAddress(%q) should fail", address)
+		}
+	}
+}
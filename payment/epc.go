@@ -0,0 +1,88 @@
+package payment
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// EPC is a SEPA credit transfer as the European Payments Council's QR code
+// (EPC069-12, also known as GiroCode) carries it.
+type EPC struct {
+	Version     string `json:"version"`
+	BIC         string `json:"bic,omitempty"`
+	Name        string `json:"name"`
+	IBAN        string `json:"iban"`
+	Amount      string `json:"amount,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	Purpose     string `json:"purpose,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Information string `json:"information,omitempty"`
+}
+
+var (
+	epcAmountPattern = regexp.MustCompile(`^EUR\d{1,9}(\.\d{1,2})?$`)
+	ibanPattern      = regexp.MustCompile(`^[A-Z]{2}\d{2}[A-Z0-9]{11,30}$`)
+)
+
+// ParseEPC reads the payload of a scanned EPC code. Trailing empty fields
+// may be left off, as the specification allows.
+func ParseEPC(text string) (*EPC, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 7 || lines[0] != "BCD" {
+		return nil, fmt.Errorf("not an EPC payload")
+	}
+	if len(lines) > 12 {
+		if strings.Join(lines[12:], "") != "" {
+			return nil, fmt.Errorf("EPC payload has %d lines, at most 12 are allowed", len(lines))
+		}
+		lines = lines[:12]
+	}
+	lines = append(lines, make([]string, 12-len(lines))...)
+
+	epc := &EPC{
+		Version:     lines[1],
+		BIC:         strings.TrimSpace(lines[4]),
+		Name:        strings.TrimSpace(lines[5]),
+		IBAN:        strings.ReplaceAll(strings.ToUpper(lines[6]), " ", ""),
+		Purpose:     lines[8],
+		Reference:   strings.TrimSpace(lines[9]),
+		Text:        strings.TrimSpace(lines[10]),
+		Information: strings.TrimSpace(lines[11]),
+	}
+	switch epc.Version {
+	case "001":
+		if epc.BIC == "" {
+			return nil, fmt.Errorf("EPC version 001 requires a BIC")
+		}
+	case "002":
+	default:
+		return nil, fmt.Errorf("EPC version must be 001 or 002, got %q", epc.Version)
+	}
+	if len(lines[2]) != 1 || lines[2] < "1" || lines[2] > "8" {
+		return nil, fmt.Errorf("EPC character set must be 1 to 8, got %q", lines[2])
+	}
+	if lines[3] != "SCT" {
+		return nil, fmt.Errorf("EPC identification must be SCT, got %q", lines[3])
+	}
+	if epc.Name == "" || len([]rune(epc.Name)) > 70 {
+		return nil, fmt.Errorf("beneficiary name must be 1 to 70 characters")
+	}
+	if !ibanPattern.MatchString(epc.IBAN) {
+		return nil, fmt.Errorf("beneficiary account %q is not an IBAN", epc.IBAN)
+	}
+	if amount := strings.TrimSpace(lines[7]); amount != "" {
+		if !epcAmountPattern.MatchString(amount) {
+			return nil, fmt.Errorf("amount %q must be EUR followed by a decimal with at most two places", amount)
+		}
+		epc.Currency, epc.Amount = amount[:3], amount[3:]
+		if err := validateAmount(epc.Amount, 2); err != nil {
+			return nil, err
+		}
+	}
+	if epc.Reference != "" && epc.Text != "" {
+		return nil, fmt.Errorf("EPC payload has both a structured reference and remittance text")
+	}
+	return epc, nil
+}
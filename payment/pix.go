@@ -0,0 +1,188 @@
+package payment
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// PIX is a static BR Code, the EMV merchant-presented QR format used by the
+// Brazilian instant payment system.
+type PIX struct {
+	Key           string
+	MerchantName  string
+	MerchantCity  string
+	Amount        string
+	Description   string
+	TransactionID string
+}
+
+const pixGUI = "br.gov.bcb.pix"
+
+var (
+	emailKeyPattern  = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	phoneKeyPattern  = regexp.MustCompile(`^\+55\d{10,11}$`)
+	randomKeyPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	txidPattern      = regexp.MustCompile(`^[A-Za-z0-9]{1,25}$`)
+)
+
+func (pix *PIX) Validate() error {
+	if err := validatePIXKey(pix.Key); err != nil {
+		return err
+	}
+
+	name, city := emvText(pix.MerchantName), emvText(pix.MerchantCity)
+	if name == "" || len(name) > 25 {
+		return fmt.Errorf("merchant name must be 1 to 25 characters")
+	}
+	if city == "" || len(city) > 15 {
+		return fmt.Errorf("merchant city must be 1 to 15 characters")
+	}
+	if pix.Amount != "" {
+		if err := validateAmount(pix.Amount, 2); err != nil {
+			return err
+		}
+		if len(pix.Amount) > 13 {
+			return fmt.Errorf("amount must not exceed 13 characters")
+		}
+	}
+	if pix.TransactionID != "" && pix.TransactionID != "***" && !txidPattern.MatchString(pix.TransactionID) {
+		return fmt.Errorf("transaction ID must be up to 25 letters and digits")
+	}
+
+	// The whole merchant account information template is limited to 99
+	// characters, which leaves room for the description after the key.
+	if length := len(pix.accountInformation()); length > 99 {
+		return fmt.Errorf("key and description are %d characters too long", length-99)
+	}
+	return nil
+}
+
+func validatePIXKey(key string) error {
+	switch {
+	case isDigits(key) && len(key) == 11:
+		if !validCPF(key) {
+			return fmt.Errorf("PIX key %q is not a valid CPF", key)
+		}
+	case isDigits(key) && len(key) == 14:
+		if !validCNPJ(key) {
+			return fmt.Errorf("PIX key %q is not a valid CNPJ", key)
+		}
+	case strings.HasPrefix(key, "+"):
+		if !phoneKeyPattern.MatchString(key) {
+			return fmt.Errorf("PIX key %q is not a valid +55 phone number", key)
+		}
+	case strings.Contains(key, "@"):
+		if len(key) > 77 || !emailKeyPattern.MatchString(key) {
+			return fmt.Errorf("PIX key %q is not a valid email address", key)
+		}
+	case !randomKeyPattern.MatchString(key):
+		return fmt.Errorf("PIX key %q is not a CPF, CNPJ, phone number, email or random key", key)
+	}
+	return nil
+}
+
+func (pix *PIX) accountInformation() string {
+	info := emvField("00", pixGUI) + emvField("01", pix.Key)
+	if pix.Description != "" {
+		info += emvField("02", emvText(pix.Description))
+	}
+	return info
+}
+
+func (pix *PIX) Payload() (string, error) {
+	if err := pix.Validate(); err != nil {
+		return "", err
+	}
+
+	txid := pix.TransactionID
+	if txid == "" {
+		txid = "***"
+	}
+
+	var payload strings.Builder
+	payload.WriteString(emvField("00", "01"))
+	payload.WriteString(emvField("26", pix.accountInformation()))
+	payload.WriteString(emvField("52", "0000"))
+	payload.WriteString(emvField("53", "986"))
+	if pix.Amount != "" {
+		payload.WriteString(emvField("54", pix.Amount))
+	}
+	payload.WriteString(emvField("58", "BR"))
+	payload.WriteString(emvField("59", emvText(pix.MerchantName)))
+	payload.WriteString(emvField("60", emvText(pix.MerchantCity)))
+	payload.WriteString(emvField("62", emvField("05", txid)))
+	payload.WriteString("6304")
+
+	return payload.String() + fmt.Sprintf("%04X", crc16(payload.String())), nil
+}
+
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// emvText strips accents, since BR Codes are read as plain ASCII by many
+// banking apps.
+func emvText(value string) string {
+	var result strings.Builder
+	for _, char := range norm.NFD.String(strings.TrimSpace(value)) {
+		if char >= 0x20 && char < 0x7f {
+			result.WriteRune(char)
+		}
+	}
+	return result.String()
+}
+
+// crc16 is CRC-16/CCITT-FALSE, as required for field 63 of EMV QR codes.
+func crc16(data string) uint16 {
+	crc := uint16(0xffff)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func isDigits(value string) bool {
+	return value != "" && strings.Trim(value, "0123456789") == ""
+}
+
+func validCPF(cpf string) bool {
+	if strings.Count(cpf, cpf[:1]) == len(cpf) {
+		return false
+	}
+	return cpf[9] == mod11Digit(cpf[:9], 10, false) && cpf[10] == mod11Digit(cpf[:10], 11, false)
+}
+
+func validCNPJ(cnpj string) bool {
+	if strings.Count(cnpj, cnpj[:1]) == len(cnpj) {
+		return false
+	}
+	return cnpj[12] == mod11Digit(cnpj[:12], 0, true) && cnpj[13] == mod11Digit(cnpj[:13], 0, true)
+}
+
+// mod11Digit computes a CPF check digit with weights counting down from
+// firstWeight, or a CNPJ check digit with weights cycling from 2 to 9 from
+// the right.
+func mod11Digit(digits string, firstWeight int, cycling bool) byte {
+	sum := 0
+	for i := range digits {
+		weight := firstWeight - i
+		if cycling {
+			weight = 2 + (len(digits)-1-i)%8
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	if remainder := sum % 11; remainder >= 2 {
+		return byte('0' + 11 - remainder)
+	}
+	return '0'
+}
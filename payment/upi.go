@@ -0,0 +1,79 @@
+package payment
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+type UPI struct {
+	PayeeAddress string
+	PayeeName    string
+	Amount       string
+	Currency     string
+	Note         string
+	Reference    string
+	MerchantCode string
+}
+
+var (
+	vpaPattern          = regexp.MustCompile(`^[a-zA-Z0-9.\-_]{2,256}@[a-zA-Z][a-zA-Z0-9]{1,63}$`)
+	merchantCodePattern = regexp.MustCompile(`^\d{4}$`)
+)
+
+func (upi *UPI) Validate() error {
+	if !vpaPattern.MatchString(upi.PayeeAddress) {
+		return fmt.Errorf("payee address %q is not a valid UPI ID", upi.PayeeAddress)
+	}
+	if strings.TrimSpace(upi.PayeeName) == "" {
+		return fmt.Errorf("payee name is required")
+	}
+	if upi.Amount != "" {
+		if err := validateAmount(upi.Amount, 2); err != nil {
+			return err
+		}
+	}
+	if upi.Currency != "" && upi.Currency != "INR" {
+		return fmt.Errorf("UPI payments must be in INR, got %q", upi.Currency)
+	}
+	if len(upi.Note) > 80 {
+		return fmt.Errorf("note must not exceed 80 characters")
+	}
+	if len(upi.Reference) > 35 {
+		return fmt.Errorf("reference must not exceed 35 characters")
+	}
+	if upi.MerchantCode != "" && !merchantCodePattern.MatchString(upi.MerchantCode) {
+		return fmt.Errorf("merchant code must be a four digit MCC, got %q", upi.MerchantCode)
+	}
+	return nil
+}
+
+func (upi *UPI) URI() (string, error) {
+	if err := upi.Validate(); err != nil {
+		return "", err
+	}
+
+	params := []string{"pa=" + upi.PayeeAddress, "pn=" + escape(upi.PayeeName)}
+	if upi.MerchantCode != "" {
+		params = append(params, "mc="+upi.MerchantCode)
+	}
+	if upi.Reference != "" {
+		params = append(params, "tr="+escape(upi.Reference))
+	}
+	if upi.Note != "" {
+		params = append(params, "tn="+escape(upi.Note))
+	}
+	if upi.Amount != "" {
+		params = append(params, "am="+upi.Amount)
+	}
+	params = append(params, "cu=INR")
+
+	return "upi://pay?" + strings.Join(params, "&"), nil
+}
+
+// escape percent-encodes a query value with %20 for spaces, which some
+// payment apps expect instead of +.
+func escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
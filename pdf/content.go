@@ -0,0 +1,162 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Content returns the content stream of a page, joining the parts of pages
+// whose content is split over several streams.
+func (reader *Reader) Content(page *ExistingPage) ([]byte, error) {
+	contents, err := reader.Resolve(page.Dict["Contents"])
+	if err != nil {
+		return nil, err
+	}
+	var parts Array
+	switch value := contents.(type) {
+	case *Stream:
+		parts = Array{value}
+	case Array:
+		parts = value
+	}
+	var content bytes.Buffer
+	for _, part := range parts {
+		object, err := reader.Resolve(part)
+		if err != nil {
+			return nil, err
+		}
+		stream, ok := object.(*Stream)
+		if !ok {
+			continue
+		}
+		data, err := reader.Decode(stream)
+		if err != nil {
+			return nil, err
+		}
+		// Operators may not be split between streams, but they must be
+		// kept apart.
+		content.Write(data)
+		content.WriteByte('\n')
+	}
+	return content.Bytes(), nil
+}
+
+// inlineKeys expands the abbreviated keys of inline image dictionaries.
+var inlineKeys = map[Name]Name{
+	"BPC": "BitsPerComponent",
+	"CS":  "ColorSpace",
+	"D":   "Decode",
+	"DP":  "DecodeParms",
+	"F":   "Filter",
+	"H":   "Height",
+	"IM":  "ImageMask",
+	"I":   "Interpolate",
+	"W":   "Width",
+}
+
+// operation reads the operands and the operator of the next operation in a
+// content stream, returning io.EOF at its end. An inline image is returned
+// as the operator BI with the image as its only operand.
+func (p *parser) operation() ([]Object, string, error) {
+	var operands []Object
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return operands, "", io.EOF
+		}
+		char := p.data[p.pos]
+		if !isDelimiter(char) && (char < '0' || char > '9') && char != '+' && char != '-' && char != '.' {
+			switch word := p.keyword(); word {
+			case "true", "false", "null":
+				p.pos -= len(word)
+				object, err := p.parseObject()
+				if err != nil {
+					return nil, "", err
+				}
+				operands = append(operands, object)
+			case "BI":
+				image, err := p.inlineImage()
+				if err != nil {
+					return nil, "", err
+				}
+				return []Object{image}, word, nil
+			default:
+				return operands, word, nil
+			}
+			continue
+		}
+		object, err := p.parseObject()
+		if err != nil {
+			return nil, "", err
+		}
+		operands = append(operands, object)
+	}
+}
+
+func (p *parser) inlineImage() (*Stream, error) {
+	dict := Dict{}
+	for {
+		p.skipSpace()
+		if p.pos < len(p.data) && p.data[p.pos] != '/' {
+			if p.keyword() != "ID" {
+				return nil, fmt.Errorf("inline image at offset %d has no data", p.pos)
+			}
+			break
+		}
+		key, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := key.(Name)
+		if full, ok := inlineKeys[name]; ok {
+			name = full
+		}
+		dict[name] = value
+	}
+	// A single white-space character separates ID from the data.
+	p.pos++
+	start := p.pos
+
+	// Unfiltered data has a known length. Otherwise the data ends at the
+	// first EI between white space.
+	end := -1
+	if dict["Filter"] == nil {
+		width, _ := toNumber(dict["Width"])
+		height, _ := toNumber(dict["Height"])
+		bits, components := 1.0, 1.0
+		if mask, _ := dict["ImageMask"].(bool); !mask {
+			bits, _ = toNumber(dict["BitsPerComponent"])
+			switch dict["ColorSpace"] {
+			case Name("RGB"), Name("DeviceRGB"):
+				components = 3
+			case Name("CMYK"), Name("DeviceCMYK"):
+				components = 4
+			}
+		}
+		if length := int((width*components*bits+7)/8) * int(height); length > 0 && start+length <= len(p.data) {
+			end = start + length
+		}
+	}
+	if end < 0 {
+		for i := start; i+2 <= len(p.data); i++ {
+			if p.data[i] == 'E' && p.data[i+1] == 'I' && i > start && isWhitespace(p.data[i-1]) &&
+				(i+2 == len(p.data) || isWhitespace(p.data[i+2]) || isDelimiter(p.data[i+2])) {
+				end = i - 1
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return nil, fmt.Errorf("inline image at offset %d has no end", start)
+	}
+	p.pos = end
+	if err := p.expect("EI"); err != nil {
+		return nil, err
+	}
+	return &Stream{Dict: dict, Data: p.data[start:end]}, nil
+}
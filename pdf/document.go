@@ -0,0 +1,223 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+const MM = 72 / 25.4
+
+type Font string
+
+const (
+	Helvetica     Font = "Helvetica"
+	HelveticaBold Font = "Helvetica-Bold"
+)
+
+var fontResources = map[Font]string{
+	Helvetica:     "F1",
+	HelveticaBold: "F2",
+}
+
+type Document struct {
+	pages []*Page
+}
+
+type Page struct {
+	Width   float64
+	Height  float64
+	content bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+func (doc *Document) AddPage(width, height float64) *Page {
+	page := &Page{Width: width, Height: height}
+	doc.pages = append(doc.pages, page)
+	return page
+}
+
+func (page *Page) SetFillColor(r, g, b float64) {
+	fmt.Fprintf(&page.content, "%s %s %s rg\n", number(r), number(g), number(b))
+}
+
+func (page *Page) SetStrokeColor(r, g, b float64) {
+	fmt.Fprintf(&page.content, "%s %s %s RG\n", number(r), number(g), number(b))
+}
+
+func (page *Page) SetLineWidth(width float64) {
+	fmt.Fprintf(&page.content, "%s w\n", number(width))
+}
+
+func (page *Page) SetDash(on, off float64) {
+	if on == 0 && off == 0 {
+		page.content.WriteString("[] 0 d\n")
+		return
+	}
+	fmt.Fprintf(&page.content, "[%s %s] 0 d\n", number(on), number(off))
+}
+
+func (page *Page) FillRect(x, y, width, height float64) {
+	fmt.Fprintf(
+		&page.content, "%s %s %s %s re f\n",
+		number(x), number(y), number(width), number(height),
+	)
+}
+
+// Rect adds a rectangle to the current path, for filling many rectangles at
+// once without seams between them.
+func (page *Page) Rect(x, y, width, height float64) {
+	fmt.Fprintf(
+		&page.content, "%s %s %s %s re\n",
+		number(x), number(y), number(width), number(height),
+	)
+}
+
+func (page *Page) Fill() {
+	page.content.WriteString("f\n")
+}
+
+func (page *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(
+		&page.content, "%s %s m %s %s l S\n",
+		number(x1), number(y1), number(x2), number(y2),
+	)
+}
+
+func (page *Page) Polyline(points ...[2]float64) {
+	for i, point := range points {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(&page.content, "%s %s %s\n", number(point[0]), number(point[1]), op)
+	}
+	page.content.WriteString("S\n")
+}
+
+// MoveTo, LineTo, CurveTo and ClosePath build a path of lines and cubic
+// Bézier curves, such as a glyph outline, to be filled with Fill.
+func (page *Page) MoveTo(x, y float64) {
+	fmt.Fprintf(&page.content, "%s %s m\n", number(x), number(y))
+}
+
+func (page *Page) LineTo(x, y float64) {
+	fmt.Fprintf(&page.content, "%s %s l\n", number(x), number(y))
+}
+
+func (page *Page) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
+	fmt.Fprintf(
+		&page.content, "%s %s %s %s %s %s c\n",
+		number(x1), number(y1), number(x2), number(y2), number(x3), number(y3),
+	)
+}
+
+func (page *Page) ClosePath() {
+	page.content.WriteString("h\n")
+}
+
+func (page *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(
+		&page.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		fontResources[font], number(size), number(x), number(y), escape(encodeWinAnsi(text)),
+	)
+}
+
+func (page *Page) SaveState() {
+	page.content.WriteString("q\n")
+}
+
+func (page *Page) RestoreState() {
+	page.content.WriteString("Q\n")
+}
+
+func (page *Page) Raw(operators string) {
+	page.content.WriteString(operators)
+}
+
+// Transform concatenates a matrix to the current transformation.
+func (page *Page) Transform(a, b, c, d, e, f float64) {
+	fmt.Fprintf(
+		&page.content, "%s %s %s %s %s %s cm\n",
+		number(a), number(b), number(c), number(d), number(e), number(f),
+	)
+}
+
+// Content returns the operators drawn so far, for stamping onto pages of
+// existing documents.
+func (page *Page) Content() []byte {
+	return page.content.Bytes()
+}
+
+func (doc *Document) Bytes() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if _, err := doc.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (doc *Document) WriteTo(out io.Writer) (int64, error) {
+	if len(doc.pages) == 0 {
+		return 0, fmt.Errorf("could not write PDF: document has no pages")
+	}
+
+	w := newObjectWriter()
+	w.header()
+
+	catalog, pages := w.reserve(), w.reserve()
+	fonts := map[Font]int{}
+	for _, font := range []Font{Helvetica, HelveticaBold} {
+		fonts[font] = w.object(fmt.Sprintf(
+			"<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font,
+		))
+	}
+	fontDict := fmt.Sprintf(
+		"<< /F1 %d 0 R /F2 %d 0 R >>", fonts[Helvetica], fonts[HelveticaBold],
+	)
+
+	kids := bytes.NewBuffer(nil)
+	for _, page := range doc.pages {
+		content := w.stream("", page.content.Bytes())
+		pageObject := w.object(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font %s >> /Contents %d 0 R >>",
+			pages, number(page.Width), number(page.Height), fontDict, content,
+		))
+		fmt.Fprintf(kids, "%d 0 R ", pageObject)
+	}
+
+	w.define(pages, fmt.Sprintf(
+		"<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids.Bytes()), len(doc.pages),
+	))
+	w.define(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	w.trailer(catalog)
+
+	return w.buf.WriteTo(out)
+}
+
+func number(value float64) string {
+	return strconv.FormatFloat(math.Round(value*10000)/10000, 'f', -1, 64)
+}
+
+func escape(text []byte) string {
+	escaped := bytes.NewBuffer(nil)
+	for _, char := range text {
+		switch char {
+		case '(', ')', '\\':
+			escaped.WriteByte('\\')
+			escaped.WriteByte(char)
+		case '\r':
+			escaped.WriteString(`\r`)
+		case '\n':
+			escaped.WriteString(`\n`)
+		default:
+			escaped.WriteByte(char)
+		}
+	}
+	return escaped.String()
+}
@@ -0,0 +1,332 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+
+	"golang.org/x/image/ccitt"
+)
+
+// Image decodes an image XObject in grey, which is all that reading codes
+// from a page needs. Images with a soft mask are composited onto white.
+// Stencil masks, which paint the fill colour where their samples are set,
+// are returned as an *image.Alpha. JPEG 2000 and JBIG2 images are not
+// supported.
+func (reader *Reader) Image(stream *Stream) (image.Image, error) {
+	return reader.image(stream, true)
+}
+
+// image decodes an image, compositing its soft mask when withMask is set.
+// A soft mask may not have a mask of its own, so its /SMask is not
+// followed, which would otherwise recurse without end on a mask naming
+// itself.
+func (reader *Reader) image(stream *Stream, withMask bool) (image.Image, error) {
+	dict := stream.Dict
+	width, _ := reader.integer(dict["Width"])
+	height, _ := reader.integer(dict["Height"])
+	if width <= 0 || height <= 0 || width*height > 1<<26 {
+		return nil, fmt.Errorf("image has invalid dimensions %dx%d", width, height)
+	}
+	mask, _ := reader.Resolve(dict["ImageMask"])
+	isMask := mask == true
+	decode, _ := reader.Resolve(dict["Decode"])
+	decodeArray, _ := decode.(Array)
+
+	data, filters, params, err := reader.decode(dict, stream.Data)
+	if err != nil {
+		return nil, err
+	}
+	var img *image.Gray
+	switch {
+	case len(filters) == 0:
+		img, err = reader.samples(dict, data, width, height, isMask)
+	case len(filters) > 1:
+		err = fmt.Errorf("filter %v is not supported", filters[0])
+	case filters[0] == "DCTDecode" || filters[0] == "DCT":
+		img, err = decodeJPEG(data)
+	case filters[0] == "CCITTFaxDecode" || filters[0] == "CCF":
+		img, err = decodeCCITT(data, params[0], width, height)
+	default:
+		err = fmt.Errorf("images with filter %v are not supported", filters[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Samples from image filters still need the decode array applied. A
+	// decode array from 1 to 0 inverts the image.
+	if len(filters) > 0 && len(decodeArray) >= 2 {
+		low, _ := toNumber(decodeArray[0])
+		high, _ := toNumber(decodeArray[1])
+		if low > high {
+			for i := range img.Pix {
+				img.Pix[i] = 255 - img.Pix[i]
+			}
+		}
+	}
+
+	if isMask {
+		// A sample of 0 paints, unless the decode array inverts it, which
+		// samples has already done for raw samples.
+		stencil := image.NewAlpha(img.Rect)
+		for i, value := range img.Pix {
+			if value < 128 {
+				stencil.Pix[i] = 255
+			}
+		}
+		return stencil, nil
+	}
+
+	if smask, ok := dict["SMask"].(Ref); ok && withMask {
+		object, err := reader.Object(smask.Number)
+		if err != nil {
+			return nil, err
+		}
+		if maskStream, ok := object.(*Stream); ok {
+			alpha, err := reader.image(maskStream, false)
+			if err != nil {
+				return nil, fmt.Errorf("soft mask: %v", err)
+			}
+			if gray, ok := alpha.(*image.Gray); ok {
+				composite(img, gray)
+			}
+		}
+	}
+	return img, nil
+}
+
+func (reader *Reader) integer(object Object) (int, bool) {
+	resolved, err := reader.Resolve(object)
+	if err != nil {
+		return 0, false
+	}
+	value, ok := toNumber(resolved)
+	return int(value), ok
+}
+
+// composite lays an image over white through a soft mask, which may be a
+// different size.
+func composite(img, alpha *image.Gray) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	maskWidth, maskHeight := alpha.Rect.Dx(), alpha.Rect.Dy()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			a := int(alpha.Pix[(y*maskHeight/height)*alpha.Stride+x*maskWidth/width])
+			i := y*img.Stride + x
+			img.Pix[i] = uint8(255 - a*(255-int(img.Pix[i]))/255)
+		}
+	}
+}
+
+func decodeJPEG(data []byte) (*image.Gray, error) {
+	decoded, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode JPEG image: %v", err)
+	}
+	bounds := decoded.Bounds()
+	img := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			img.Set(x, y, decoded.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return img, nil
+}
+
+func decodeCCITT(data []byte, params Dict, width, height int) (*image.Gray, error) {
+	k, columns, align, blackIs1 := int64(0), int64(1728), false, false
+	if params != nil {
+		if value, ok := params["K"].(int64); ok {
+			k = value
+		}
+		if value, ok := params["Columns"].(int64); ok {
+			columns = value
+		}
+		align, _ = params["EncodedByteAlign"].(bool)
+		blackIs1, _ = params["BlackIs1"].(bool)
+	}
+	if int(columns) != width {
+		return nil, fmt.Errorf("CCITT image is %d columns wide but %d pixels", columns, width)
+	}
+	format := ccitt.Group4
+	if k >= 0 {
+		format = ccitt.Group3
+	}
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	err := ccitt.DecodeIntoGray(img, bytes.NewReader(data), ccitt.MSB, format, &ccitt.Options{Align: align, Invert: blackIs1})
+	if err != nil {
+		return nil, fmt.Errorf("could not decode CCITT image: %v", err)
+	}
+	return img, nil
+}
+
+// samples converts raw samples to grey through the image's colour space
+// and decode array.
+func (reader *Reader) samples(dict Dict, data []byte, width, height int, isMask bool) (*image.Gray, error) {
+	bits, components := 1, 1
+	toGray := func(values []float64) float64 { return values[0] }
+	isIndexed := false
+	if !isMask {
+		var ok bool
+		if bits, ok = reader.integer(dict["BitsPerComponent"]); !ok {
+			return nil, fmt.Errorf("image has no bits per component")
+		}
+		space, err := reader.Resolve(dict["ColorSpace"])
+		if err != nil {
+			return nil, err
+		}
+		if components, toGray, err = reader.colorSpace(space); err != nil {
+			return nil, err
+		}
+		if array, ok := space.(Array); ok && len(array) > 0 {
+			isIndexed = array[0] == Name("Indexed") || array[0] == Name("I")
+		}
+	}
+	switch bits {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("images with %d bits per component are not supported", bits)
+	}
+
+	maximum := float64(int(1)<<bits - 1)
+	decode, _ := reader.Resolve(dict["Decode"])
+	decodeArray, _ := decode.(Array)
+	ranges := make([][2]float64, components)
+	for i := range ranges {
+		ranges[i] = [2]float64{0, 1}
+		if 2*i+1 < len(decodeArray) {
+			ranges[i][0], _ = toNumber(decodeArray[2*i])
+			ranges[i][1], _ = toNumber(decodeArray[2*i+1])
+		} else if isIndexed {
+			// Indexed samples are palette entries, not fractions.
+			ranges[i] = [2]float64{0, maximum}
+		}
+	}
+
+	stride := (width*components*bits + 7) / 8
+	if len(data) < stride*height {
+		// Pad short data with zeros rather than failing, as viewers do.
+		data = append(data, make([]byte, stride*height-len(data))...)
+	}
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	values := make([]float64, components)
+	for y := 0; y < height; y++ {
+		row := data[y*stride : (y+1)*stride]
+		for x := 0; x < width; x++ {
+			for c := range values {
+				index := x*components + c
+				var sample int
+				switch bits {
+				case 8:
+					sample = int(row[index])
+				case 16:
+					sample = int(row[2*index])<<8 | int(row[2*index+1])
+				default:
+					bit := index * bits
+					sample = int(row[bit/8]>>(8-bits-bit%8)) & (1<<bits - 1)
+				}
+				values[c] = ranges[c][0] + float64(sample)*(ranges[c][1]-ranges[c][0])/maximum
+			}
+			img.Pix[y*img.Stride+x] = uint8(255*clamp(toGray(values)) + 0.5)
+		}
+	}
+	return img, nil
+}
+
+// colorSpace returns the number of components of a colour space and a
+// function from their values to grey, from 0 for black to 1 for white.
+func (reader *Reader) colorSpace(space Object) (int, func([]float64) float64, error) {
+	gray := func(values []float64) float64 { return values[0] }
+	rgb := func(values []float64) float64 { return luminance(values[0], values[1], values[2]) }
+	cmyk := func(values []float64) float64 {
+		return luminance((1-values[0])*(1-values[3]), (1-values[1])*(1-values[3]), (1-values[2])*(1-values[3]))
+	}
+	var family Name
+	var array Array
+	switch value := space.(type) {
+	case Name:
+		family = value
+	case Array:
+		if len(value) == 0 {
+			return 0, nil, fmt.Errorf("empty colour space")
+		}
+		family, _ = value[0].(Name)
+		array = value
+	}
+	switch family {
+	case "DeviceGray", "G", "CalGray":
+		return 1, gray, nil
+	case "DeviceRGB", "RGB", "CalRGB", "Lab":
+		return 3, rgb, nil
+	case "DeviceCMYK", "CMYK":
+		return 4, cmyk, nil
+	case "ICCBased":
+		if len(array) == 2 {
+			if object, err := reader.Resolve(array[1]); err == nil {
+				if profile, ok := object.(*Stream); ok {
+					switch n, _ := reader.integer(profile.Dict["N"]); n {
+					case 1:
+						return 1, gray, nil
+					case 3:
+						return 3, rgb, nil
+					case 4:
+						return 4, cmyk, nil
+					}
+				}
+			}
+		}
+	case "Separation":
+		// All colorants are taken to be dark inks.
+		return 1, func(values []float64) float64 { return 1 - values[0] }, nil
+	case "Indexed", "I":
+		if len(array) != 4 {
+			break
+		}
+		base, err := reader.Resolve(array[1])
+		if err != nil {
+			return 0, nil, err
+		}
+		components, toGray, err := reader.colorSpace(base)
+		if err != nil {
+			return 0, nil, err
+		}
+		lookup, err := reader.Resolve(array[3])
+		if err != nil {
+			return 0, nil, err
+		}
+		var table []byte
+		switch value := lookup.(type) {
+		case String:
+			table = value
+		case *Stream:
+			if table, err = reader.Decode(value); err != nil {
+				return 0, nil, err
+			}
+		}
+		palette := make([]float64, len(table)/components)
+		entry := make([]float64, components)
+		for i := range palette {
+			for c := range entry {
+				entry[c] = float64(table[i*components+c]) / 255
+			}
+			palette[i] = toGray(entry)
+		}
+		return 1, func(values []float64) float64 {
+			index := int(values[0] + 0.5)
+			if index < 0 || index >= len(palette) {
+				return 0
+			}
+			return palette[index]
+		}, nil
+	}
+	return 0, nil, fmt.Errorf("colour space %v is not supported", family)
+}
+
+func luminance(r, g, b float64) float64 {
+	return 0.299*r + 0.587*g + 0.114*b
+}
+
+func clamp(value float64) float64 {
+	return max(0, min(1, value))
+}
@@ -0,0 +1,104 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Objects read from existing documents. Integers, reals, booleans and null
+// are int64, float64, bool and nil.
+type (
+	Object interface{}
+	Name   string
+	String []byte
+	Array  []Object
+	Dict   map[Name]Object
+)
+
+type Ref struct {
+	Number     int
+	Generation int
+}
+
+// Stream holds the data as stored in the file, before any filters are
+// undone.
+type Stream struct {
+	Dict Dict
+	Data []byte
+}
+
+func writeObject(buf *bytes.Buffer, object Object) {
+	switch value := object.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case int64:
+		buf.WriteString(strconv.FormatInt(value, 10))
+	case int:
+		buf.WriteString(strconv.Itoa(value))
+	case float64:
+		buf.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	case Name:
+		buf.WriteByte('/')
+		for _, char := range []byte(value) {
+			if char < 0x21 || char > 0x7e || bytes.IndexByte([]byte("#()<>[]{}/%"), char) >= 0 {
+				fmt.Fprintf(buf, "#%02X", char)
+			} else {
+				buf.WriteByte(char)
+			}
+		}
+	case String:
+		fmt.Fprintf(buf, "<%X>", []byte(value))
+	case Array:
+		buf.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			writeObject(buf, item)
+		}
+		buf.WriteByte(']')
+	case Dict:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, string(key))
+		}
+		sort.Strings(keys)
+
+		buf.WriteString("<<")
+		for _, key := range keys {
+			writeObject(buf, Name(key))
+			buf.WriteByte(' ')
+			writeObject(buf, value[Name(key)])
+		}
+		buf.WriteString(">>")
+	case Ref:
+		fmt.Fprintf(buf, "%d %d R", value.Number, value.Generation)
+	case *Stream:
+		dict := Dict{}
+		for key, item := range value.Dict {
+			dict[key] = item
+		}
+		dict["Length"] = int64(len(value.Data))
+		writeObject(buf, dict)
+		buf.WriteString("\nstream\n")
+		buf.Write(value.Data)
+		buf.WriteString("\nendstream")
+	default:
+		panic(fmt.Sprintf("pdf: cannot write %T", object))
+	}
+}
+
+// number returns a numeric object as a float64.
+func toNumber(object Object) (float64, bool) {
+	switch value := object.(type) {
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
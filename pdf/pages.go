@@ -0,0 +1,138 @@
+package pdf
+
+import "fmt"
+
+// ExistingPage is a page of a document opened with a Reader, with the
+// attributes it inherits from the page tree filled in.
+type ExistingPage struct {
+	Ref      Ref
+	Dict     Dict
+	MediaBox [4]float64
+	CropBox  [4]float64
+	Rotate   int
+	// Resources are the fonts, images and other resources the content
+	// refers to by name.
+	Resources Dict
+}
+
+// Pages returns the pages in order.
+func (reader *Reader) Pages() ([]*ExistingPage, error) {
+	catalog, err := reader.Resolve(reader.trailer["Root"])
+	if err != nil {
+		return nil, err
+	}
+	catalogDict, ok := catalog.(Dict)
+	if !ok {
+		return nil, fmt.Errorf("document catalog is not a dictionary")
+	}
+	root, ok := catalogDict["Pages"].(Ref)
+	if !ok {
+		return nil, fmt.Errorf("document catalog has no page tree")
+	}
+
+	var pages []*ExistingPage
+	visited := map[int]bool{}
+	var walk func(ref Ref, inherited Dict) error
+	walk = func(ref Ref, inherited Dict) error {
+		if visited[ref.Number] {
+			return fmt.Errorf("page tree refers to object %d twice", ref.Number)
+		}
+		visited[ref.Number] = true
+
+		object, err := reader.Object(ref.Number)
+		if err != nil {
+			return err
+		}
+		node, ok := object.(Dict)
+		if !ok {
+			return fmt.Errorf("page tree node %d is not a dictionary", ref.Number)
+		}
+
+		attributes := Dict{}
+		for key, value := range inherited {
+			attributes[key] = value
+		}
+		for _, key := range []Name{"MediaBox", "CropBox", "Rotate", "Resources"} {
+			if value, ok := node[key]; ok {
+				attributes[key] = value
+			}
+		}
+
+		if node["Type"] == Name("Pages") || node["Kids"] != nil && node["Type"] != Name("Page") {
+			kids, err := reader.Resolve(node["Kids"])
+			if err != nil {
+				return err
+			}
+			kidList, _ := kids.(Array)
+			for _, kid := range kidList {
+				kidRef, ok := kid.(Ref)
+				if !ok {
+					return fmt.Errorf("page tree node %d has a direct kid", ref.Number)
+				}
+				if err := walk(kidRef, attributes); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		page := &ExistingPage{Ref: ref, Dict: node}
+		if page.MediaBox, err = reader.rectangle(attributes["MediaBox"]); err != nil {
+			return fmt.Errorf("page %d: %v", len(pages)+1, err)
+		}
+		page.CropBox = page.MediaBox
+		if attributes["CropBox"] != nil {
+			if cropBox, err := reader.rectangle(attributes["CropBox"]); err == nil {
+				page.CropBox = intersect(cropBox, page.MediaBox)
+			}
+		}
+		if resources, err := reader.Resolve(attributes["Resources"]); err == nil {
+			page.Resources, _ = resources.(Dict)
+		}
+		if rotate, err := reader.Resolve(attributes["Rotate"]); err == nil {
+			if value, ok := rotate.(int64); ok {
+				page.Rotate = int((value%360 + 360) % 360)
+			}
+		}
+		pages = append(pages, page)
+		return nil
+	}
+
+	if err := walk(root, Dict{}); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+func (reader *Reader) rectangle(object Object) ([4]float64, error) {
+	var rect [4]float64
+	resolved, err := reader.Resolve(object)
+	if err != nil {
+		return rect, err
+	}
+	array, ok := resolved.(Array)
+	if !ok || len(array) != 4 {
+		return rect, fmt.Errorf("page box is not a rectangle")
+	}
+	for i, item := range array {
+		value, err := reader.Resolve(item)
+		if err != nil {
+			return rect, err
+		}
+		if rect[i], ok = toNumber(value); !ok {
+			return rect, fmt.Errorf("page box is not a rectangle")
+		}
+	}
+	// Boxes may be given with any two opposite corners.
+	if rect[0] > rect[2] {
+		rect[0], rect[2] = rect[2], rect[0]
+	}
+	if rect[1] > rect[3] {
+		rect[1], rect[3] = rect[3], rect[1]
+	}
+	return rect, nil
+}
+
+func intersect(a, b [4]float64) [4]float64 {
+	return [4]float64{max(a[0], b[0]), max(a[1], b[1]), min(a[2], b[2]), min(a[3], b[3])}
+}
@@ -0,0 +1,274 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// parser reads objects from the bytes of a document, starting at pos. depth
+// counts the arrays and dictionaries being read, so that deeply nested ones
+// fail instead of overflowing the stack.
+type parser struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// maxNesting is deeper than any real document nests arrays and dictionaries.
+const maxNesting = 256
+
+func isWhitespace(char byte) bool {
+	switch char {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelimiter(char byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), char) >= 0
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch char := p.data[p.pos]; {
+		case isWhitespace(char):
+			p.pos++
+		case char == '%':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// keyword reads a run of regular characters, such as obj, R or a number.
+func (p *parser) keyword() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.data) && !isWhitespace(p.data[p.pos]) && !isDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+func (p *parser) expect(keyword string) error {
+	if word := p.keyword(); word != keyword {
+		return fmt.Errorf("expected %s at offset %d, found %q", keyword, p.pos, word)
+	}
+	return nil
+}
+
+func (p *parser) parseObject() (Object, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, fmt.Errorf("unexpected end of file")
+	}
+
+	switch p.data[p.pos] {
+	case '/':
+		return p.parseName(), nil
+	case '(':
+		return p.parseLiteralString()
+	case '<':
+		if p.pos+1 < len(p.data) && p.data[p.pos+1] == '<' {
+			if err := p.nest(); err != nil {
+				return nil, err
+			}
+			defer p.unnest()
+			return p.parseDict()
+		}
+		return p.parseHexString()
+	case '[':
+		if err := p.nest(); err != nil {
+			return nil, err
+		}
+		defer p.unnest()
+		return p.parseArray()
+	}
+
+	start := p.pos
+	word := p.keyword()
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "":
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.data[p.pos], p.pos)
+	}
+
+	integer, err := strconv.ParseInt(word, 10, 64)
+	if err != nil {
+		real, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected %q at offset %d", word, start)
+		}
+		return real, nil
+	}
+
+	// An integer may start a reference: number generation R.
+	save := p.pos
+	if generation, err := strconv.Atoi(p.keyword()); err == nil && p.keyword() == "R" {
+		return Ref{Number: int(integer), Generation: generation}, nil
+	}
+	p.pos = save
+	return integer, nil
+}
+
+func (p *parser) nest() error {
+	if p.depth >= maxNesting {
+		return fmt.Errorf("objects nested more than %d deep at offset %d", maxNesting, p.pos)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *parser) unnest() {
+	p.depth--
+}
+
+func (p *parser) parseArray() (Array, error) {
+	p.pos++
+	array := Array{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		if p.data[p.pos] == ']' {
+			p.pos++
+			return array, nil
+		}
+		item, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, item)
+	}
+}
+
+func (p *parser) parseName() Name {
+	p.pos++
+	var name []byte
+	for p.pos < len(p.data) && !isWhitespace(p.data[p.pos]) && !isDelimiter(p.data[p.pos]) {
+		char := p.data[p.pos]
+		if char == '#' && p.pos+2 < len(p.data) {
+			if decoded, err := hex.DecodeString(string(p.data[p.pos+1 : p.pos+3])); err == nil {
+				name = append(name, decoded[0])
+				p.pos += 3
+				continue
+			}
+		}
+		name = append(name, char)
+		p.pos++
+	}
+	return Name(name)
+}
+
+func (p *parser) parseLiteralString() (String, error) {
+	p.pos++
+	var result []byte
+	depth := 1
+	for p.pos < len(p.data) {
+		char := p.data[p.pos]
+		p.pos++
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return String(result), nil
+			}
+		case '\\':
+			if p.pos >= len(p.data) {
+				break
+			}
+			escaped := p.data[p.pos]
+			p.pos++
+			switch escaped {
+			case 'n':
+				char = '\n'
+			case 'r':
+				char = '\r'
+			case 't':
+				char = '\t'
+			case 'b':
+				char = '\b'
+			case 'f':
+				char = '\f'
+			case '\r':
+				if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					value := int(escaped - '0')
+					for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						value = value*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					char = byte(value)
+				} else {
+					char = escaped
+				}
+			}
+		}
+		result = append(result, char)
+	}
+	return nil, fmt.Errorf("unterminated string")
+}
+
+func (p *parser) parseHexString() (String, error) {
+	p.pos++
+	var digits []byte
+	for p.pos < len(p.data) && p.data[p.pos] != '>' {
+		if !isWhitespace(p.data[p.pos]) {
+			digits = append(digits, p.data[p.pos])
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.data) {
+		return nil, fmt.Errorf("unterminated hex string")
+	}
+	p.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	decoded, err := hex.DecodeString(string(digits))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex string: %v", err)
+	}
+	return String(decoded), nil
+}
+
+func (p *parser) parseDict() (Dict, error) {
+	p.pos += 2
+	dict := Dict{}
+	for {
+		p.skipSpace()
+		if p.pos+1 < len(p.data) && p.data[p.pos] == '>' && p.data[p.pos+1] == '>' {
+			p.pos += 2
+			return dict, nil
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != '/' {
+			return nil, fmt.Errorf("expected a name in dictionary at offset %d", p.pos)
+		}
+		key := p.parseName()
+		value, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		dict[key] = value
+	}
+}
@@ -0,0 +1,563 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type xrefEntry struct {
+	// offset of the object, or for compressed objects the number of the
+	// object stream holding it.
+	offset     int
+	generation int
+	compressed bool
+	index      int
+}
+
+// Reader gives access to the objects of an existing document, following
+// every cross-reference section from the last one back.
+type Reader struct {
+	data      []byte
+	xref      map[int]xrefEntry
+	trailer   Dict
+	startxref int
+	// xrefStream is set when the last section is a cross-reference stream,
+	// so that updates are written the same way.
+	xrefStream bool
+	objects    map[int]Object
+}
+
+func Open(data []byte) (*Reader, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data[:min(len(data), 1024)], "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF document")
+	}
+
+	keyword := bytes.LastIndex(data, []byte("startxref"))
+	if keyword < 0 {
+		return nil, fmt.Errorf("no startxref found")
+	}
+	p := &parser{data: data, pos: keyword + len("startxref")}
+	startxref, err := strconv.Atoi(p.keyword())
+	if err != nil {
+		return nil, fmt.Errorf("startxref is not an offset")
+	}
+
+	reader := &Reader{data: data, xref: map[int]xrefEntry{}, startxref: startxref, objects: map[int]Object{}}
+	visited := map[int]bool{}
+	for offset := startxref; ; {
+		if visited[offset] {
+			return nil, fmt.Errorf("cross-reference sections form a loop")
+		}
+		visited[offset] = true
+
+		trailer, isStream, err := reader.readXref(offset)
+		if err != nil {
+			return nil, fmt.Errorf("cross-reference section at %d: %v", offset, err)
+		}
+		if reader.trailer == nil {
+			reader.trailer = trailer
+			reader.xrefStream = isStream
+		}
+
+		// Hybrid files list objects in compressed streams separately.
+		if hidden, ok := trailer["XRefStm"].(int64); ok && !visited[int(hidden)] {
+			visited[int(hidden)] = true
+			if _, _, err := reader.readXref(int(hidden)); err != nil {
+				return nil, fmt.Errorf("cross-reference stream at %d: %v", hidden, err)
+			}
+		}
+
+		previous, ok := trailer["Prev"].(int64)
+		if !ok {
+			break
+		}
+		offset = int(previous)
+	}
+
+	if _, ok := reader.trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("encrypted documents are not supported")
+	}
+	if _, ok := reader.trailer["Root"].(Ref); !ok {
+		return nil, fmt.Errorf("the trailer has no document catalog")
+	}
+	return reader, nil
+}
+
+// setEntry records an entry unless a later section already did. Free
+// entries never hide an object.
+func (reader *Reader) setEntry(number int, entry xrefEntry) {
+	if _, ok := reader.xref[number]; !ok {
+		reader.xref[number] = entry
+	}
+}
+
+func (reader *Reader) readXref(offset int) (Dict, bool, error) {
+	if offset < 0 || offset >= len(reader.data) {
+		return nil, false, fmt.Errorf("offset is outside the file")
+	}
+	p := &parser{data: reader.data, pos: offset}
+	save := p.pos
+	if p.keyword() != "xref" {
+		p.pos = save
+		return reader.readXrefStream(p)
+	}
+
+	for {
+		save = p.pos
+		first, err := strconv.Atoi(p.keyword())
+		if err != nil {
+			p.pos = save
+			break
+		}
+		count, err := strconv.Atoi(p.keyword())
+		if err != nil {
+			return nil, false, fmt.Errorf("malformed subsection header")
+		}
+		for i := 0; i < count; i++ {
+			entryOffset, err1 := strconv.Atoi(p.keyword())
+			generation, err2 := strconv.Atoi(p.keyword())
+			kind := p.keyword()
+			if err1 != nil || err2 != nil || (kind != "n" && kind != "f") {
+				return nil, false, fmt.Errorf("malformed entry for object %d", first+i)
+			}
+			if kind == "n" {
+				reader.setEntry(first+i, xrefEntry{offset: entryOffset, generation: generation})
+			}
+		}
+	}
+
+	if err := p.expect("trailer"); err != nil {
+		return nil, false, err
+	}
+	object, err := p.parseObject()
+	if err != nil {
+		return nil, false, err
+	}
+	trailer, ok := object.(Dict)
+	if !ok {
+		return nil, false, fmt.Errorf("trailer is not a dictionary")
+	}
+	return trailer, false, nil
+}
+
+func (reader *Reader) readXrefStream(p *parser) (Dict, bool, error) {
+	_, object, err := p.parseIndirectObject(reader)
+	if err != nil {
+		return nil, false, err
+	}
+	stream, ok := object.(*Stream)
+	if !ok || stream.Dict["Type"] != Name("XRef") {
+		return nil, false, fmt.Errorf("neither an xref table nor an xref stream")
+	}
+	data, err := reader.Decode(stream)
+	if err != nil {
+		return nil, false, err
+	}
+
+	widths, ok := stream.Dict["W"].(Array)
+	if !ok || len(widths) != 3 {
+		return nil, false, fmt.Errorf("xref stream has no field widths")
+	}
+	var w [3]int
+	for i, width := range widths {
+		value, ok := width.(int64)
+		if !ok || value < 0 || value > 8 {
+			return nil, false, fmt.Errorf("xref stream has invalid field widths")
+		}
+		w[i] = int(value)
+	}
+
+	index, _ := stream.Dict["Index"].(Array)
+	if index == nil {
+		size, _ := stream.Dict["Size"].(int64)
+		index = Array{int64(0), size}
+	}
+
+	field := func(entry []byte, i int) int {
+		start := 0
+		for j := 0; j < i; j++ {
+			start += w[j]
+		}
+		value := 0
+		for _, char := range entry[start : start+w[i]] {
+			value = value<<8 | int(char)
+		}
+		return value
+	}
+
+	size := w[0] + w[1] + w[2]
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		first, _ := index[i].(int64)
+		count, _ := index[i+1].(int64)
+		for j := 0; j < int(count); j++ {
+			if pos+size > len(data) {
+				return nil, false, fmt.Errorf("xref stream is truncated")
+			}
+			entry := data[pos : pos+size]
+			pos += size
+
+			kind := 1
+			if w[0] > 0 {
+				kind = field(entry, 0)
+			}
+			switch kind {
+			case 1:
+				reader.setEntry(int(first)+j, xrefEntry{offset: field(entry, 1), generation: field(entry, 2)})
+			case 2:
+				reader.setEntry(int(first)+j, xrefEntry{offset: field(entry, 1), compressed: true, index: field(entry, 2)})
+			}
+		}
+	}
+	return stream.Dict, true, nil
+}
+
+// parseIndirectObject reads "number generation obj" and the object after it,
+// with the stream data if there is any.
+func (p *parser) parseIndirectObject(reader *Reader) (Ref, Object, error) {
+	number, err1 := strconv.Atoi(p.keyword())
+	generation, err2 := strconv.Atoi(p.keyword())
+	if err1 != nil || err2 != nil || p.keyword() != "obj" {
+		return Ref{}, nil, fmt.Errorf("expected an object at offset %d", p.pos)
+	}
+	ref := Ref{Number: number, Generation: generation}
+
+	object, err := p.parseObject()
+	if err != nil {
+		return ref, nil, err
+	}
+	dict, ok := object.(Dict)
+	if !ok {
+		return ref, object, nil
+	}
+
+	save := p.pos
+	if p.keyword() != "stream" {
+		p.pos = save
+		return ref, dict, nil
+	}
+	// The data starts after the end of line that follows the keyword.
+	if p.pos < len(p.data) && p.data[p.pos] == '\r' {
+		p.pos++
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+		p.pos++
+	}
+	start := p.pos
+
+	length := -1
+	if value, err := reader.Resolve(dict["Length"]); err == nil {
+		if number, ok := value.(int64); ok {
+			length = int(number)
+		}
+	}
+	end := start + length
+	if length < 0 || end > len(p.data) || !bytes.HasPrefix(bytes.TrimLeft(p.data[end:], "\x00\t\n\f\r "), []byte("endstream")) {
+		// Fall back to searching when the length is missing or wrong.
+		found := bytes.Index(p.data[start:], []byte("endstream"))
+		if found < 0 {
+			return ref, nil, fmt.Errorf("stream of object %d has no end", number)
+		}
+		end = start + found
+		for end > start && (p.data[end-1] == '\n' || p.data[end-1] == '\r') {
+			end--
+		}
+	}
+	p.pos = end
+	return ref, &Stream{Dict: dict, Data: p.data[start:end]}, nil
+}
+
+// Trailer returns the trailer dictionary of the last cross-reference section.
+func (reader *Reader) Trailer() Dict {
+	return reader.trailer
+}
+
+// Object returns the object with the given number, or nil if it does not
+// exist, as PDF requires of references to missing objects.
+func (reader *Reader) Object(number int) (Object, error) {
+	if object, ok := reader.objects[number]; ok {
+		return object, nil
+	}
+	entry, ok := reader.xref[number]
+	if !ok {
+		return nil, nil
+	}
+	// Guard against streams whose Length refers back to themselves.
+	reader.objects[number] = nil
+
+	var (
+		object Object
+		err    error
+	)
+	if entry.compressed {
+		object, err = reader.compressedObject(entry.offset, entry.index, number)
+	} else if entry.offset >= 0 && entry.offset < len(reader.data) {
+		p := &parser{data: reader.data, pos: entry.offset}
+		var ref Ref
+		ref, object, err = p.parseIndirectObject(reader)
+		if err == nil && ref.Number != number {
+			err = fmt.Errorf("found object %d where %d was expected", ref.Number, number)
+		}
+	} else {
+		err = fmt.Errorf("object %d is outside the file", number)
+	}
+	if err != nil {
+		delete(reader.objects, number)
+		return nil, err
+	}
+	reader.objects[number] = object
+	return object, nil
+}
+
+func (reader *Reader) compressedObject(streamNumber, index, number int) (Object, error) {
+	object, err := reader.Object(streamNumber)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := object.(*Stream)
+	if !ok || stream.Dict["Type"] != Name("ObjStm") {
+		return nil, fmt.Errorf("object %d is not an object stream", streamNumber)
+	}
+	data, err := reader.Decode(stream)
+	if err != nil {
+		return nil, err
+	}
+	count, _ := stream.Dict["N"].(int64)
+	first, _ := stream.Dict["First"].(int64)
+
+	p := &parser{data: data}
+	for i := 0; i < int(count); i++ {
+		objectNumber, err1 := strconv.Atoi(p.keyword())
+		offset, err2 := strconv.Atoi(p.keyword())
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("object stream %d has a malformed header", streamNumber)
+		}
+		if objectNumber == number {
+			p.pos = int(first) + offset
+			if p.pos > len(data) {
+				break
+			}
+			return p.parseObject()
+		}
+	}
+	return nil, fmt.Errorf("object %d is not in object stream %d at index %d", number, streamNumber, index)
+}
+
+// Resolve follows references until it reaches a direct object.
+func (reader *Reader) Resolve(object Object) (Object, error) {
+	for i := 0; i < 32; i++ {
+		ref, ok := object.(Ref)
+		if !ok {
+			return object, nil
+		}
+		var err error
+		if object, err = reader.Object(ref.Number); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("references form a loop")
+}
+
+// Decode undoes the filters of a stream. FlateDecode, with or without PNG
+// and TIFF predictors, ASCIIHexDecode and ASCII85Decode are supported.
+func (reader *Reader) Decode(stream *Stream) ([]byte, error) {
+	data, filters, _, err := reader.decode(stream.Dict, stream.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) > 0 {
+		return nil, fmt.Errorf("filter %v is not supported", filters[0])
+	}
+	return data, nil
+}
+
+// maxStreamBytes leaves room for a 32 megapixel RGB image, the largest the
+// scanner reads, while keeping small compressed bombs from exhausting memory.
+const maxStreamBytes = 128 << 20
+
+// decode undoes filters until it reaches one it does not support, and
+// returns the filters left with their parameters, for image filters that
+// the caller can undo itself. The abbreviated filter names of inline images
+// are accepted too.
+func (reader *Reader) decode(dict Dict, data []byte) ([]byte, []Name, []Dict, error) {
+	filters, err := reader.Resolve(dict["Filter"])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	params, err := reader.Resolve(dict["DecodeParms"])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if name, ok := filters.(Name); ok {
+		filters, params = Array{name}, Array{params}
+	}
+	filterList, _ := filters.(Array)
+	paramList, _ := params.(Array)
+
+	var names []Name
+	var paramDicts []Dict
+	for i, filter := range filterList {
+		name, _ := filter.(Name)
+		names = append(names, name)
+		var param Dict
+		if i < len(paramList) {
+			if resolved, err := reader.Resolve(paramList[i]); err == nil {
+				param, _ = resolved.(Dict)
+			}
+		}
+		paramDicts = append(paramDicts, param)
+	}
+
+	for i, name := range names {
+		switch name {
+		case "FlateDecode", "Fl":
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("could not inflate stream: %v", err)
+			}
+			// Tolerate truncated streams, which many producers write.
+			decoded, err := io.ReadAll(io.LimitReader(zr, maxStreamBytes+1))
+			if err != nil && err != io.ErrUnexpectedEOF && len(decoded) == 0 {
+				return nil, nil, nil, fmt.Errorf("could not inflate stream: %v", err)
+			}
+			if len(decoded) > maxStreamBytes {
+				return nil, nil, nil, fmt.Errorf("stream is larger than %d MB once inflated", maxStreamBytes>>20)
+			}
+			data = decoded
+			if paramDicts[i] != nil {
+				if data, err = unpredict(data, paramDicts[i]); err != nil {
+					return nil, nil, nil, err
+				}
+			}
+		case "ASCIIHexDecode", "AHx":
+			if data, err = asciiHex(data); err != nil {
+				return nil, nil, nil, err
+			}
+		case "ASCII85Decode", "A85":
+			if data, err = ascii85Decode(data); err != nil {
+				return nil, nil, nil, err
+			}
+		default:
+			return data, names[i:], paramDicts[i:], nil
+		}
+	}
+	return data, nil, nil, nil
+}
+
+func asciiHex(data []byte) ([]byte, error) {
+	if end := bytes.IndexByte(data, '>'); end >= 0 {
+		data = data[:end]
+	}
+	p := &parser{data: append(append([]byte{'<'}, data...), '>')}
+	decoded, err := p.parseHexString()
+	return []byte(decoded), err
+}
+
+func ascii85Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+	decoded := make([]byte, 4*len(data)+4)
+	n, _, err := ascii85.Decode(decoded, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode ASCII85 data: %v", err)
+	}
+	return decoded[:n], nil
+}
+
+func unpredict(data []byte, params Dict) ([]byte, error) {
+	predictor, _ := params["Predictor"].(int64)
+	if predictor < 2 {
+		return data, nil
+	}
+	columns, colors, bits := int64(1), int64(1), int64(8)
+	if value, ok := params["Columns"].(int64); ok {
+		columns = value
+	}
+	if value, ok := params["Colors"].(int64); ok {
+		colors = value
+	}
+	if value, ok := params["BitsPerComponent"].(int64); ok {
+		bits = value
+	}
+	if columns < 1 || colors < 1 {
+		return nil, fmt.Errorf("predictor needs at least one column and colour, got %d and %d", columns, colors)
+	}
+	switch bits {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("predictor bits per component must be 1, 2, 4, 8 or 16, got %d", bits)
+	}
+	if len(data) == 0 {
+		return data, nil
+	}
+	// Dividing keeps columns times colours from overflowing.
+	if available := 8 * int64(len(data)); colors > available || columns > available/(colors*bits) {
+		return nil, fmt.Errorf("predictor row of %d columns is longer than the data", columns)
+	}
+	pixel := int((colors*bits + 7) / 8)
+	row := int((columns*colors*bits + 7) / 8)
+
+	if predictor == 2 {
+		if bits != 8 {
+			return nil, fmt.Errorf("TIFF predictor with %d bits is not supported", bits)
+		}
+		for start := 0; start+row <= len(data); start += row {
+			for i := start + pixel; i < start+row; i++ {
+				data[i] += data[i-pixel]
+			}
+		}
+		return data, nil
+	}
+
+	var result []byte
+	previous := make([]byte, row)
+	for start := 0; start+row+1 <= len(data); start += row + 1 {
+		kind := data[start]
+		current := append([]byte(nil), data[start+1:start+1+row]...)
+		for i := range current {
+			var left, upLeft byte
+			if i >= pixel {
+				left, upLeft = current[i-pixel], previous[i-pixel]
+			}
+			up := previous[i]
+			switch kind {
+			case 1:
+				current[i] += left
+			case 2:
+				current[i] += up
+			case 3:
+				current[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				current[i] += paeth(left, up, upLeft)
+			}
+		}
+		result = append(result, current...)
+		previous = current
+	}
+	return result, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,448 @@
+package pdf
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+	"golang.org/x/image/vector"
+)
+
+// maxRenderPixels bounds the size of a rendered page, lowering the
+// resolution of very large pages.
+const maxRenderPixels = 1 << 25
+
+// maxFormDepth bounds how deeply forms may draw other forms.
+const maxFormDepth = 8
+
+// matrix is an affine transformation as PDF writes it, [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns the transformation that applies m and then n.
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+type graphicsState struct {
+	ctm       matrix
+	fill      uint8
+	stroke    uint8
+	lineWidth float64
+}
+
+type point struct{ x, y float64 }
+
+// painter interprets content streams. With a destination it draws on it,
+// and it collects the images that are drawn either way.
+type painter struct {
+	reader *Reader
+	dst    *image.Gray
+	// device maps the page's coordinates to the destination's pixels.
+	device matrix
+	raster *vector.Rasterizer
+	images []image.Image
+	seen   map[int]bool
+}
+
+// Render draws a page in grey at a resolution in dots per inch: its filled
+// and stroked paths and its images, which is enough to read codes drawn
+// either as vectors or as pictures. Text, shadings and clipping are left
+// out and the page is not rotated, so the result is meant for reading
+// codes, not for viewing.
+func (reader *Reader) Render(page *ExistingPage, dpi float64) (*image.Gray, error) {
+	box := page.CropBox
+	scale := dpi / 72
+	width, height := (box[2]-box[0])*scale, (box[3]-box[1])*scale
+	if width*height > maxRenderPixels {
+		scale *= math.Sqrt(maxRenderPixels / (width * height))
+		width, height = (box[2]-box[0])*scale, (box[3]-box[1])*scale
+	}
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("page is empty")
+	}
+	dst := image.NewGray(image.Rect(0, 0, int(math.Ceil(width)), int(math.Ceil(height))))
+	for i := range dst.Pix {
+		dst.Pix[i] = 255
+	}
+	painter := &painter{
+		reader: reader,
+		dst:    dst,
+		device: matrix{scale, 0, 0, -scale, -box[0] * scale, box[3] * scale},
+		raster: &vector.Rasterizer{},
+		seen:   map[int]bool{},
+	}
+	if err := painter.page(page); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// Images returns the images a page draws, directly, through forms or
+// inline, each once and at its own resolution. Images that cannot be
+// decoded are left out.
+func (reader *Reader) Images(page *ExistingPage) ([]image.Image, error) {
+	painter := &painter{reader: reader, seen: map[int]bool{}}
+	if err := painter.page(page); err != nil {
+		return nil, err
+	}
+	return painter.images, nil
+}
+
+func (painter *painter) page(page *ExistingPage) error {
+	content, err := painter.reader.Content(page)
+	if err != nil {
+		return err
+	}
+	painter.run(content, page.Resources, graphicsState{ctm: identity, lineWidth: 1}, 0)
+	return nil
+}
+
+// run interprets a content stream. Unknown operators are skipped and a
+// malformed stream stops where it goes wrong, as viewers do.
+func (painter *painter) run(content []byte, resources Dict, state graphicsState, depth int) {
+	p := &parser{data: content}
+	var (
+		stack   []graphicsState
+		path    [][]point
+		current []point
+		start   point
+	)
+	closePath := func() {
+		if len(current) > 0 {
+			current = append(current, start)
+			path = append(path, current)
+			current = nil
+		}
+	}
+	endPath := func() {
+		if len(current) > 1 {
+			path = append(path, current)
+		}
+		current = nil
+	}
+	add := func(x, y float64) point {
+		x, y = state.ctm.multiply(painter.device).apply(x, y)
+		return point{x, y}
+	}
+
+	for {
+		operands, operator, err := p.operation()
+		if err != nil {
+			// Whatever was drawn before an error is kept.
+			return
+		}
+		numbers := make([]float64, 0, len(operands))
+		for _, operand := range operands {
+			if value, ok := toNumber(operand); ok {
+				numbers = append(numbers, value)
+			}
+		}
+
+		switch operator {
+		case "q":
+			stack = append(stack, state)
+		case "Q":
+			if len(stack) > 0 {
+				state, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(numbers) == 6 {
+				state.ctm = matrix(numbers).multiply(state.ctm)
+			}
+		case "w":
+			if len(numbers) == 1 {
+				state.lineWidth = numbers[0]
+			}
+		case "g", "rg", "k", "sc", "scn":
+			state.fill = colorOperands(operands, numbers)
+		case "G", "RG", "K", "SC", "SCN":
+			state.stroke = colorOperands(operands, numbers)
+		case "cs":
+			state.fill = 0
+		case "CS":
+			state.stroke = 0
+
+		case "m":
+			if len(numbers) == 2 {
+				endPath()
+				start = add(numbers[0], numbers[1])
+				current = []point{start}
+			}
+		case "l":
+			if len(numbers) == 2 && len(current) > 0 {
+				current = append(current, add(numbers[0], numbers[1]))
+			}
+		case "c", "v", "y":
+			if len(current) == 0 {
+				break
+			}
+			var control [3]point
+			switch {
+			case operator == "c" && len(numbers) == 6:
+				control = [3]point{add(numbers[0], numbers[1]), add(numbers[2], numbers[3]), add(numbers[4], numbers[5])}
+			case operator == "v" && len(numbers) == 4:
+				control = [3]point{current[len(current)-1], add(numbers[0], numbers[1]), add(numbers[2], numbers[3])}
+			case operator == "y" && len(numbers) == 4:
+				end := add(numbers[2], numbers[3])
+				control = [3]point{add(numbers[0], numbers[1]), end, end}
+			default:
+				continue
+			}
+			current = flatten(current, control)
+		case "h":
+			closePath()
+		case "re":
+			if len(numbers) == 4 {
+				endPath()
+				x, y, w, h := numbers[0], numbers[1], numbers[2], numbers[3]
+				path = append(path, []point{add(x, y), add(x+w, y), add(x+w, y+h), add(x, y+h), add(x, y)})
+			}
+
+		case "f", "F", "f*", "B", "B*", "b", "b*", "S", "s", "n":
+			if operator == "b" || operator == "b*" || operator == "s" {
+				closePath()
+			}
+			endPath()
+			switch operator {
+			case "f", "F", "f*":
+				painter.fill(path, state.fill)
+			case "B", "B*", "b", "b*":
+				painter.fill(path, state.fill)
+				painter.stroke(path, state)
+			case "S", "s":
+				painter.stroke(path, state)
+			}
+			path = nil
+
+		case "Do":
+			if len(operands) == 1 && depth < maxFormDepth {
+				name, _ := operands[0].(Name)
+				painter.xObject(name, resources, state, depth)
+			}
+		case "BI":
+			if image, ok := operands[0].(*Stream); ok {
+				painter.inlineImage(image, resources, state)
+			}
+		}
+	}
+}
+
+// colorOperands converts the operands of a colour operator to grey.
+// Patterns, given by name, are taken to be dark.
+func colorOperands(operands []Object, numbers []float64) uint8 {
+	if len(operands) > 0 {
+		if _, ok := operands[len(operands)-1].(Name); ok {
+			return 0
+		}
+	}
+	var value float64
+	switch len(numbers) {
+	case 1:
+		value = numbers[0]
+	case 3:
+		value = luminance(numbers[0], numbers[1], numbers[2])
+	case 4:
+		value = luminance((1-numbers[0])*(1-numbers[3]), (1-numbers[1])*(1-numbers[3]), (1-numbers[2])*(1-numbers[3]))
+	}
+	return uint8(255*clamp(value) + 0.5)
+}
+
+// flatten appends a cubic Bézier curve from the last point of a subpath as
+// straight segments.
+func flatten(subpath []point, control [3]point) []point {
+	const segments = 16
+	from := subpath[len(subpath)-1]
+	for i := 1; i <= segments; i++ {
+		t := float64(i) / segments
+		u := 1 - t
+		subpath = append(subpath, point{
+			u*u*u*from.x + 3*u*u*t*control[0].x + 3*u*t*t*control[1].x + t*t*t*control[2].x,
+			u*u*u*from.y + 3*u*u*t*control[0].y + 3*u*t*t*control[1].y + t*t*t*control[2].y,
+		})
+	}
+	return subpath
+}
+
+// fill paints the subpaths with the nonzero winding rule. The rasterizer
+// only covers the path's bounds, which for codes drawn as many small
+// rectangles is far less than the page.
+func (painter *painter) fill(path [][]point, gray uint8) {
+	if painter.dst == nil || len(path) == 0 {
+		return
+	}
+	bounds := image.Rectangle{}
+	for i, subpath := range path {
+		for j, pt := range subpath {
+			pixel := image.Rect(int(math.Floor(pt.x)), int(math.Floor(pt.y)), int(math.Floor(pt.x))+1, int(math.Floor(pt.y))+1)
+			if i == 0 && j == 0 {
+				bounds = pixel
+			} else {
+				bounds = bounds.Union(pixel)
+			}
+		}
+	}
+	bounds = bounds.Intersect(painter.dst.Rect)
+	if bounds.Empty() {
+		return
+	}
+	z := painter.raster
+	z.Reset(bounds.Dx(), bounds.Dy())
+	offsetX, offsetY := float64(bounds.Min.X), float64(bounds.Min.Y)
+	for _, subpath := range path {
+		z.MoveTo(float32(subpath[0].x-offsetX), float32(subpath[0].y-offsetY))
+		for _, pt := range subpath[1:] {
+			z.LineTo(float32(pt.x-offsetX), float32(pt.y-offsetY))
+		}
+		z.ClosePath()
+	}
+	z.DrawOp = draw.Over
+	z.Draw(painter.dst, bounds, image.NewUniform(color.Gray{gray}), image.Point{})
+}
+
+// stroke paints each segment as a rectangle as wide as the line, which is
+// close enough for the lines of codes and frames.
+func (painter *painter) stroke(path [][]point, state graphicsState) {
+	if painter.dst == nil {
+		return
+	}
+	device := state.ctm.multiply(painter.device)
+	width := max(1, state.lineWidth*math.Sqrt(math.Abs(device[0]*device[3]-device[1]*device[2])))
+	var outline [][]point
+	for _, subpath := range path {
+		for i := 1; i < len(subpath); i++ {
+			a, b := subpath[i-1], subpath[i]
+			length := math.Hypot(b.x-a.x, b.y-a.y)
+			if length == 0 {
+				continue
+			}
+			nx, ny := -(b.y-a.y)/length*width/2, (b.x-a.x)/length*width/2
+			outline = append(outline, []point{
+				{a.x + nx, a.y + ny}, {b.x + nx, b.y + ny}, {b.x - nx, b.y - ny}, {a.x - nx, a.y - ny},
+			})
+		}
+	}
+	painter.fill(outline, state.stroke)
+}
+
+func (painter *painter) xObject(name Name, resources Dict, state graphicsState, depth int) {
+	xObjects, _ := painter.reader.Resolve(resources["XObject"])
+	xObjectDict, _ := xObjects.(Dict)
+	ref, _ := xObjectDict[name].(Ref)
+	object, err := painter.reader.Resolve(xObjectDict[name])
+	if err != nil {
+		return
+	}
+	stream, ok := object.(*Stream)
+	if !ok {
+		return
+	}
+
+	switch stream.Dict["Subtype"] {
+	case Name("Image"):
+		img, err := painter.reader.Image(stream)
+		if err != nil {
+			return
+		}
+		if ref.Number == 0 || !painter.seen[ref.Number] {
+			painter.seen[ref.Number] = true
+			painter.collect(img)
+		}
+		painter.drawImage(img, state)
+	case Name("Form"):
+		content, err := painter.reader.Decode(stream)
+		if err != nil {
+			return
+		}
+		if form, err := painter.reader.Resolve(stream.Dict["Matrix"]); err == nil {
+			if values, ok := form.(Array); ok && len(values) == 6 {
+				var m matrix
+				for i, value := range values {
+					m[i], _ = toNumber(value)
+				}
+				state.ctm = m.multiply(state.ctm)
+			}
+		}
+		// Forms without resources of their own use those of the page.
+		if own, err := painter.reader.Resolve(stream.Dict["Resources"]); err == nil {
+			if dict, ok := own.(Dict); ok {
+				resources = dict
+			}
+		}
+		painter.run(content, resources, state, depth+1)
+	}
+}
+
+func (painter *painter) inlineImage(stream *Stream, resources Dict, state graphicsState) {
+	// Inline images may name a colour space from the resources.
+	if name, ok := stream.Dict["ColorSpace"].(Name); ok {
+		spaces, _ := painter.reader.Resolve(resources["ColorSpace"])
+		if dict, ok := spaces.(Dict); ok && dict[name] != nil {
+			stream.Dict["ColorSpace"] = dict[name]
+		}
+	}
+	img, err := painter.reader.Image(stream)
+	if err != nil {
+		return
+	}
+	painter.collect(img)
+	painter.drawImage(img, state)
+}
+
+func (painter *painter) collect(img image.Image) {
+	if stencil, ok := img.(*image.Alpha); ok {
+		// Stencil masks are kept as the black shapes they usually paint.
+		gray := image.NewGray(stencil.Rect)
+		for i, value := range stencil.Pix {
+			gray.Pix[i] = 255 - value
+		}
+		img = gray
+	}
+	painter.images = append(painter.images, img)
+}
+
+// drawImage maps the image onto the unit square of user space, with its
+// first row at the top.
+func (painter *painter) drawImage(img image.Image, state graphicsState) {
+	if painter.dst == nil {
+		return
+	}
+	bounds := img.Bounds()
+	unit := matrix{1 / float64(bounds.Dx()), 0, 0, -1 / float64(bounds.Dy()), 0, 1}
+	m := unit.multiply(state.ctm).multiply(painter.device)
+	transform := f64.Aff3{m[0], m[2], m[4], m[1], m[3], m[5]}
+
+	var src image.Image = img
+	if stencil, ok := img.(*image.Alpha); ok {
+		painted := image.NewNRGBA(stencil.Rect)
+		for i, value := range stencil.Pix {
+			painted.Pix[4*i] = state.fill
+			painted.Pix[4*i+1] = state.fill
+			painted.Pix[4*i+2] = state.fill
+			painted.Pix[4*i+3] = value
+		}
+		src = painted
+	}
+	// Enlarged images keep sharp module edges. Reduced ones are
+	// interpolated, which loses less than dropping pixels.
+	var interpolator draw.Transformer = draw.NearestNeighbor
+	if math.Abs(m[0]*m[3]-m[1]*m[2]) < 1 {
+		interpolator = draw.ApproxBiLinear
+	}
+	interpolator.Transform(painter.dst, transform, src, bounds, draw.Over, nil)
+}
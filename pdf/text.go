@@ -0,0 +1,97 @@
+package pdf
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+func encodeWinAnsi(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, char := range norm.NFC.String(text) {
+		switch {
+		case char < 0x80 || (char >= 0xa0 && char <= 0xff):
+			encoded = append(encoded, byte(char))
+		case winAnsiSpecials[char] != 0:
+			encoded = append(encoded, winAnsiSpecials[char])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// WinAnsi reports whether text can be set in the standard 14 fonts without
+// characters being replaced.
+func WinAnsi(text string) bool {
+	for _, char := range norm.NFC.String(text) {
+		if char >= 0x80 && (char < 0xa0 || char > 0xff) && winAnsiSpecials[char] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Glyph widths for the printable ASCII range of the standard 14 fonts, in
+// 1/1000 em. Latin-1 letters are measured as their unaccented base letter.
+var glyphWidths = map[Font][95]int{
+	Helvetica: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	HelveticaBold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+func TextWidth(font Font, size float64, text string) float64 {
+	widths := glyphWidths[font]
+	total := 0
+	for _, char := range norm.NFD.String(text) {
+		switch {
+		case char >= 0x20 && char < 0x7f:
+			total += widths[char-0x20]
+		case char >= 0x300 && char < 0x370:
+			// Combining accents do not advance.
+		default:
+			total += widths['o'-0x20]
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+func WrapText(font Font, size, width float64, text string) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && TextWidth(font, size, candidate) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Update collects new and changed objects and appends them to the original
+// document as an incremental update, leaving its bytes untouched.
+type Update struct {
+	reader  *Reader
+	objects map[Ref]Object
+	next    int
+}
+
+func (reader *Reader) NewUpdate() *Update {
+	next := 1
+	if size, ok := reader.trailer["Size"].(int64); ok {
+		next = int(size)
+	}
+	for number := range reader.xref {
+		if number >= next {
+			next = number + 1
+		}
+	}
+	return &Update{reader: reader, objects: map[Ref]Object{}, next: next}
+}
+
+// Add stores a new object and returns its reference.
+func (update *Update) Add(object Object) Ref {
+	ref := Ref{Number: update.next}
+	update.next++
+	update.objects[ref] = object
+	return ref
+}
+
+// Replace writes a new version of an existing object.
+func (update *Update) Replace(ref Ref, object Object) {
+	update.objects[ref] = object
+}
+
+// CompressedStream returns a stream holding data with FlateDecode applied.
+func CompressedStream(dict Dict, data []byte) *Stream {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+
+	stream := &Stream{Dict: Dict{"Filter": Name("FlateDecode")}, Data: compressed.Bytes()}
+	for key, value := range dict {
+		stream.Dict[key] = value
+	}
+	return stream
+}
+
+// trailerKeys are copied from the previous trailer; the rest describe the
+// previous section only.
+var trailerKeys = []Name{"Root", "Info", "ID"}
+
+func (update *Update) Bytes() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	buf.Write(update.reader.data)
+	if !bytes.HasSuffix(update.reader.data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	refs := make([]Ref, 0, len(update.objects))
+	for ref := range update.objects {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Number < refs[j].Number })
+
+	offsets := map[Ref]int{}
+	for _, ref := range refs {
+		offsets[ref] = buf.Len()
+		fmt.Fprintf(buf, "%d %d obj\n", ref.Number, ref.Generation)
+		writeObject(buf, update.objects[ref])
+		buf.WriteString("\nendobj\n")
+	}
+
+	trailer := Dict{
+		"Size": int64(update.next),
+		"Prev": int64(update.reader.startxref),
+	}
+	for _, key := range trailerKeys {
+		if value, ok := update.reader.trailer[key]; ok {
+			trailer[key] = value
+		}
+	}
+
+	xref := buf.Len()
+	if update.reader.xrefStream {
+		// The cross-reference stream lists itself as well.
+		self := Ref{Number: update.next}
+		trailer["Size"] = int64(update.next + 1)
+		offsets[self] = xref
+		refs = append(refs, self)
+
+		var data []byte
+		var index Array
+		for _, run := range runs(refs) {
+			index = append(index, int64(run[0].Number), int64(len(run)))
+			for _, ref := range run {
+				data = append(data, 1)
+				data = binary.BigEndian.AppendUint32(data, uint32(offsets[ref]))
+				data = binary.BigEndian.AppendUint16(data, uint16(ref.Generation))
+			}
+		}
+		trailer["Type"] = Name("XRef")
+		trailer["W"] = Array{int64(1), int64(4), int64(2)}
+		trailer["Index"] = index
+
+		fmt.Fprintf(buf, "%d 0 obj\n", self.Number)
+		writeObject(buf, &Stream{Dict: trailer, Data: data})
+		buf.WriteString("\nendobj\n")
+	} else {
+		buf.WriteString("xref\n")
+		for _, run := range runs(refs) {
+			fmt.Fprintf(buf, "%d %d\n", run[0].Number, len(run))
+			for _, ref := range run {
+				fmt.Fprintf(buf, "%010d %05d n \n", offsets[ref], ref.Generation)
+			}
+		}
+		buf.WriteString("trailer\n")
+		writeObject(buf, trailer)
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(buf, "startxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes(), nil
+}
+
+// runs splits sorted references into runs of consecutive object numbers,
+// one per cross-reference subsection.
+func runs(refs []Ref) [][]Ref {
+	var result [][]Ref
+	for i, ref := range refs {
+		if i == 0 || ref.Number != refs[i-1].Number+1 {
+			result = append(result, nil)
+		}
+		result[len(result)-1] = append(result[len(result)-1], ref)
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+)
+
+type objectWriter struct {
+	buf     *bytes.Buffer
+	offsets []int
+}
+
+func newObjectWriter() *objectWriter {
+	return &objectWriter{buf: bytes.NewBuffer(nil)}
+}
+
+func (w *objectWriter) header() {
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+}
+
+func (w *objectWriter) reserve() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+func (w *objectWriter) define(id int, body string) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *objectWriter) object(body string) int {
+	id := w.reserve()
+	w.define(id, body)
+	return id
+}
+
+func (w *objectWriter) stream(dictionary string, data []byte) int {
+	compressed := bytes.NewBuffer(nil)
+	zw := zlib.NewWriter(compressed)
+	zw.Write(data)
+	zw.Close()
+
+	id := w.reserve()
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(
+		w.buf, "%d 0 obj\n<< %s /Length %d /Filter /FlateDecode >>\nstream\n",
+		id, dictionary, compressed.Len(),
+	)
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return id
+}
+
+func (w *objectWriter) trailer(root int) {
+	xref := w.buf.Len()
+	fmt.Fprintf(w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(
+		w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1, root, xref,
+	)
+}
@@ -0,0 +1,44 @@
+package qrcode
+
+import (
+	"fmt"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/makiuchi-d/gozxing/qrcode/encoder"
+)
+
+// GS1 element strings are encoded with the FNC1 in first position mode
+// indicator, which go-qrcode does not support, so they go through the zxing
+// encoder instead. Variable length fields are separated with the GS character.
+func (code *SimpleQRCode) gs1Modules() ([][]bool, error) {
+	hints := map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_GS1_FORMAT: true,
+	}
+	qrCode, err := encoder.Encoder_encode(code.Content, code.ErrorCorrection.zxingLevel(), hints)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate a GS1 QR code: %v", err)
+	}
+
+	matrix := qrCode.GetMatrix()
+	modules := make([][]bool, matrix.GetHeight())
+	for y := range modules {
+		modules[y] = make([]bool, matrix.GetWidth())
+		for x := range modules[y] {
+			modules[y][x] = matrix.Get(x, y) == 1
+		}
+	}
+	return modules, nil
+}
+
+func (level ErrorCorrection) zxingLevel() decoder.ErrorCorrectionLevel {
+	switch level {
+	case "L":
+		return decoder.ErrorCorrectionLevel_L
+	case "Q":
+		return decoder.ErrorCorrectionLevel_Q
+	case "H":
+		return decoder.ErrorCorrectionLevel_H
+	}
+	return decoder.ErrorCorrectionLevel_M
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
 	"image/png"
 	"io"
@@ -13,16 +14,65 @@ import (
 )
 
 type SimpleQRCode struct {
-	Content string
-	Size    int
+	Content         string
+	Size            int
+	GS1             bool
+	Symbology       Symbology
+	ErrorCorrection ErrorCorrection
+	// Foreground and Background colour the PNG, black on white when nil.
+	Foreground color.Color
+	Background color.Color
+}
+
+// ErrorCorrection is the QR code recovery level: L, M, Q or H restore
+// about 7, 15, 25 and 30 percent of the symbol. Medium is used when it is
+// empty.
+type ErrorCorrection string
+
+func ParseErrorCorrection(name string) (ErrorCorrection, error) {
+	switch ErrorCorrection(name) {
+	case "":
+		return "M", nil
+	case "L", "M", "Q", "H":
+		return ErrorCorrection(name), nil
+	}
+	return "", fmt.Errorf("error correction must be L, M, Q or H, got %q", name)
+}
+
+func (level ErrorCorrection) recoveryLevel() qrcode.RecoveryLevel {
+	switch level {
+	case "L":
+		return qrcode.Low
+	case "Q":
+		return qrcode.High
+	case "H":
+		return qrcode.Highest
+	}
+	return qrcode.Medium
 }
 
 func (code *SimpleQRCode) Generate() ([]byte, error) {
-	qrCode, err := qrcode.Encode(code.Content, qrcode.Medium, code.Size)
+	if code.GS1 || code.Symbology.isMatrixOnly() {
+		return code.generateFromModules()
+	}
+	qrCode, err := qrcode.New(code.Content, code.ErrorCorrection.recoveryLevel())
 	if err != nil {
 		return nil, fmt.Errorf("could not generate a QR code: %v", err)
 	}
-	return qrCode, nil
+	qrCode.ForegroundColor, qrCode.BackgroundColor = code.palette()[1], code.palette()[0]
+	return qrCode.PNG(code.Size)
+}
+
+// palette holds the background at index 0 and the modules at index 1.
+func (code *SimpleQRCode) palette() color.Palette {
+	palette := color.Palette{color.White, color.Black}
+	if code.Background != nil {
+		palette[0] = code.Background
+	}
+	if code.Foreground != nil {
+		palette[1] = code.Foreground
+	}
+	return palette
 }
 
 func (code *SimpleQRCode) GenerateWithWatermark(watermark []byte) ([]byte, error) {
@@ -58,10 +108,27 @@ func (code *SimpleQRCode) AddWatermark(qrCode []byte, watermarkData []byte) ([]b
 		return nil, fmt.Errorf("could not decode QR code: %v", err)
 	}
 
-	watermarkWidth := uint(float64(qrCodeData.Bounds().Dx()) * 0.25)
+	m, err := code.WatermarkImage(qrCodeData, watermarkData)
+	if err != nil {
+		return nil, err
+	}
+
+	watermarkedQRCode := bytes.NewBuffer(nil)
+	png.Encode(watermarkedQRCode, m)
+
+	return watermarkedQRCode.Bytes(), nil
+}
+
+// WatermarkScale is the width of a watermark relative to the code's.
+const WatermarkScale = 0.25
+
+// WatermarkImage places the watermark in the centre of a rendered code, at a
+// quarter of its width.
+func (code *SimpleQRCode) WatermarkImage(qrCodeData image.Image, watermarkData []byte) (*image.RGBA, error) {
+	watermarkWidth := uint(float64(qrCodeData.Bounds().Dx()) * WatermarkScale)
 	watermark, err := resizeWatermark(bytes.NewBuffer(watermarkData), watermarkWidth)
 	if err != nil {
-		return nil, fmt.Errorf("Could not resize the watermark image.", err)
+		return nil, fmt.Errorf("could not resize the watermark image: %v", err)
 	}
 
 	watermarkImage, err := png.Decode(bytes.NewBuffer(watermark))
@@ -87,8 +154,21 @@ func (code *SimpleQRCode) AddWatermark(qrCode []byte, watermarkData []byte) ([]b
 		draw.Over,
 	)
 
-	watermarkedQRCode := bytes.NewBuffer(nil)
-	png.Encode(watermarkedQRCode, m)
+	return m, nil
+}
 
-	return watermarkedQRCode.Bytes(), nil
+func (code *SimpleQRCode) Modules() ([][]bool, error) {
+	if code.Symbology.isMatrixOnly() {
+		return code.symbologyModules()
+	}
+	if code.GS1 {
+		return code.gs1Modules()
+	}
+	qrCode, err := qrcode.New(code.Content, code.ErrorCorrection.recoveryLevel())
+	if err != nil {
+		return nil, fmt.Errorf("could not generate a QR code: %v", err)
+	}
+	qrCode.DisableBorder = true
+
+	return qrCode.Bitmap(), nil
 }
@@ -0,0 +1,162 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/aztec"
+	"github.com/boombuler/barcode/pdf417"
+)
+
+type Symbology string
+
+const (
+	QR     Symbology = "qr"
+	Aztec  Symbology = "aztec"
+	PDF417 Symbology = "pdf417"
+)
+
+func ParseSymbology(name string) (Symbology, error) {
+	switch Symbology(name) {
+	case "", QR:
+		return QR, nil
+	case Aztec, PDF417:
+		return Symbology(name), nil
+	}
+	return "", fmt.Errorf("symbology must be qr, aztec or pdf417, got %q", name)
+}
+
+func (symbology Symbology) isMatrixOnly() bool {
+	return symbology == Aztec || symbology == PDF417
+}
+
+// Quiet zones in modules: four for QR, two for PDF417 and none for Aztec,
+// whose finder pattern does not need one.
+func (symbology Symbology) QuietZone() int {
+	switch symbology {
+	case Aztec:
+		return 0
+	case PDF417:
+		return 2
+	}
+	return 4
+}
+
+func (code *SimpleQRCode) symbologyModules() ([][]bool, error) {
+	var (
+		symbol    barcode.Barcode
+		err       error
+		rowHeight = 1
+	)
+	switch code.Symbology {
+	case Aztec:
+		symbol, err = aztec.Encode([]byte(code.Content), aztec.DEFAULT_EC_PERCENT, aztec.DEFAULT_LAYERS)
+	case PDF417:
+		// Rows are drawn two pixels high; PDF417 needs them at least three
+		// modules high.
+		symbol, err = pdf417.Encode(code.Content, 4)
+		rowHeight = 3
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not generate %s symbol: %v", code.Symbology, err)
+	}
+
+	bounds := symbol.Bounds()
+	step := 1
+	if code.Symbology == PDF417 {
+		step = 2
+	}
+	var modules [][]bool
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		row := make([]bool, bounds.Dx())
+		for x := range row {
+			r, _, _, _ := symbol.At(bounds.Min.X+x, y).RGBA()
+			row[x] = r < 0x8000
+		}
+		for i := 0; i < rowHeight; i++ {
+			modules = append(modules, row)
+		}
+	}
+	return modules, nil
+}
+
+func (code *SimpleQRCode) generateFromModules() ([]byte, error) {
+	modules, err := code.Modules()
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := bytes.NewBuffer(nil)
+	if err := png.Encode(symbol, renderModules(modules, code.Size, code.Symbology.QuietZone(), code.palette())); err != nil {
+		return nil, fmt.Errorf("could not encode %s symbol: %v", code.Symbology, err)
+	}
+	return symbol.Bytes(), nil
+}
+
+// renderModules draws a module matrix inside a quiet zone, scaled to the
+// given width the same way go-qrcode scales its images. Non-square symbols
+// keep their aspect ratio.
+func renderModules(modules [][]bool, size, quietZone int, palette color.Palette) image.Image {
+	realWidth := len(modules[0]) + 2*quietZone
+	realHeight := len(modules) + 2*quietZone
+	if size < 0 {
+		size = -size * realWidth
+	}
+	if size < realWidth {
+		size = realWidth
+	}
+	height := size * realHeight / realWidth
+
+	img := image.NewPaletted(image.Rect(0, 0, size, height), palette)
+	modulesPerPixel := float64(realWidth) / float64(size)
+	for y := 0; y < height; y++ {
+		row := int(float64(y)*modulesPerPixel) - quietZone
+		for x := 0; x < size; x++ {
+			col := int(float64(x)*modulesPerPixel) - quietZone
+			if row >= 0 && row < len(modules) && col >= 0 && col < len(modules[row]) && modules[row][col] {
+				img.Pix[img.PixOffset(x, y)] = 1
+			}
+		}
+	}
+	return img
+}
+
+// Bitmap renders the code with every module the same whole number of
+// pixels, as many as fit in the requested size, or -Size pixels when Size
+// is negative. Unlike Generate, nothing is resampled or padded, so the image
+// is exactly the symbol and its quiet zone.
+func (code *SimpleQRCode) Bitmap() (*image.Paletted, error) {
+	modules, err := code.Modules()
+	if err != nil {
+		return nil, err
+	}
+
+	quietZone := code.Symbology.QuietZone()
+	width, height := len(modules[0])+2*quietZone, len(modules)+2*quietZone
+	scale := max(1, code.Size/width)
+	if code.Size < 0 {
+		scale = -code.Size
+	}
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, width*scale, height*scale),
+		color.Palette{color.White, color.Black},
+	)
+	for y := 0; y < height*scale; y++ {
+		row := y/scale - quietZone
+		if row < 0 || row >= len(modules) {
+			continue
+		}
+		for x := 0; x < width*scale; x++ {
+			col := x/scale - quietZone
+			if col >= 0 && col < len(modules[row]) && modules[row][col] {
+				img.Pix[img.PixOffset(x, y)] = 1
+			}
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,43 @@
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	zxingqr "github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// ErrNotVerifiable is returned by Verify for symbologies it cannot read.
+var ErrNotVerifiable = errors.New("PDF417 symbols cannot be read back")
+
+// Verify reads a rendered code back and checks that it decodes to its
+// content, for renderings that change the symbol, such as a watermark
+// reduced to black and white.
+func (code *SimpleQRCode) Verify(img image.Image) error {
+	var reader gozxing.Reader
+	switch code.Symbology {
+	case Aztec:
+		reader = aztec.NewAztecReader()
+	case PDF417:
+		return ErrNotVerifiable
+	default:
+		reader = zxingqr.NewQRCodeReader()
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return fmt.Errorf("could not read the image: %v", err)
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	result, err := reader.Decode(bitmap, hints)
+	if err != nil {
+		return fmt.Errorf("the code could not be read: %v", err)
+	}
+	if result.GetText() != code.Content {
+		return fmt.Errorf("the code reads as %q instead of its content", result.GetText())
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package scan
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"qr-code-generator/dataset"
+)
+
+const (
+	// MaxArchiveImages bounds the images read from one archive.
+	MaxArchiveImages = 2000
+	// maxImageBytes bounds the size of each image once unzipped.
+	maxImageBytes = 32 << 20
+)
+
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true}
+
+// Match is the outcome of comparing an image's codes with a manifest.
+type Match string
+
+const (
+	Matched    Match = "match"
+	Mismatched Match = "mismatch"
+	// Missing marks a file the manifest lists but the archive lacks.
+	Missing Match = "missing"
+)
+
+// Entry reports on one image of an archive. Content, symbology and quality
+// are those of the first code, from the top, when an image has several,
+// and quality is left out when there is none.
+type Entry struct {
+	File      string `json:"file"`
+	Codes     int    `json:"codes"`
+	Content   string `json:"content"`
+	Symbology string `json:"symbology"`
+	*Quality
+	Expected *string `json:"expected,omitempty"`
+	Match    Match   `json:"match,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	// contents are those of every code, any of which may match.
+	contents []string
+}
+
+// Manifest reads the expected content of each image from a table with file
+// and content columns. Files are matched by their path in the archive or,
+// failing that, by their name alone.
+func Manifest(table *dataset.Table) (map[string]string, error) {
+	hasFile, hasContent := false, false
+	for _, column := range table.Columns {
+		hasFile = hasFile || column == "file"
+		hasContent = hasContent || column == "content"
+	}
+	if !hasFile || !hasContent {
+		return nil, fmt.Errorf("the manifest needs file and content columns")
+	}
+	expected := map[string]string{}
+	for i, row := range table.Rows {
+		file := strings.TrimSpace(row["file"])
+		if file == "" {
+			return nil, fmt.Errorf("row %d of the manifest has no file", i+1)
+		}
+		if _, ok := expected[file]; ok {
+			return nil, fmt.Errorf("the manifest lists %q more than once", file)
+		}
+		expected[file] = row["content"]
+	}
+	return expected, nil
+}
+
+// Archive decodes every image in a ZIP archive, several at a time, and
+// compares them with the expected content when a manifest is given. Entries
+// are in the order of the archive, followed by any files the manifest lists
+// that it lacks.
+func Archive(data []byte, expected map[string]string) ([]Entry, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("could not open the ZIP archive: %v", err)
+	}
+	var files []*zip.File
+	for _, file := range archive.File {
+		name := file.Name
+		if file.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+		if imageExtensions[strings.ToLower(path.Ext(name))] {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the archive has no PNG, JPEG or GIF images")
+	}
+	if len(files) > MaxArchiveImages {
+		return nil, fmt.Errorf("the archive has %d images, at most %d can be read", len(files), MaxArchiveImages)
+	}
+
+	entries := make([]Entry, len(files))
+	jobs := make(chan int)
+	var workers sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(files)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range jobs {
+				entries[i] = readEntry(files[i])
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	workers.Wait()
+
+	if expected != nil {
+		listed := map[string]bool{}
+		for i := range entries {
+			entry := &entries[i]
+			want, ok := expected[entry.File]
+			if ok {
+				listed[entry.File] = true
+			} else if want, ok = expected[path.Base(entry.File)]; ok {
+				listed[path.Base(entry.File)] = true
+			} else {
+				continue
+			}
+			entry.Expected = &want
+			entry.Match = Mismatched
+			for _, content := range entry.contents {
+				if content == want {
+					entry.Match = Matched
+				}
+			}
+		}
+		var missing []string
+		for file := range expected {
+			if !listed[file] {
+				missing = append(missing, file)
+			}
+		}
+		sort.Strings(missing)
+		for _, file := range missing {
+			want := expected[file]
+			entries = append(entries, Entry{File: file, Expected: &want, Match: Missing, Error: "the archive has no such image"})
+		}
+	}
+	return entries, nil
+}
+
+func readEntry(file *zip.File) Entry {
+	entry := Entry{File: file.Name}
+	reader, err := file.Open()
+	if err != nil {
+		entry.Error = fmt.Sprintf("could not unzip the image: %v", err)
+		return entry
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxImageBytes+1))
+	if err != nil {
+		entry.Error = fmt.Sprintf("could not unzip the image: %v", err)
+		return entry
+	}
+	if len(data) > maxImageBytes {
+		entry.Error = fmt.Sprintf("the image is larger than %d MB", maxImageBytes>>20)
+		return entry
+	}
+	img, err := decodeImage(data)
+	if err != nil {
+		entry.Error = fmt.Sprintf("could not decode the image: %v", err)
+		return entry
+	}
+
+	results := decode(img)
+	entry.Codes = len(results)
+	if len(results) == 0 {
+		entry.Error = "no code was found"
+		return entry
+	}
+	for _, result := range results {
+		entry.contents = append(entry.contents, result.GetText())
+	}
+	code := newCode(results[0])
+	entry.Content, entry.Symbology = code.Content, string(code.Symbology)
+	quality := grade(img, results[0])
+	entry.Quality = &quality
+	return entry
+}
+
+// WriteCSV writes a report with a header row, in the order of the JSON
+// fields.
+func WriteCSV(writer io.Writer, entries []Entry) error {
+	out := csv.NewWriter(writer)
+	out.Write([]string{"file", "codes", "content", "symbology", "grade", "contrast", "unused_error_correction", "expected", "match", "error"})
+	for _, entry := range entries {
+		var grade, contrast, unused, expected string
+		if entry.Quality != nil {
+			grade = entry.Grade
+			contrast = strconv.FormatFloat(entry.Contrast, 'f', 2, 64)
+			if entry.UnusedErrorCorrection != nil {
+				unused = strconv.FormatFloat(*entry.UnusedErrorCorrection, 'f', 2, 64)
+			}
+		}
+		if entry.Expected != nil {
+			expected = *entry.Expected
+		}
+		out.Write([]string{
+			entry.File, strconv.Itoa(entry.Codes), entry.Content, entry.Symbology, grade,
+			contrast, unused, expected, string(entry.Match), entry.Error,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,177 @@
+package scan
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/common/reedsolomon"
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/makiuchi-d/gozxing/qrcode/detector"
+)
+
+// Quality is an approximate print quality grade in the manner of ISO/IEC
+// 15415, from A down to D and F, taken from two of its parameters: symbol
+// contrast, the spread between the lightest and darkest parts of the
+// symbol, and unused error correction, the share of the error correction
+// capacity a read leaves to spare. The grade is the lower of the two. It
+// is meant for telling good prints from failing ones across a batch, not
+// as a substitute for a calibrated verifier.
+type Quality struct {
+	Grade    string  `json:"grade"`
+	Contrast float64 `json:"contrast"`
+	// UnusedErrorCorrection is left out for Aztec codes, whose reader does
+	// not expose the codewords.
+	UnusedErrorCorrection *float64 `json:"unused_error_correction,omitempty"`
+}
+
+var (
+	contrastGrades        = [4]float64{0.70, 0.55, 0.40, 0.20}
+	errorCorrectionGrades = [4]float64{0.62, 0.50, 0.37, 0.25}
+)
+
+func letter(value float64, thresholds [4]float64) string {
+	for i, threshold := range thresholds {
+		if value >= threshold {
+			return string("ABCD"[i])
+		}
+	}
+	return "F"
+}
+
+// grade measures a code that was read from an image.
+func grade(img image.Image, result *gozxing.Result) Quality {
+	quality := Quality{Contrast: contrast(img, result)}
+	quality.Grade = letter(quality.Contrast, contrastGrades)
+	if result.GetBarcodeFormat() != gozxing.BarcodeFormat_QR_CODE {
+		return quality
+	}
+	if unused, ok := unusedErrorCorrection(img, result); ok {
+		quality.UnusedErrorCorrection = &unused
+		// Letters sort from best to worst, so the later is the lower grade.
+		quality.Grade = max(quality.Grade, letter(unused, errorCorrectionGrades))
+	}
+	return quality
+}
+
+// contrast is the difference between the light and dark reflectance of
+// the symbol, taken as the 95th and 5th percentiles of the grey levels
+// around the points the reader found, so that a few stray pixels do not
+// count.
+func contrast(img image.Image, result *gozxing.Result) float64 {
+	points := result.GetResultPoints()
+	if len(points) == 0 {
+		return 0
+	}
+	left, top, right, bottom := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, point := range points {
+		left, right = min(left, point.GetX()), max(right, point.GetX())
+		top, bottom = min(top, point.GetY()), max(bottom, point.GetY())
+	}
+	// The points are the centres of finder patterns, a few modules in from
+	// the edges of the symbol.
+	margin := max(right-left, bottom-top) / 6
+	region := image.Rect(int(left-margin), int(top-margin), int(right+margin)+1, int(bottom+margin)+1).Intersect(img.Bounds())
+	if region.Empty() {
+		return 0
+	}
+	levels := make([]int, 0, region.Dx()*region.Dy())
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		for x := region.Min.X; x < region.Max.X; x++ {
+			levels = append(levels, int(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y))
+		}
+	}
+	sort.Ints(levels)
+	dark, light := levels[len(levels)*5/100], levels[len(levels)*95/100]
+	return float64(light-dark) / 255
+}
+
+// unusedErrorCorrection samples the QR code's modules as the reader does,
+// starting from the finder patterns it found so that other codes in the
+// image do not count, and counts the codewords Reed-Solomon decoding had to
+// correct in each block. It is the least that any block has to spare.
+func unusedErrorCorrection(img image.Image, result *gozxing.Result) (float64, bool) {
+	points := result.GetResultPoints()
+	if len(points) < 3 {
+		return 0, false
+	}
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return 0, false
+	}
+	matrix, err := bitmap.GetBlackMatrix()
+	if err != nil {
+		return 0, false
+	}
+	// The points are the bottom left, top left and top right finder
+	// patterns. The module size is measured again from the image.
+	var finders [3]*detector.FinderPattern
+	for i := range finders {
+		finders[i] = detector.NewFinderPattern1(points[i].GetX(), points[i].GetY(), 1)
+	}
+	detected, err := detector.NewDetector(matrix).ProcessFinderPatternInfo(detector.NewFinderPatternInfo(finders[0], finders[1], finders[2]))
+	if err != nil {
+		return 0, false
+	}
+	parser, err := decoder.NewBitMatrixParser(detected.GetBits())
+	if err != nil {
+		return 0, false
+	}
+	if unused, ok := correctedBlocks(parser); ok {
+		return unused, true
+	}
+	// Mirrored codes are read again the other way round.
+	parser.Remask()
+	parser.SetMirror(true)
+	if _, err := parser.ReadVersion(); err != nil {
+		return 0, false
+	}
+	if _, err := parser.ReadFormatInformation(); err != nil {
+		return 0, false
+	}
+	parser.Mirror()
+	return correctedBlocks(parser)
+}
+
+func correctedBlocks(parser *decoder.BitMatrixParser) (float64, bool) {
+	version, err := parser.ReadVersion()
+	if err != nil {
+		return 0, false
+	}
+	format, err := parser.ReadFormatInformation()
+	if err != nil {
+		return 0, false
+	}
+	codewords, err := parser.ReadCodewords()
+	if err != nil {
+		return 0, false
+	}
+	blocks, err := decoder.DataBlock_GetDataBlocks(codewords, version, format.GetErrorCorrectionLevel())
+	if err != nil {
+		return 0, false
+	}
+	rs := reedsolomon.NewReedSolomonDecoder(reedsolomon.GenericGF_QR_CODE_FIELD_256)
+	unused := 1.0
+	for _, block := range blocks {
+		read := block.GetCodewords()
+		corrected := make([]int, len(read))
+		for i, codeword := range read {
+			corrected[i] = int(codeword)
+		}
+		checkCodewords := len(read) - block.GetNumDataCodewords()
+		if err := rs.Decode(corrected, checkCodewords); err != nil {
+			return 0, false
+		}
+		errors := 0
+		for i, codeword := range read {
+			if corrected[i] != int(codeword) {
+				errors++
+			}
+		}
+		// Each error uses two check codewords.
+		unused = min(unused, 1-float64(2*errors)/float64(checkCodewords))
+	}
+	return max(0, unused), true
+}
@@ -0,0 +1,86 @@
+package scan
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"qr-code-generator/bcbp"
+	"qr-code-generator/envelope"
+	"qr-code-generator/gs1"
+	"qr-code-generator/onboarding"
+	"qr-code-generator/payment"
+	"qr-code-generator/swissqr"
+)
+
+// Envelope is the data of a compressed envelope. Data is the decompressed
+// text, or JSON when it is JSON.
+type Envelope struct {
+	Algorithm string      `json:"algorithm"`
+	Data      interface{} `json:"data"`
+}
+
+// Classify recognises the type of a payload and parses it when this service
+// knows the format. The error explains why a payload that starts like a
+// known type could not be parsed; the type is still returned.
+func Classify(content string) (string, interface{}, error) {
+	lower := strings.ToLower(content)
+	switch {
+	case strings.HasPrefix(content, "SPC\n"), strings.HasPrefix(content, "SPC\r\n"):
+		bill, err := swissqr.Parse(content)
+		return parsed("swiss_qr_bill", bill, err)
+	case strings.HasPrefix(content, "BCD\n"), strings.HasPrefix(content, "BCD\r\n"):
+		epc, err := payment.ParseEPC(content)
+		return parsed("epc", epc, err)
+	case strings.HasPrefix(content, "MT:"):
+		payload, err := onboarding.ParseMatter(content)
+		return parsed("matter", payload, err)
+	case strings.HasPrefix(content, "DPP:"):
+		uri, err := onboarding.ParseDPP(content)
+		return parsed("dpp", uri, err)
+	case strings.HasPrefix(content, "WIFI:"):
+		return "wifi", nil, nil
+	case strings.HasPrefix(content, "BEGIN:VCARD"):
+		return "vcard", nil, nil
+	case strings.HasPrefix(lower, "upi://"):
+		return "upi", nil, nil
+	case strings.HasPrefix(lower, "bitcoin:"):
+		return "bitcoin", nil, nil
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		if elements, err := gs1.ParseDigitalLink(content); err == nil {
+			return "gs1_digital_link", elements, nil
+		}
+		return "url", nil, nil
+	}
+	if len(content) >= 60 && content[0] == 'M' && content[1] >= '1' && content[1] <= '4' {
+		if pass, err := bcbp.Parse(content); err == nil {
+			return "boarding_pass", pass, nil
+		}
+	}
+	if data, algorithm, err := envelope.Decode(content); err == nil {
+		return "envelope", decodedEnvelope(data, algorithm), nil
+	}
+	return "text", nil, nil
+}
+
+// parsed leaves out the result of a parser that failed, which would
+// otherwise show as null.
+func parsed(kind string, value interface{}, err error) (string, interface{}, error) {
+	if err != nil {
+		return kind, nil, err
+	}
+	return kind, value, nil
+}
+
+func decodedEnvelope(data []byte, algorithm envelope.Algorithm) Envelope {
+	decoded := Envelope{Algorithm: algorithm.String()}
+	switch {
+	case json.Valid(data):
+		decoded.Data = json.RawMessage(data)
+	case utf8.Valid(data):
+		decoded.Data = string(data)
+	default:
+		decoded.Data = data
+	}
+	return decoded
+}
@@ -0,0 +1,193 @@
+// Package scan reads codes back from images and PDF documents and
+// recognises what their payloads are, such as payment slips arriving on
+// inbound invoices.
+package scan
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"sort"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	multiqr "github.com/makiuchi-d/gozxing/multi/qrcode"
+	zxingqr "github.com/makiuchi-d/gozxing/qrcode"
+	"golang.org/x/image/draw"
+
+	"qr-code-generator/pdf"
+	"qr-code-generator/qrcode"
+)
+
+const (
+	// dpi is the resolution pages are rendered at, which gives a code of
+	// 15mm with 33 modules three or four pixels a module.
+	dpi = 200
+	// MaxPages bounds the pages of a document that are read.
+	MaxPages = 100
+	// minImageSize is the size images are enlarged to before decoding, so
+	// that codes embedded a pixel a module can be read.
+	minImageSize = 300
+	// maxImagePixels bounds the size of images once decoded, which a small
+	// compressed file can make very large.
+	maxImagePixels = 1 << 25
+)
+
+// Code is a code that was read, with its page for documents.
+type Code struct {
+	Page      int              `json:"page,omitempty"`
+	Symbology qrcode.Symbology `json:"symbology"`
+	Content   string           `json:"content"`
+	Type      string           `json:"type"`
+	Payload   interface{}      `json:"payload,omitempty"`
+	// Error explains why a payload that looks like a known type could not
+	// be parsed.
+	Error string `json:"error,omitempty"`
+}
+
+// File reads the codes in a PDF document or a PNG, JPEG or GIF image.
+func File(data []byte) ([]Code, error) {
+	if bytes.HasPrefix(bytes.TrimLeft(data[:min(len(data), 1024)], "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return PDF(data)
+	}
+	img, err := decodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("expected a PDF, PNG, JPEG or GIF file: %v", err)
+	}
+	return Image(img), nil
+}
+
+// decodeImage decodes a PNG, JPEG or GIF image after checking its size.
+func decodeImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("the image is %dx%d pixels, at most %d megapixels can be read", config.Width, config.Height, maxImagePixels>>20)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Image reads every code in an image, from the top down.
+func Image(img image.Image) []Code {
+	var codes []Code
+	for _, result := range decode(img) {
+		codes = append(codes, newCode(result))
+	}
+	return codes
+}
+
+// PDF reads every code in a document, page by page. Each page is rendered,
+// which finds codes drawn as vectors, and each image on it is also read at
+// its own resolution, which finds codes the rendering shrinks too much.
+func PDF(data []byte) ([]Code, error) {
+	reader, err := pdf.Open(data)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := reader.Pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) > MaxPages {
+		return nil, fmt.Errorf("the document has %d pages, at most %d can be read", len(pages), MaxPages)
+	}
+
+	var codes []Code
+	for i, page := range pages {
+		rendered, err := reader.Render(page, dpi)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", i+1, err)
+		}
+		results := decode(rendered)
+		images, err := reader.Images(page)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", i+1, err)
+		}
+		for _, img := range images {
+			results = append(results, decode(enlarge(img))...)
+		}
+
+		seen := map[string]bool{}
+		for _, result := range results {
+			code := newCode(result)
+			key := string(code.Symbology) + "\x00" + code.Content
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			code.Page = i + 1
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+func newCode(result *gozxing.Result) Code {
+	code := Code{Symbology: qrcode.QR, Content: result.GetText()}
+	if result.GetBarcodeFormat() == gozxing.BarcodeFormat_AZTEC {
+		code.Symbology = qrcode.Aztec
+	}
+	var err error
+	code.Type, code.Payload, err = Classify(code.Content)
+	if err != nil {
+		code.Error = err.Error()
+	}
+	return code
+}
+
+// decode finds every QR code in an image, or failing that a single QR or
+// Aztec code, which the multiple reader sometimes misses.
+func decode(img image.Image) []*gozxing.Result {
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	results, _ := multiqr.NewQRCodeMultiReader().DecodeMultiple(bitmap, hints)
+	if len(results) == 0 {
+		for _, reader := range []gozxing.Reader{zxingqr.NewQRCodeReader(), aztec.NewAztecReader()} {
+			if result, err := reader.Decode(bitmap, hints); err == nil {
+				results = append(results, result)
+				break
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return top(results[i]) < top(results[j])
+	})
+	return results
+}
+
+func top(result *gozxing.Result) float64 {
+	points := result.GetResultPoints()
+	if len(points) == 0 {
+		return 0
+	}
+	y := points[0].GetY()
+	for _, point := range points[1:] {
+		y = min(y, point.GetY())
+	}
+	return y
+}
+
+// enlarge scales small images up by a whole factor, keeping modules sharp,
+// and surrounds them with a white margin, since embedded images are often
+// cropped to the symbol with no quiet zone.
+func enlarge(img image.Image) image.Image {
+	bounds := img.Bounds()
+	factor := max(1, minImageSize/max(1, min(bounds.Dx(), bounds.Dy())))
+	width, height := bounds.Dx()*factor, bounds.Dy()*factor
+	margin := max(width, height) / 8
+	enlarged := image.NewGray(image.Rect(0, 0, width+2*margin, height+2*margin))
+	for i := range enlarged.Pix {
+		enlarged.Pix[i] = 255
+	}
+	draw.NearestNeighbor.Scale(enlarged, image.Rect(margin, margin, margin+width, margin+height), img, bounds, draw.Over, nil)
+	return enlarged
+}
@@ -0,0 +1,160 @@
+package sizing
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"qr-code-generator/qrcode"
+)
+
+// Method is a way of printing a code: the smallest module it reproduces
+// reliably in millimetres, the fewest printer dots a module may span, and
+// the error correction the medium calls for, since some wear or distort.
+type Method struct {
+	Name            string
+	MinimumModule   float64
+	MinimumDots     int
+	ErrorCorrection qrcode.ErrorCorrection
+}
+
+// Methods are rules of thumb rather than guarantees; ink, paper and the
+// printer's condition all move the limits.
+var Methods = map[string]Method{
+	"offset":       {"offset", 0.25, 1, "L"},
+	"laser":        {"laser", 0.33, 2, "L"},
+	"inkjet":       {"inkjet", 0.4, 2, "M"},
+	"thermal":      {"thermal", 0.375, 3, "M"},
+	"flexo":        {"flexo", 0.5, 2, "M"},
+	"screen":       {"screen", 0.6, 1, "M"},
+	"engraving":    {"engraving", 0.3, 1, "M"},
+	"large-format": {"large-format", 1, 2, "Q"},
+	"embroidery":   {"embroidery", 1, 1, "Q"},
+}
+
+func ParseMethod(name string) (Method, error) {
+	if name == "" {
+		return Methods["laser"], nil
+	}
+	if method, ok := Methods[name]; ok {
+		return method, nil
+	}
+	return Method{}, fmt.Errorf("printing method must be offset, laser, inkjet, thermal, flexo, screen, engraving, large-format or embroidery, got %q", name)
+}
+
+// The usual rule of thumb is a code a tenth as wide as the distance it is
+// scanned from. It holds for a version 3 QR code of 29 modules, so stated
+// per module a module is 1/290 of the distance.
+const distanceRatio = 290
+
+// Request describes a code and, optionally, how it will be scanned and
+// printed. Distance is in metres and Size, the printed width with the quiet
+// zone, in millimetres; either may be zero when it is not known. DPI is the
+// printer's resolution, also zero when not known.
+type Request struct {
+	Modules         int
+	QuietZone       int
+	Method          Method
+	ErrorCorrection qrcode.ErrorCorrection
+	Distance        float64
+	Size            float64
+	DPI             float64
+}
+
+// Advice gives the smallest module and code width, with the quiet zone, that
+// will scan, and for a requested size the module it gives and how far away
+// it can be read from. Lengths are in millimetres.
+type Advice struct {
+	Modules           int      `json:"modules"`
+	QuietZone         int      `json:"quiet_zone"`
+	MinimumModule     float64  `json:"minimum_module_mm"`
+	MinimumSize       float64  `json:"minimum_size_mm"`
+	MinimumModuleDots int      `json:"minimum_module_dots,omitempty"`
+	Module            float64  `json:"module_mm,omitempty"`
+	DotsPerModule     float64  `json:"dots_per_module,omitempty"`
+	MaximumDistance   float64  `json:"maximum_distance_m,omitempty"`
+	Warnings          []string `json:"warnings"`
+}
+
+func (request *Request) Validate() error {
+	if request.Modules <= 0 {
+		return fmt.Errorf("the code has no modules")
+	}
+	if request.Distance < 0 || request.Size < 0 || request.DPI < 0 {
+		return fmt.Errorf("distance, size and DPI must not be negative")
+	}
+	return nil
+}
+
+func Advise(request Request) (*Advice, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	width := float64(request.Modules + 2*request.QuietZone)
+	advice := &Advice{
+		Modules:       request.Modules,
+		QuietZone:     request.QuietZone,
+		MinimumModule: max(request.Method.MinimumModule, request.Distance*1000/distanceRatio),
+		Warnings:      []string{},
+	}
+
+	// At a known resolution the recommendation is rounded up to whole dots,
+	// so that every module prints the same width.
+	if request.DPI > 0 {
+		dot := 25.4 / request.DPI
+		advice.MinimumModuleDots = max(request.Method.MinimumDots, int(math.Ceil(advice.MinimumModule/dot-1e-9)))
+		advice.MinimumModule = float64(advice.MinimumModuleDots) * dot
+	}
+	advice.MinimumModule = round(advice.MinimumModule, 3)
+	advice.MinimumSize = round(advice.MinimumModule*width, 1)
+
+	if level := request.ErrorCorrection; level != "" && !atLeast(level, request.Method.ErrorCorrection) {
+		advice.warn("%s printing wears or distorts codes; use error correction %s or higher instead of %s",
+			request.Method.Name, request.Method.ErrorCorrection, level)
+	}
+	if request.Size == 0 {
+		return advice, nil
+	}
+
+	module := request.Size / width
+	advice.Module = round(module, 3)
+	advice.MaximumDistance = round(module*distanceRatio/1000, 2)
+	if request.Distance > 0 && module*distanceRatio/1000 < request.Distance {
+		advice.warn("at %.1fmm the modules are %.2fmm, too small to scan from %gm; make the code at least %gmm wide",
+			request.Size, module, request.Distance, advice.MinimumSize)
+	}
+	if module < request.Method.MinimumModule {
+		advice.warn("%s printing does not reproduce modules under %gmm reliably, and these are %.2fmm",
+			request.Method.Name, request.Method.MinimumModule, module)
+	}
+
+	if request.DPI > 0 {
+		dots := module * request.DPI / 25.4
+		advice.DotsPerModule = round(dots, 2)
+		if dots < float64(request.Method.MinimumDots) {
+			advice.warn("modules are %.1f dots at %g DPI, and %s printing needs at least %d",
+				dots, request.DPI, request.Method.Name, request.Method.MinimumDots)
+		} else if fraction := dots - math.Floor(dots); dots < 8 && fraction > 0.1 && fraction < 0.9 {
+			// A module spanning a fraction of a dot is rounded one way or the
+			// other, so neighbouring modules differ by a whole dot; with few
+			// dots per module that throws off the code's proportions.
+			whole := math.Round(dots)
+			advice.warn("modules are %.2f dots at %g DPI, so they print unevenly as %g or %g dots; %gmm gives exactly %g dots per module",
+				dots, request.DPI, math.Floor(dots), math.Ceil(dots), round(whole*25.4/request.DPI*width, 2), whole)
+		}
+	}
+	return advice, nil
+}
+
+func (advice *Advice) warn(format string, args ...interface{}) {
+	advice.Warnings = append(advice.Warnings, fmt.Sprintf(format, args...))
+}
+
+func atLeast(level, minimum qrcode.ErrorCorrection) bool {
+	return strings.Index("LMQH", string(level)) >= strings.Index("LMQH", string(minimum))
+}
+
+func round(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,206 @@
+package stamp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"qr-code-generator/pdf"
+	"qr-code-generator/qrcode"
+)
+
+type Corner string
+
+const (
+	TopLeft     Corner = "top-left"
+	TopRight    Corner = "top-right"
+	BottomLeft  Corner = "bottom-left"
+	BottomRight Corner = "bottom-right"
+)
+
+// Placement positions the code relative to a corner of the visible page, as
+// it is displayed after any rotation. Lengths are in millimetres; the size
+// includes the quiet zone.
+type Placement struct {
+	Corner  Corner
+	OffsetX float64
+	OffsetY float64
+	Size    float64
+}
+
+// Pages selects which pages are stamped.
+type Pages string
+
+const (
+	AllPages  Pages = "all"
+	FirstPage Pages = "first"
+	LastPage  Pages = "last"
+)
+
+// Options describe what is stamped. Content is a template: {page} and
+// {pages} make a code per page, while {sha256}, the digest of the original
+// document, and {filename} are the same on every page.
+type Options struct {
+	Content   string
+	Filename  string
+	Pages     Pages
+	Placement Placement
+}
+
+const quietZone = 4
+
+func (options *Options) Validate() error {
+	if options.Content == "" {
+		return fmt.Errorf("content is required")
+	}
+	switch options.Placement.Corner {
+	case TopLeft, TopRight, BottomLeft, BottomRight:
+	default:
+		return fmt.Errorf("corner must be top-left, top-right, bottom-left or bottom-right, got %q", options.Placement.Corner)
+	}
+	switch options.Pages {
+	case AllPages, FirstPage, LastPage:
+	default:
+		return fmt.Errorf("pages must be all, first or last, got %q", options.Pages)
+	}
+	if options.Placement.Size <= 0 {
+		return fmt.Errorf("size must be positive")
+	}
+	if options.Placement.OffsetX < 0 || options.Placement.OffsetY < 0 {
+		return fmt.Errorf("offsets must not be negative")
+	}
+	return nil
+}
+
+// Stamp draws a vector QR code on the selected pages of document and returns
+// the document with an incremental update appended, so the original content
+// is kept byte for byte.
+func Stamp(document []byte, options Options) ([]byte, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	reader, err := pdf.Open(document)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := reader.Pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("document has no pages")
+	}
+
+	digest := sha256.Sum256(document)
+	update := reader.NewUpdate()
+	// Existing content may leave the graphics state changed, so it is
+	// wrapped in q and Q before the code is drawn.
+	save := update.Add(pdf.CompressedStream(nil, []byte("q\n")))
+
+	for i, page := range pages {
+		if (options.Pages == FirstPage && i > 0) || (options.Pages == LastPage && i < len(pages)-1) {
+			continue
+		}
+
+		content := strings.NewReplacer(
+			"{page}", strconv.Itoa(i+1),
+			"{pages}", strconv.Itoa(len(pages)),
+			"{sha256}", hex.EncodeToString(digest[:]),
+			"{filename}", options.Filename,
+		).Replace(options.Content)
+
+		operators, err := drawCode(page, content, options.Placement)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", i+1, err)
+		}
+		code := update.Add(pdf.CompressedStream(nil, operators))
+
+		contents, err := reader.Resolve(page.Dict["Contents"])
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", i+1, err)
+		}
+		streams := pdf.Array{save}
+		switch value := contents.(type) {
+		case pdf.Array:
+			streams = append(streams, value...)
+		case *pdf.Stream:
+			streams = append(streams, page.Dict["Contents"])
+		}
+		streams = append(streams, code)
+
+		dict := pdf.Dict{}
+		for key, value := range page.Dict {
+			dict[key] = value
+		}
+		dict["Contents"] = streams
+		update.Replace(page.Ref, dict)
+	}
+
+	return update.Bytes()
+}
+
+func drawCode(page *pdf.ExistingPage, content string, placement Placement) ([]byte, error) {
+	modules, err := (&qrcode.SimpleQRCode{Content: content}).Modules()
+	if err != nil {
+		return nil, err
+	}
+
+	box := page.CropBox
+	width, height := box[2]-box[0], box[3]-box[1]
+	if page.Rotate == 90 || page.Rotate == 270 {
+		width, height = height, width
+	}
+
+	size := placement.Size * pdf.MM
+	x, y := placement.OffsetX*pdf.MM, placement.OffsetY*pdf.MM
+	if placement.Corner == TopRight || placement.Corner == BottomRight {
+		x = width - x - size
+	}
+	if placement.Corner == TopLeft || placement.Corner == TopRight {
+		y = height - y - size
+	}
+	if x < 0 || y < 0 || x+size > width || y+size > height {
+		return nil, fmt.Errorf("a %gmm code does not fit the page at this offset", placement.Size)
+	}
+
+	canvas := &pdf.Page{}
+	canvas.Raw("Q\n")
+	canvas.SaveState()
+	// Map the displayed page, with its origin at the lower left, onto the
+	// page's own coordinates.
+	switch page.Rotate {
+	case 90:
+		canvas.Transform(0, 1, -1, 0, box[2], box[1])
+	case 180:
+		canvas.Transform(-1, 0, 0, -1, box[2], box[3])
+	case 270:
+		canvas.Transform(0, -1, 1, 0, box[0], box[3])
+	default:
+		canvas.Transform(1, 0, 0, 1, box[0], box[1])
+	}
+
+	module := size / float64(len(modules)+2*quietZone)
+	canvas.SetFillColor(1, 1, 1)
+	canvas.FillRect(x, y, size, size)
+	canvas.SetFillColor(0, 0, 0)
+	for row, line := range modules {
+		top := y + size - float64(quietZone+row+1)*module
+		// Runs of dark modules are drawn as one rectangle.
+		for col := 0; col < len(line); col++ {
+			if !line[col] {
+				continue
+			}
+			start := col
+			for col < len(line) && line[col] {
+				col++
+			}
+			canvas.Rect(x+float64(quietZone+start)*module, top, float64(col-start)*module, module)
+		}
+	}
+	canvas.Fill()
+	canvas.RestoreState()
+	return canvas.Content(), nil
+}
@@ -0,0 +1,248 @@
+package swissqr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ReferenceType string
+
+const (
+	QRReference       ReferenceType = "QRR"
+	CreditorReference ReferenceType = "SCOR"
+	NoReference       ReferenceType = "NON"
+)
+
+const maxPayloadLength = 997
+
+var currencies = map[string]bool{"CHF": true, "EUR": true}
+
+type Address struct {
+	Name           string `json:"name"`
+	Street         string `json:"street,omitempty"`
+	BuildingNumber string `json:"building_number,omitempty"`
+	PostalCode     string `json:"postal_code"`
+	Town           string `json:"town"`
+	Country        string `json:"country"`
+}
+
+type Bill struct {
+	Account            string        `json:"account"`
+	Creditor           Address       `json:"creditor"`
+	Amount             string        `json:"amount,omitempty"`
+	Currency           string        `json:"currency"`
+	Debtor             *Address      `json:"debtor,omitempty"`
+	ReferenceType      ReferenceType `json:"reference_type"`
+	Reference          string        `json:"reference,omitempty"`
+	Message            string        `json:"message,omitempty"`
+	BillInformation    string        `json:"bill_information,omitempty"`
+	AlternativeSchemes []string      `json:"alternative_schemes,omitempty"`
+	Language           string        `json:"language,omitempty"`
+}
+
+var (
+	amountPattern  = regexp.MustCompile(`^\d{1,9}(\.\d{1,2})?$`)
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+func (bill *Bill) Validate() error {
+	account := compact(bill.Account)
+	if err := validateIBAN(account); err != nil {
+		return err
+	}
+
+	if err := bill.Creditor.validate("creditor"); err != nil {
+		return err
+	}
+	if bill.Debtor != nil {
+		if err := bill.Debtor.validate("debtor"); err != nil {
+			return err
+		}
+	}
+
+	if !currencies[bill.Currency] {
+		return fmt.Errorf("currency must be CHF or EUR, got %q", bill.Currency)
+	}
+	if bill.Amount != "" {
+		if !amountPattern.MatchString(bill.Amount) {
+			return fmt.Errorf("amount %q is not a decimal with at most two places", bill.Amount)
+		}
+		amount, _ := strconv.ParseFloat(bill.Amount, 64)
+		if amount < 0.01 || amount > 999999999.99 {
+			return fmt.Errorf("amount must be between 0.01 and 999999999.99")
+		}
+	}
+
+	reference := compact(bill.Reference)
+	switch bill.ReferenceType {
+	case QRReference:
+		if !isQRIBAN(account) {
+			return fmt.Errorf("a QR reference can only be used with a QR-IBAN")
+		}
+		if err := validateQRReference(reference); err != nil {
+			return err
+		}
+	case CreditorReference:
+		if isQRIBAN(account) {
+			return fmt.Errorf("a QR-IBAN requires a QR reference")
+		}
+		if err := validateCreditorReference(reference); err != nil {
+			return err
+		}
+	case NoReference:
+		if isQRIBAN(account) {
+			return fmt.Errorf("a QR-IBAN requires a QR reference")
+		}
+		if reference != "" {
+			return fmt.Errorf("reference must be empty for reference type NON")
+		}
+	default:
+		return fmt.Errorf("reference type must be QRR, SCOR or NON, got %q", bill.ReferenceType)
+	}
+
+	if err := checkField("message", bill.Message, 140); err != nil {
+		return err
+	}
+	if err := checkField("bill information", bill.BillInformation, 140); err != nil {
+		return err
+	}
+	if len([]rune(bill.Message))+len([]rune(bill.BillInformation)) > 140 {
+		return fmt.Errorf("message and bill information together must not exceed 140 characters")
+	}
+	if len(bill.AlternativeSchemes) > 2 {
+		return fmt.Errorf("at most two alternative schemes are allowed")
+	}
+	for _, scheme := range bill.AlternativeSchemes {
+		if err := checkField("alternative scheme", scheme, 100); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := translations[bill.Language]; bill.Language != "" && !ok {
+		return fmt.Errorf("language must be one of en, de, fr or it, got %q", bill.Language)
+	}
+
+	if length := len([]rune(bill.payload())); length > maxPayloadLength {
+		return fmt.Errorf("payload is %d characters, the maximum is %d", length, maxPayloadLength)
+	}
+
+	return nil
+}
+
+func (address *Address) validate(role string) error {
+	if strings.TrimSpace(address.Name) == "" {
+		return fmt.Errorf("%s name is required", role)
+	}
+	if strings.TrimSpace(address.PostalCode) == "" || strings.TrimSpace(address.Town) == "" {
+		return fmt.Errorf("%s postal code and town are required", role)
+	}
+	if !countryPattern.MatchString(address.Country) {
+		return fmt.Errorf("%s country must be a two-letter ISO code, got %q", role, address.Country)
+	}
+
+	fields := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"name", address.Name, 70},
+		{"street", address.Street, 70},
+		{"building number", address.BuildingNumber, 16},
+		{"postal code", address.PostalCode, 16},
+		{"town", address.Town, 35},
+	}
+	for _, field := range fields {
+		if err := checkField(role+" "+field.name, field.value, field.max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bill *Bill) Payload() (string, error) {
+	if err := bill.Validate(); err != nil {
+		return "", err
+	}
+	return bill.payload(), nil
+}
+
+func (bill *Bill) payload() string {
+	lines := []string{"SPC", "0200", "1", compact(bill.Account)}
+	lines = append(lines, bill.Creditor.lines()...)
+	lines = append(lines, "", "", "", "", "", "", "")
+	lines = append(lines, bill.formattedAmount(), bill.Currency)
+	if bill.Debtor != nil {
+		lines = append(lines, bill.Debtor.lines()...)
+	} else {
+		lines = append(lines, "", "", "", "", "", "", "")
+	}
+	lines = append(
+		lines,
+		string(bill.ReferenceType), compact(bill.Reference),
+		bill.Message, "EPD",
+	)
+
+	switch {
+	case len(bill.AlternativeSchemes) > 0:
+		lines = append(lines, bill.BillInformation)
+		lines = append(lines, bill.AlternativeSchemes...)
+	case bill.BillInformation != "":
+		lines = append(lines, bill.BillInformation)
+	}
+
+	return strings.Join(lines, "\r\n")
+}
+
+func (address *Address) lines() []string {
+	return []string{
+		"S",
+		strings.TrimSpace(address.Name),
+		strings.TrimSpace(address.Street),
+		strings.TrimSpace(address.BuildingNumber),
+		strings.TrimSpace(address.PostalCode),
+		strings.TrimSpace(address.Town),
+		address.Country,
+	}
+}
+
+func (bill *Bill) formattedAmount() string {
+	if bill.Amount == "" {
+		return ""
+	}
+	amount, _ := strconv.ParseFloat(bill.Amount, 64)
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func checkField(name, value string, max int) error {
+	if length := len([]rune(value)); length > max {
+		return fmt.Errorf("%s must not exceed %d characters, got %d", name, max, length)
+	}
+	for _, char := range value {
+		if !allowedCharacter(char) {
+			return fmt.Errorf("%s contains the character %q, which is not permitted", name, char)
+		}
+	}
+	return nil
+}
+
+// The QR-bill character set is Basic Latin, Latin-1 Supplement and Latin
+// Extended-A, plus a handful of Romanian letters and the euro sign.
+func allowedCharacter(char rune) bool {
+	switch {
+	case char >= 0x20 && char <= 0x7e:
+		return true
+	case char >= 0xa0 && char <= 0x17f:
+		return true
+	case char >= 0x218 && char <= 0x21b:
+		return true
+	case char == 0x20ac:
+		return true
+	}
+	return false
+}
+
+func compact(value string) string {
+	return strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+}
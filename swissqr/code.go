@@ -0,0 +1,99 @@
+package swissqr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"qr-code-generator/qrcode"
+)
+
+// The Swiss cross is 7 mm wide on a 46 mm symbol, both measured without the
+// quiet zone.
+const (
+	symbolSizeMM = 46.0
+	crossSizeMM  = 7.0
+)
+
+func (bill *Bill) QRCode(size int) (*qrcode.SimpleQRCode, error) {
+	payload, err := bill.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return &qrcode.SimpleQRCode{Content: payload, Size: size}, nil
+}
+
+func (bill *Bill) GeneratePNG(size int) ([]byte, error) {
+	code, err := bill.QRCode(size)
+	if err != nil {
+		return nil, err
+	}
+
+	modules, err := code.Modules()
+	if err != nil {
+		return nil, err
+	}
+
+	codeData, err := code.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	qrImage, err := png.Decode(bytes.NewBuffer(codeData))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode QR code: %v", err)
+	}
+
+	bounds := qrImage.Bounds()
+	m := image.NewRGBA(bounds)
+	draw.Draw(m, bounds, qrImage, image.Point{}, draw.Src)
+
+	// The generated image includes a four module quiet zone on each side.
+	symbolWidth := float64(bounds.Dx()) * float64(len(modules)) / float64(len(modules)+8)
+	crossWidth := symbolWidth * crossSizeMM / symbolSizeMM
+	center := float64(bounds.Dx()) / 2
+
+	for _, part := range crossParts(center-crossWidth/2, center-crossWidth/2, crossWidth) {
+		rect := image.Rect(
+			int(part.x+0.5), int(part.y+0.5),
+			int(part.x+part.width+0.5), int(part.y+part.height+0.5),
+		)
+		fill := color.Color(color.Black)
+		if part.white {
+			fill = color.White
+		}
+		draw.Draw(m, rect, image.NewUniform(fill), image.Point{}, draw.Src)
+	}
+
+	withCross := bytes.NewBuffer(nil)
+	if err := png.Encode(withCross, m); err != nil {
+		return nil, fmt.Errorf("could not encode QR code: %v", err)
+	}
+
+	return withCross.Bytes(), nil
+}
+
+type rectangle struct {
+	x, y, width, height float64
+	white               bool
+}
+
+// crossParts lays out the Swiss cross as a white frame around a black square
+// carrying a white cross whose arms are 7/6 as long as they are wide.
+func crossParts(x, y, size float64) []rectangle {
+	frame := size * 0.5 / crossSizeMM
+	inner := size - 2*frame
+	arm := inner * 6 / 32
+	length := inner * 20 / 32
+	center := size / 2
+
+	return []rectangle{
+		{x, y, size, size, true},
+		{x + frame, y + frame, inner, inner, false},
+		{x + center - length/2, y + center - arm/2, length, arm, true},
+		{x + center - arm/2, y + center - length/2, arm, length, true},
+	}
+}
@@ -0,0 +1,44 @@
+package swissqr
+
+type labels struct {
+	receipt         string
+	paymentPart     string
+	account         string
+	reference       string
+	information     string
+	currency        string
+	amount          string
+	acceptancePoint string
+	payableBy       string
+	payableByBlank  string
+}
+
+var translations = map[string]labels{
+	"en": {
+		"Receipt", "Payment part", "Account / Payable to", "Reference",
+		"Additional information", "Currency", "Amount", "Acceptance point",
+		"Payable by", "Payable by (name/address)",
+	},
+	"de": {
+		"Empfangsschein", "Zahlteil", "Konto / Zahlbar an", "Referenz",
+		"Zusätzliche Informationen", "Währung", "Betrag", "Annahmestelle",
+		"Zahlbar durch", "Zahlbar durch (Name/Adresse)",
+	},
+	"fr": {
+		"Récépissé", "Section paiement", "Compte / Payable à", "Référence",
+		"Informations supplémentaires", "Monnaie", "Montant", "Point de dépôt",
+		"Payable par", "Payable par (nom/adresse)",
+	},
+	"it": {
+		"Ricevuta", "Sezione pagamento", "Conto / Pagabile a", "Riferimento",
+		"Informazioni supplementari", "Valuta", "Importo", "Punto di accettazione",
+		"Pagabile da", "Pagabile da (nome/indirizzo)",
+	},
+}
+
+func (bill *Bill) labels() labels {
+	if translation, ok := translations[bill.Language]; ok {
+		return translation
+	}
+	return translations["en"]
+}
@@ -0,0 +1,190 @@
+package swissqr
+
+import (
+	"strings"
+
+	"qr-code-generator/pdf"
+)
+
+// Dimensions of the payment slip in millimetres: a 62 mm receipt followed by
+// a 148 mm payment part, 105 mm high (A6 landscape for the payment part).
+const (
+	slipWidth        = 210.0
+	slipHeight       = 105.0
+	receiptWidth     = 62.0
+	margin           = 5.0
+	pointsToMM       = 25.4 / 72
+	receiptInfoWidth = receiptWidth - 2*margin
+	paymentInfoX     = receiptWidth + margin + symbolSizeMM + margin
+	paymentInfoWidth = slipWidth - paymentInfoX - margin
+)
+
+// canvas is implemented by the PDF and SVG renderers. Coordinates are in
+// millimetres from the top left corner and y is the text baseline.
+type canvas interface {
+	text(x, y, size float64, bold bool, value string)
+	line(x1, y1, x2, y2, width float64, dashed bool)
+	rect(x, y, width, height float64, white bool)
+	polyline(width float64, points ...[2]float64)
+}
+
+type textBlock struct {
+	c       canvas
+	x, y    float64
+	width   float64
+	heading float64
+	value   float64
+	spacing float64
+}
+
+func (block *textBlock) section(heading string, values ...string) {
+	block.y += block.heading * pointsToMM
+	block.c.text(block.x, block.y, block.heading, true, heading)
+	for _, value := range values {
+		for _, line := range pdf.WrapText(pdf.Helvetica, block.value, block.width/pointsToMM, value) {
+			block.y += block.value * pointsToMM * 1.1
+			block.c.text(block.x, block.y, block.value, false, line)
+		}
+	}
+	block.y += block.spacing
+}
+
+func (bill *Bill) draw(c canvas, modules [][]bool) {
+	text := bill.labels()
+
+	c.line(0, 0, slipWidth, 0, 0.2, true)
+	c.line(receiptWidth, 0, receiptWidth, slipHeight, 0.2, true)
+	c.text(margin, margin+11*pointsToMM, 11, true, text.receipt)
+	c.text(receiptWidth+margin, margin+11*pointsToMM, 11, true, text.paymentPart)
+
+	bill.drawReceipt(c, text)
+	bill.drawPaymentPart(c, text, modules)
+}
+
+func (bill *Bill) drawReceipt(c canvas, text labels) {
+	info := &textBlock{c: c, x: margin, y: 12, width: receiptInfoWidth, heading: 6, value: 8, spacing: 2.5}
+	info.section(text.account, append([]string{formatIBAN(bill.Account)}, bill.Creditor.display()...)...)
+	if bill.ReferenceType != NoReference {
+		info.section(text.reference, formatReference(bill.ReferenceType, bill.Reference))
+	}
+	if bill.Debtor != nil {
+		info.section(text.payableBy, bill.Debtor.display()...)
+	} else {
+		info.section(text.payableByBlank)
+		cornerMarks(c, margin, info.y-1.5, 52, 20)
+	}
+
+	bill.drawAmount(c, text, margin, 68, 6, 8)
+	if bill.Amount == "" {
+		cornerMarks(c, receiptWidth-margin-30, 68+5, 30, 10)
+	}
+
+	acceptance := text.acceptancePoint
+	width := pdf.TextWidth(pdf.HelveticaBold, 6, acceptance) * pointsToMM
+	c.text(receiptWidth-margin-width, 82+6*pointsToMM, 6, true, acceptance)
+}
+
+func (bill *Bill) drawPaymentPart(c canvas, text labels, modules [][]bool) {
+	drawSymbol(c, modules, receiptWidth+margin, 17)
+
+	bill.drawAmount(c, text, receiptWidth+margin, 68, 8, 10)
+	if bill.Amount == "" {
+		cornerMarks(c, receiptWidth+margin+11, 68+5, 40, 15)
+	}
+
+	info := &textBlock{c: c, x: paymentInfoX, y: margin, width: paymentInfoWidth, heading: 8, value: 10, spacing: 3.5}
+	info.section(text.account, append([]string{formatIBAN(bill.Account)}, bill.Creditor.display()...)...)
+	if bill.ReferenceType != NoReference {
+		info.section(text.reference, formatReference(bill.ReferenceType, bill.Reference))
+	}
+	if additional := bill.additionalInformation(); len(additional) > 0 {
+		info.section(text.information, additional...)
+	}
+	if bill.Debtor != nil {
+		info.section(text.payableBy, bill.Debtor.display()...)
+	} else {
+		info.section(text.payableByBlank)
+		cornerMarks(c, paymentInfoX, info.y-2.5, 65, 25)
+	}
+
+	y := 90.0
+	for _, scheme := range bill.AlternativeSchemes {
+		y += 7 * pointsToMM * 1.1
+		c.text(receiptWidth+margin, y, 7, false, scheme)
+	}
+}
+
+func (bill *Bill) drawAmount(c canvas, text labels, x, y, heading, value float64) {
+	amountX := x + 17
+	if heading > 6 {
+		amountX = x + 20
+	}
+	y += heading * pointsToMM
+	c.text(x, y, heading, true, text.currency)
+	c.text(amountX, y, heading, true, text.amount)
+
+	y += value * pointsToMM * 1.3
+	c.text(x, y, value, false, bill.Currency)
+	if bill.Amount != "" {
+		c.text(amountX, y, value, false, formatAmount(bill.formattedAmount()))
+	}
+}
+
+func drawSymbol(c canvas, modules [][]bool, x, y float64) {
+	module := symbolSizeMM / float64(len(modules))
+	for row, line := range modules {
+		for col := 0; col < len(line); col++ {
+			if !line[col] {
+				continue
+			}
+			run := col
+			for run < len(line) && line[run] {
+				run++
+			}
+			c.rect(x+float64(col)*module, y+float64(row)*module, float64(run-col)*module, module, false)
+			col = run
+		}
+	}
+
+	offset := (symbolSizeMM - crossSizeMM) / 2
+	for _, part := range crossParts(x+offset, y+offset, crossSizeMM) {
+		c.rect(part.x, part.y, part.width, part.height, part.white)
+	}
+}
+
+func (bill *Bill) additionalInformation() []string {
+	var lines []string
+	for _, value := range []string{bill.Message, bill.BillInformation} {
+		if strings.TrimSpace(value) != "" {
+			lines = append(lines, value)
+		}
+	}
+	return lines
+}
+
+func (address *Address) display() []string {
+	street := strings.TrimSpace(address.Street + " " + address.BuildingNumber)
+	town := strings.TrimSpace(address.PostalCode + " " + address.Town)
+	if address.Country != "CH" && address.Country != "LI" {
+		town = address.Country + " - " + town
+	}
+
+	lines := []string{address.Name}
+	if street != "" {
+		lines = append(lines, street)
+	}
+	return append(lines, town)
+}
+
+func formatAmount(amount string) string {
+	whole, cents, _ := strings.Cut(amount, ".")
+	return group(whole, 3, true) + "." + cents
+}
+
+func cornerMarks(c canvas, x, y, width, height float64) {
+	const length, stroke = 3.0, 0.75 * pointsToMM
+	c.polyline(stroke, [2]float64{x, y + length}, [2]float64{x, y}, [2]float64{x + length, y})
+	c.polyline(stroke, [2]float64{x + width - length, y}, [2]float64{x + width, y}, [2]float64{x + width, y + length})
+	c.polyline(stroke, [2]float64{x, y + height - length}, [2]float64{x, y + height}, [2]float64{x + length, y + height})
+	c.polyline(stroke, [2]float64{x + width - length, y + height}, [2]float64{x + width, y + height}, [2]float64{x + width, y + height - length})
+}
@@ -0,0 +1,94 @@
+package swissqr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The fields of a payload: the header, the account, seven for the creditor,
+// seven reserved for the ultimate creditor, the amount and currency, seven
+// for the debtor, the reference, the message and the trailer.
+const payloadFields = 31
+
+// Parse reads the payload of a scanned QR-bill. It checks the structure of
+// the payload rather than everything Validate checks, so that bills from
+// other issuers can be read even where they bend the rules. Combined
+// addresses, which carry two free lines instead of a street and a town, are
+// read into Street and Town.
+func Parse(payload string) (*Bill, error) {
+	lines := strings.Split(strings.ReplaceAll(payload, "\r\n", "\n"), "\n")
+	// Some issuers end the payload with a line break.
+	if len(lines) > payloadFields && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) < payloadFields || lines[0] != "SPC" {
+		return nil, fmt.Errorf("not a QR-bill payload")
+	}
+	if !strings.HasPrefix(lines[1], "02") {
+		return nil, fmt.Errorf("QR-bill version %q is not supported", lines[1])
+	}
+	if lines[2] != "1" {
+		return nil, fmt.Errorf("QR-bill coding type %q is not supported", lines[2])
+	}
+	if lines[30] != "EPD" {
+		return nil, fmt.Errorf("QR-bill payload has no EPD trailer")
+	}
+	if len(lines) > payloadFields+3 {
+		return nil, fmt.Errorf("QR-bill payload has %d lines, at most %d are allowed", len(lines), payloadFields+3)
+	}
+
+	bill := &Bill{
+		Account:       lines[3],
+		Amount:        lines[18],
+		Currency:      lines[19],
+		ReferenceType: ReferenceType(lines[27]),
+		Reference:     lines[28],
+		Message:       lines[29],
+	}
+	creditor, err := parseAddress(lines[4:11], "creditor")
+	if err != nil {
+		return nil, err
+	}
+	if creditor == nil {
+		return nil, fmt.Errorf("QR-bill payload has no creditor")
+	}
+	bill.Creditor = *creditor
+	if bill.Debtor, err = parseAddress(lines[20:27], "debtor"); err != nil {
+		return nil, err
+	}
+	if len(lines) > payloadFields {
+		bill.BillInformation = lines[31]
+		bill.AlternativeSchemes = lines[32:]
+	}
+	if bill.Amount != "" && !amountPattern.MatchString(bill.Amount) {
+		return nil, fmt.Errorf("amount %q is not a decimal with at most two places", bill.Amount)
+	}
+	if !currencies[bill.Currency] {
+		return nil, fmt.Errorf("currency must be CHF or EUR, got %q", bill.Currency)
+	}
+	switch bill.ReferenceType {
+	case QRReference, CreditorReference, NoReference:
+	default:
+		return nil, fmt.Errorf("reference type must be QRR, SCOR or NON, got %q", bill.ReferenceType)
+	}
+	return bill, nil
+}
+
+// parseAddress reads the seven fields of an address, returning nil when
+// they are all empty.
+func parseAddress(fields []string, role string) (*Address, error) {
+	if strings.Join(fields, "") == "" {
+		return nil, nil
+	}
+	address := &Address{Name: fields[1], Country: fields[6]}
+	switch fields[0] {
+	case "S":
+		address.Street, address.BuildingNumber = fields[2], fields[3]
+		address.PostalCode, address.Town = fields[4], fields[5]
+	case "K":
+		address.Street, address.Town = fields[2], fields[3]
+	default:
+		return nil, fmt.Errorf("%s address type must be S or K, got %q", role, fields[0])
+	}
+	return address, nil
+}
@@ -0,0 +1,111 @@
+package swissqr
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+const qrIIDLower, qrIIDUpper = 30000, 31999
+
+var (
+	ibanPattern              = regexp.MustCompile(`^(CH|LI)\d{7}[0-9A-Z]{12}$`)
+	qrReferencePattern       = regexp.MustCompile(`^\d{27}$`)
+	creditorReferencePattern = regexp.MustCompile(`^RF\d{2}[0-9A-Z]{1,21}$`)
+)
+
+func validateIBAN(iban string) error {
+	if !ibanPattern.MatchString(iban) {
+		return fmt.Errorf("account %q is not a Swiss or Liechtenstein IBAN", iban)
+	}
+	if mod97(iban[4:]+iban[:4]) != 1 {
+		return fmt.Errorf("account %q has an invalid IBAN check digit", iban)
+	}
+	return nil
+}
+
+// A QR-IBAN carries an institution ID (positions 5 to 9) in the range
+// reserved for QR payments.
+func isQRIBAN(iban string) bool {
+	if len(iban) < 9 {
+		return false
+	}
+	var iid int
+	if _, err := fmt.Sscanf(iban[4:9], "%05d", &iid); err != nil {
+		return false
+	}
+	return iid >= qrIIDLower && iid <= qrIIDUpper
+}
+
+func validateQRReference(reference string) error {
+	if !qrReferencePattern.MatchString(reference) {
+		return fmt.Errorf("QR reference must be 27 digits, got %q", reference)
+	}
+	if QRReferenceCheckDigit(reference[:26]) != reference[26] {
+		return fmt.Errorf("QR reference %q has an invalid check digit", reference)
+	}
+	return nil
+}
+
+func validateCreditorReference(reference string) error {
+	if !creditorReferencePattern.MatchString(reference) {
+		return fmt.Errorf("creditor reference must be RF, two check digits and up to 21 characters, got %q", reference)
+	}
+	if mod97(reference[4:]+reference[:4]) != 1 {
+		return fmt.Errorf("creditor reference %q has an invalid check digit", reference)
+	}
+	return nil
+}
+
+// QRReferenceCheckDigit computes the recursive modulo 10 check digit used by
+// QR and ESR references.
+func QRReferenceCheckDigit(digits string) byte {
+	table := [10]int{0, 9, 4, 6, 8, 2, 7, 1, 3, 5}
+	carry := 0
+	for _, digit := range digits {
+		carry = table[(carry+int(digit-'0'))%10]
+	}
+	return byte('0' + (10-carry)%10)
+}
+
+func mod97(value string) int {
+	var digits strings.Builder
+	for _, char := range value {
+		if char >= 'A' && char <= 'Z' {
+			fmt.Fprintf(&digits, "%d", char-'A'+10)
+			continue
+		}
+		digits.WriteRune(char)
+	}
+	number, _ := new(big.Int).SetString(digits.String(), 10)
+	return int(new(big.Int).Mod(number, big.NewInt(97)).Int64())
+}
+
+func formatIBAN(iban string) string {
+	return group(compact(iban), 4, false)
+}
+
+func formatReference(referenceType ReferenceType, reference string) string {
+	reference = compact(reference)
+	if referenceType == QRReference {
+		return group(reference, 5, true)
+	}
+	return group(reference, 4, false)
+}
+
+func group(value string, size int, fromRight bool) string {
+	var groups []string
+	if fromRight {
+		for len(value) > size {
+			groups = append([]string{value[len(value)-size:]}, groups...)
+			value = value[:len(value)-size]
+		}
+		return strings.Join(append([]string{value}, groups...), " ")
+	}
+	for len(value) > size {
+		groups = append(groups, value[:size])
+		value = value[size:]
+	}
+	return strings.Join(append(groups, value), " ")
+}
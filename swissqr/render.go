@@ -0,0 +1,178 @@
+package swissqr
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+
+	"qr-code-generator/caption"
+	"qr-code-generator/pdf"
+)
+
+func (bill *Bill) modules() ([][]bool, error) {
+	code, err := bill.QRCode(0)
+	if err != nil {
+		return nil, err
+	}
+	return code.Modules()
+}
+
+func (bill *Bill) GeneratePDF() ([]byte, error) {
+	modules, err := bill.modules()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New()
+	page := doc.AddPage(slipWidth*pdf.MM, slipHeight*pdf.MM)
+	c := &pdfCanvas{page: page}
+	bill.draw(c, modules)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return doc.Bytes()
+}
+
+func (bill *Bill) GenerateSVG() ([]byte, error) {
+	modules, err := bill.modules()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &svgCanvas{buf: bytes.NewBuffer(nil)}
+	fmt.Fprintf(
+		c.buf,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%gmm" height="%gmm" viewBox="0 0 %g %g" shape-rendering="crispEdges">`+"\n",
+		slipWidth, slipHeight, slipWidth, slipHeight,
+	)
+	fmt.Fprintf(c.buf, `<rect width="%g" height="%g" fill="#fff"/>`+"\n", slipWidth, slipHeight)
+	bill.draw(c, modules)
+	c.buf.WriteString("</svg>\n")
+
+	return c.buf.Bytes(), nil
+}
+
+type pdfCanvas struct {
+	page *pdf.Page
+	// err is the first text that could not be set.
+	err error
+}
+
+func (c *pdfCanvas) y(y float64) float64 {
+	return c.page.Height - y*pdf.MM
+}
+
+func (c *pdfCanvas) text(x, y, size float64, bold bool, value string) {
+	font := pdf.Helvetica
+	if bold {
+		font = pdf.HelveticaBold
+	}
+	c.page.SetFillColor(0, 0, 0)
+	if !pdf.WinAnsi(value) {
+		c.outlineText(x, y, size, font, value)
+		return
+	}
+	c.page.Text(x*pdf.MM, c.y(y), font, size, value)
+}
+
+// outlineText draws text the standard fonts lack, such as the Latin
+// Extended-A letters of the QR-bill character set, as outlines of the
+// bundled fonts. It is narrowed to the width Helvetica would take, which the
+// layout wraps to.
+func (c *pdfCanvas) outlineText(x, y, size float64, font pdf.Font, value string) {
+	text, err := caption.New(value, size, math.MaxInt32)
+	if err != nil {
+		if c.err == nil {
+			c.err = fmt.Errorf("could not set %q: %v", value, err)
+		}
+		return
+	}
+	scale := 1.0
+	if width := pdf.TextWidth(font, size, value); text.Width > width {
+		scale = width / text.Width
+	}
+	c.page.SaveState()
+	c.page.Transform(scale, 0, 0, 1, x*pdf.MM, c.y(y))
+	text.DrawPDF(c.page, text.Width/2, text.Ascent)
+	c.page.RestoreState()
+}
+
+func (c *pdfCanvas) line(x1, y1, x2, y2, width float64, dashed bool) {
+	c.page.SetLineWidth(width * pdf.MM)
+	if dashed {
+		c.page.SetDash(2, 2)
+	}
+	c.page.Line(x1*pdf.MM, c.y(y1), x2*pdf.MM, c.y(y2))
+	c.page.SetDash(0, 0)
+}
+
+func (c *pdfCanvas) rect(x, y, width, height float64, white bool) {
+	if white {
+		c.page.SetFillColor(1, 1, 1)
+	} else {
+		c.page.SetFillColor(0, 0, 0)
+	}
+	c.page.FillRect(x*pdf.MM, c.y(y+height), width*pdf.MM, height*pdf.MM)
+}
+
+func (c *pdfCanvas) polyline(width float64, points ...[2]float64) {
+	c.page.SetLineWidth(width * pdf.MM)
+	converted := make([][2]float64, len(points))
+	for i, point := range points {
+		converted[i] = [2]float64{point[0] * pdf.MM, c.y(point[1])}
+	}
+	c.page.Polyline(converted...)
+}
+
+type svgCanvas struct {
+	buf *bytes.Buffer
+}
+
+func (c *svgCanvas) text(x, y, size float64, bold bool, value string) {
+	weight := "normal"
+	if bold {
+		weight = "bold"
+	}
+	fmt.Fprintf(
+		c.buf,
+		`<text x="%.2f" y="%.2f" font-family="Helvetica, Arial, sans-serif" font-size="%.3f" font-weight="%s">%s</text>`+"\n",
+		x, y, size*pointsToMM, weight, html.EscapeString(value),
+	)
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2, width float64, dashed bool) {
+	dash := ""
+	if dashed {
+		dash = ` stroke-dasharray="0.7 0.7"`
+	}
+	fmt.Fprintf(
+		c.buf,
+		`<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="#000" stroke-width="%.2f"%s/>`+"\n",
+		x1, y1, x2, y2, width, dash,
+	)
+}
+
+func (c *svgCanvas) rect(x, y, width, height float64, white bool) {
+	fill := "#000"
+	if white {
+		fill = "#fff"
+	}
+	fmt.Fprintf(
+		c.buf, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" fill="%s"/>`+"\n",
+		x, y, width, height, fill,
+	)
+}
+
+func (c *svgCanvas) polyline(width float64, points ...[2]float64) {
+	c.buf.WriteString(`<polyline fill="none" stroke="#000" stroke-width="`)
+	fmt.Fprintf(c.buf, `%.3f" points="`, width)
+	for i, point := range points {
+		if i > 0 {
+			c.buf.WriteByte(' ')
+		}
+		fmt.Fprintf(c.buf, "%.2f,%.2f", point[0], point[1])
+	}
+	c.buf.WriteString(`"/>` + "\n")
+}
@@ -0,0 +1,59 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// WriteBlocks draws an image with two pixels to a character cell, as upper
+// half blocks with the upper pixel in the foreground colour and the lower
+// one in the background colour. Codes should be rendered at one pixel per
+// module. Colours are kept with truecolor; otherwise every pixel is black or
+// white, which all terminals show.
+func WriteBlocks(out io.Writer, img image.Image, truecolor bool) error {
+	bounds := img.Bounds()
+	writer := bufio.NewWriter(out)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		// Colours are only written when they change along the line.
+		foreground, background := "", ""
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var lower color.Color = color.White
+			if y+1 < bounds.Max.Y {
+				lower = img.At(x, y+1)
+			}
+			if upper := blockColour(38, img.At(x, y), truecolor); upper != foreground {
+				foreground = upper
+				writer.WriteString(upper)
+			}
+			if lower := blockColour(48, lower, truecolor); lower != background {
+				background = lower
+				writer.WriteString(lower)
+			}
+			writer.WriteString("▀")
+		}
+		writer.WriteString("\x1b[0m\n")
+	}
+	return writer.Flush()
+}
+
+// blockColour selects a foreground (38) or background (48) colour.
+func blockColour(layer int, c color.Color, truecolor bool) string {
+	r, g, b, _ := c.RGBA()
+	if truecolor {
+		return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", layer, r>>8, g>>8, b>>8)
+	}
+	// 15 and 0 are bright white and black in the 256 colour palette.
+	if color.GrayModel.Convert(c).(color.Gray).Y < 0x80 {
+		return fmt.Sprintf("\x1b[%d;5;0m", layer)
+	}
+	return fmt.Sprintf("\x1b[%d;5;15m", layer)
+}
+
+// Truecolor reports whether the terminal announces 24-bit colour.
+func Truecolor(getenv Environment) bool {
+	colorterm := getenv("COLORTERM")
+	return colorterm == "truecolor" || colorterm == "24bit"
+}
@@ -0,0 +1,106 @@
+package terminal
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Environment gives environment variables by name; os.Getenv satisfies it.
+type Environment func(name string) string
+
+// FromEnvironment recognises terminals by the variables they set. It returns
+// an empty protocol when the environment does not tell.
+func FromEnvironment(getenv Environment) Protocol {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty",
+		program == "ghostty", program == "WezTerm", getenv("KONSOLE_VERSION") != "":
+		return Kitty
+	case strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "mlterm"), strings.Contains(term, "sixel"),
+		program == "iTerm.app", getenv("WT_SESSION") != "":
+		return Sixel
+	}
+	return ""
+}
+
+// Kitty graphics support is queried with a one pixel image that is not
+// displayed; Sixel support is attribute 4 in the reply to the primary
+// device attributes request, which every terminal answers, so the reply to
+// it also ends the wait.
+const (
+	kittyQuery      = "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\"
+	attributesQuery = "\x1b[c"
+)
+
+// Detect chooses the protocol for the terminal on stdin and stdout: from the
+// environment if it is conclusive, otherwise by asking the terminal.
+// Anything that is not a terminal gets Unicode blocks.
+func Detect() Protocol {
+	if !isTerminal(os.Stdout) || !isTerminal(os.Stdin) {
+		return Blocks
+	}
+	if protocol := FromEnvironment(os.Getenv); protocol != "" {
+		return protocol
+	}
+	reply := query(kittyQuery+attributesQuery, 'c', time.Second)
+	return fromReply(reply)
+}
+
+func fromReply(reply string) Protocol {
+	if strings.Contains(reply, "\x1b_Gi=31;OK") {
+		return Kitty
+	}
+	if start := strings.LastIndex(reply, "\x1b[?"); start >= 0 {
+		attributes := strings.TrimSuffix(reply[start+3:], "c")
+		for _, attribute := range strings.Split(attributes, ";") {
+			if attribute == "4" {
+				return Sixel
+			}
+		}
+	}
+	return Blocks
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// query writes a request to the terminal and reads the reply up to the
+// final byte, with the terminal in raw mode so the reply is neither echoed
+// nor held back until a newline. Raw mode is set with stty, which exists
+// wherever these escape sequences do.
+func query(request string, final byte, timeout time.Duration) string {
+	saved, err := stty("-g")
+	if err != nil {
+		return ""
+	}
+	// Reads return after a tenth of a second without input.
+	if _, err := stty("raw", "-echo", "min", "0", "time", "1"); err != nil {
+		return ""
+	}
+	defer stty(strings.TrimSpace(saved))
+
+	os.Stdout.WriteString(request)
+	var reply bytes.Buffer
+	buffer := make([]byte, 256)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		n, _ := os.Stdin.Read(buffer)
+		reply.Write(buffer[:n])
+		if n > 0 && bytes.IndexByte(buffer[:n], final) >= 0 {
+			break
+		}
+	}
+	return reply.String()
+}
+
+func stty(args ...string) (string, error) {
+	command := exec.Command("stty", args...)
+	command.Stdin = os.Stdin
+	output, err := command.Output()
+	return string(output), err
+}
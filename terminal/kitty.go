@@ -0,0 +1,32 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// Kitty payloads are sent in chunks of at most 4096 base64 characters.
+const kittyChunk = 4096
+
+// WriteKitty displays a PNG image with the Kitty graphics protocol, which
+// Kitty, Ghostty, WezTerm and Konsole support.
+func WriteKitty(out io.Writer, png []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(png)
+	for start := 0; start < len(encoded); start += kittyChunk {
+		end := min(start+kittyChunk, len(encoded))
+		more := 0
+		if end < len(encoded) {
+			more = 1
+		}
+		control := fmt.Sprintf("m=%d", more)
+		if start == 0 {
+			control = "a=T,f=100,t=d," + control
+		}
+		if _, err := fmt.Fprintf(out, "\x1b_G%s;%s\x1b\\", control, encoded[start:end]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(out)
+	return err
+}
@@ -0,0 +1,86 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"io"
+)
+
+// WriteSixel displays an image as Sixel graphics. Images that are not
+// already paletted are reduced to the 256 colour Plan 9 palette, which has
+// exact black and white, so plain codes stay sharp.
+func WriteSixel(out io.Writer, img image.Image) error {
+	paletted, ok := img.(*image.Paletted)
+	if !ok || len(paletted.Palette) > 256 {
+		paletted = image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, img.Bounds().Min)
+	}
+	bounds := paletted.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	writer := bufio.NewWriter(out)
+	// The second parameter 1 leaves pixels of no colour untouched; the
+	// raster attributes give square pixels and the size.
+	fmt.Fprintf(writer, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for i, c := range paletted.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(writer, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	// Each band is six rows, drawn one colour at a time from the left.
+	row := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		used := map[uint8]bool{}
+		for y := top; y < min(top+6, height); y++ {
+			for x := 0; x < width; x++ {
+				used[paletted.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+y)] = true
+			}
+		}
+		first := true
+		for index := 0; index < len(paletted.Palette); index++ {
+			if !used[uint8(index)] {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && top+dy < height; dy++ {
+					if paletted.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+top+dy) == uint8(index) {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+			}
+			if !first {
+				writer.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(writer, "#%d", index)
+			writeRuns(writer, row)
+		}
+		writer.WriteByte('-')
+	}
+	writer.WriteString("\x1b\\\n")
+	return writer.Flush()
+}
+
+// writeRuns writes sixel characters with runs of four or more repeated as
+// !count.
+func writeRuns(writer *bufio.Writer, row []byte) {
+	for x := 0; x < len(row); {
+		run := 1
+		for x+run < len(row) && row[x+run] == row[x] {
+			run++
+		}
+		if run >= 4 {
+			fmt.Fprintf(writer, "!%d%c", run, row[x])
+		} else {
+			for i := 0; i < run; i++ {
+				writer.WriteByte(row[x])
+			}
+		}
+		x += run
+	}
+}
@@ -0,0 +1,22 @@
+// Package terminal shows images inline in terminals, with the Kitty graphics
+// protocol or Sixel where the terminal supports them and Unicode half blocks
+// everywhere else.
+package terminal
+
+import "fmt"
+
+type Protocol string
+
+const (
+	Kitty  Protocol = "kitty"
+	Sixel  Protocol = "sixel"
+	Blocks Protocol = "blocks"
+)
+
+func ParseProtocol(name string) (Protocol, error) {
+	switch Protocol(name) {
+	case Kitty, Sixel, Blocks:
+		return Protocol(name), nil
+	}
+	return "", fmt.Errorf("protocol must be auto, kitty, sixel or blocks, got %q", name)
+}
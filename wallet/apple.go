@@ -0,0 +1,298 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"qr-code-generator/qrcode"
+)
+
+// AppleSigner holds the Pass Type ID certificate that signs .pkpass files,
+// and Apple's WWDR intermediate certificate.
+type AppleSigner struct {
+	PassTypeIdentifier string
+	TeamIdentifier     string
+	Certificate        *x509.Certificate
+	Intermediates      []*x509.Certificate
+	Key                crypto.Signer
+}
+
+var oidUserID = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+
+// LoadAppleSigner reads PEM files. The pass type identifier and team
+// identifier are taken from the certificate subject, where Apple puts them.
+func LoadAppleSigner(certificateFile, keyFile, intermediateFile string) (*AppleSigner, error) {
+	certificates, err := readCertificates(certificateFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := readPrivateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &AppleSigner{Certificate: certificates[0], Intermediates: certificates[1:], Key: key}
+	if intermediateFile != "" {
+		intermediates, err := readCertificates(intermediateFile)
+		if err != nil {
+			return nil, err
+		}
+		signer.Intermediates = append(signer.Intermediates, intermediates...)
+	}
+
+	for _, name := range signer.Certificate.Subject.Names {
+		if value, ok := name.Value.(string); ok && name.Type.Equal(oidUserID) {
+			signer.PassTypeIdentifier = value
+		}
+	}
+	if units := signer.Certificate.Subject.OrganizationalUnit; len(units) > 0 {
+		signer.TeamIdentifier = units[0]
+	}
+	return signer, nil
+}
+
+func readCertificates(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("%s holds no PEM certificates", file)
+	}
+	return certificates, nil
+}
+
+// readPrivateKey accepts PKCS #8, PKCS #1 and SEC 1 PEM keys.
+func readPrivateKey(file string) (crypto.Signer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s holds no PEM private key", file)
+	}
+	return parsePrivateKey(block.Bytes)
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("private key cannot sign")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("private key is not PKCS #8, PKCS #1 or SEC 1")
+}
+
+var appleBarcodeFormats = map[qrcode.Symbology]string{
+	qrcode.QR:     "PKBarcodeFormatQR",
+	qrcode.Aztec:  "PKBarcodeFormatAztec",
+	qrcode.PDF417: "PKBarcodeFormatPDF417",
+}
+
+type appleField struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Value string `json:"value"`
+}
+
+type appleFields struct {
+	HeaderFields    []appleField `json:"headerFields,omitempty"`
+	PrimaryFields   []appleField `json:"primaryFields,omitempty"`
+	SecondaryFields []appleField `json:"secondaryFields,omitempty"`
+	AuxiliaryFields []appleField `json:"auxiliaryFields,omitempty"`
+	BackFields      []appleField `json:"backFields,omitempty"`
+}
+
+func toAppleFields(fields []Field) []appleField {
+	result := make([]appleField, len(fields))
+	for i, field := range fields {
+		result[i] = appleField(field)
+	}
+	return result
+}
+
+// PassJSON returns pass.json for the pass, signed by signer.
+func (pass *Pass) PassJSON(signer *AppleSigner) ([]byte, error) {
+	if err := pass.Validate(); err != nil {
+		return nil, err
+	}
+	symbology, _ := qrcode.ParseSymbology(string(pass.Barcode.Symbology))
+
+	barcode := map[string]string{
+		"format":          appleBarcodeFormats[symbology],
+		"message":         pass.Barcode.Message,
+		"messageEncoding": messageEncoding(pass.Barcode.Message),
+	}
+	if pass.Barcode.AltText != "" {
+		barcode["altText"] = pass.Barcode.AltText
+	}
+
+	document := map[string]interface{}{
+		"formatVersion":      1,
+		"passTypeIdentifier": signer.PassTypeIdentifier,
+		"teamIdentifier":     signer.TeamIdentifier,
+		"serialNumber":       pass.SerialNumber,
+		"organizationName":   pass.OrganizationName,
+		"description":        pass.Description,
+		"barcodes":           []map[string]string{barcode},
+		// Wallet before iOS 9 only reads the single barcode.
+		"barcode": barcode,
+		appleStyles[pass.style()]: appleFields{
+			HeaderFields:    toAppleFields(pass.HeaderFields),
+			PrimaryFields:   toAppleFields(pass.PrimaryFields),
+			SecondaryFields: toAppleFields(pass.SecondaryFields),
+			AuxiliaryFields: toAppleFields(pass.AuxiliaryFields),
+			BackFields:      toAppleFields(pass.BackFields),
+		},
+	}
+	for key, value := range map[string]string{
+		"logoText":        pass.LogoText,
+		"foregroundColor": rgb(pass.ForegroundColor),
+		"backgroundColor": rgb(pass.BackgroundColor),
+		"labelColor":      rgb(pass.LabelColor),
+	} {
+		if value != "" {
+			document[key] = value
+		}
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// messageEncoding names the encoding Wallet converts the barcode message
+// with: ISO 8859-1, which most scanners expect, unless the message has
+// characters outside it.
+func messageEncoding(message string) string {
+	for _, char := range message {
+		if char > 0xff {
+			return "utf-8"
+		}
+	}
+	return "iso-8859-1"
+}
+
+// ApplePass returns a signed .pkpass archive. Images are PNG files by name,
+// such as logo.png or icon@2x.png; a plain icon is added for the icon
+// sizes not given, since Wallet rejects passes without one.
+func (pass *Pass) ApplePass(signer *AppleSigner, images map[string][]byte, now time.Time) ([]byte, error) {
+	if signer.PassTypeIdentifier == "" || signer.TeamIdentifier == "" {
+		return nil, fmt.Errorf("the certificate does not name a pass type identifier and team identifier")
+	}
+
+	passJSON, err := pass.PassJSON(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{"pass.json": passJSON}
+	for name, data := range images {
+		if path.Ext(name) != ".png" || path.Base(name) != name {
+			return nil, fmt.Errorf("image %q must be a PNG file name without a directory", name)
+		}
+		files[name] = data
+	}
+	for name, size := range map[string]int{"icon.png": 29, "icon@2x.png": 58} {
+		if _, ok := files[name]; ok {
+			continue
+		}
+		if files[name], err = plainIcon(size, pass.BackgroundColor); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	manifest := map[string]string{}
+	for name, data := range files {
+		digest := sha1.Sum(data)
+		manifest[name] = hex.EncodeToString(digest[:])
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signDetached(manifestJSON, signer.Certificate, signer.Intermediates, signer.Key, now)
+	if err != nil {
+		return nil, err
+	}
+	files["manifest.json"] = manifestJSON
+	files["signature"] = signature
+	names = append(names, "manifest.json", "signature")
+
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	for _, name := range names {
+		entry, err := zipWriter.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return archive.Bytes(), nil
+}
+
+func plainIcon(size int, background string) ([]byte, error) {
+	fill := color.RGBA{0, 0, 0, 0xff}
+	if background != "" {
+		value, _ := strconv.ParseUint(background[1:], 16, 32)
+		fill = color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xff}
+	}
+
+	icon := image.NewRGBA(image.Rect(0, 0, size, size))
+	for i := 0; i < len(icon.Pix); i += 4 {
+		icon.Pix[i], icon.Pix[i+1], icon.Pix[i+2], icon.Pix[i+3] = fill.R, fill.G, fill.B, fill.A
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, icon); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,120 @@
+package wallet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"qr-code-generator/qrcode"
+)
+
+// Pass is a ticket or card for Apple Wallet and Google Wallet. The same pass
+// can be issued to both.
+type Pass struct {
+	SerialNumber     string  `json:"serial_number"`
+	OrganizationName string  `json:"organization_name"`
+	Description      string  `json:"description"`
+	Style            Style   `json:"style,omitempty"`
+	Barcode          Barcode `json:"barcode"`
+
+	LogoText        string `json:"logo_text,omitempty"`
+	ForegroundColor string `json:"foreground_color,omitempty"`
+	BackgroundColor string `json:"background_color,omitempty"`
+	LabelColor      string `json:"label_color,omitempty"`
+
+	HeaderFields    []Field `json:"header_fields,omitempty"`
+	PrimaryFields   []Field `json:"primary_fields,omitempty"`
+	SecondaryFields []Field `json:"secondary_fields,omitempty"`
+	AuxiliaryFields []Field `json:"auxiliary_fields,omitempty"`
+	BackFields      []Field `json:"back_fields,omitempty"`
+}
+
+type Barcode struct {
+	Message   string           `json:"message"`
+	Symbology qrcode.Symbology `json:"symbology,omitempty"`
+	AltText   string           `json:"alt_text,omitempty"`
+}
+
+type Field struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Value string `json:"value"`
+}
+
+type Style string
+
+const (
+	Generic     Style = "generic"
+	EventTicket Style = "event_ticket"
+	Coupon      Style = "coupon"
+	StoreCard   Style = "store_card"
+)
+
+// appleStyles are the keys of pass.json that hold the fields of each style.
+var appleStyles = map[Style]string{
+	Generic:     "generic",
+	EventTicket: "eventTicket",
+	Coupon:      "coupon",
+	StoreCard:   "storeCard",
+}
+
+var (
+	colorPattern    = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	fieldKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+)
+
+func (pass *Pass) style() Style {
+	if pass.Style == "" {
+		return Generic
+	}
+	return pass.Style
+}
+
+func (pass *Pass) Validate() error {
+	if pass.SerialNumber == "" {
+		return fmt.Errorf("serial number is required")
+	}
+	if pass.OrganizationName == "" {
+		return fmt.Errorf("organization name is required")
+	}
+	if pass.Description == "" {
+		return fmt.Errorf("description is required, it is read out by VoiceOver")
+	}
+	if _, ok := appleStyles[pass.style()]; !ok {
+		return fmt.Errorf("style must be generic, event_ticket, coupon or store_card, got %q", pass.Style)
+	}
+	if pass.Barcode.Message == "" {
+		return fmt.Errorf("barcode message is required")
+	}
+	if _, err := qrcode.ParseSymbology(string(pass.Barcode.Symbology)); err != nil {
+		return err
+	}
+	for _, color := range []string{pass.ForegroundColor, pass.BackgroundColor, pass.LabelColor} {
+		if color != "" && !colorPattern.MatchString(color) {
+			return fmt.Errorf("colors must be given as #rrggbb, got %q", color)
+		}
+	}
+
+	keys := map[string]bool{}
+	for _, fields := range [][]Field{pass.HeaderFields, pass.PrimaryFields, pass.SecondaryFields, pass.AuxiliaryFields, pass.BackFields} {
+		for _, field := range fields {
+			if !fieldKeyPattern.MatchString(field.Key) {
+				return fmt.Errorf("field key %q must be letters, digits, dashes and underscores", field.Key)
+			}
+			if keys[field.Key] {
+				return fmt.Errorf("field key %q is used more than once", field.Key)
+			}
+			keys[field.Key] = true
+		}
+	}
+	return nil
+}
+
+// rgb converts #rrggbb into the rgb(r, g, b) form used by pass.json.
+func rgb(color string) string {
+	if color == "" {
+		return ""
+	}
+	value, _ := strconv.ParseUint(color[1:], 16, 32)
+	return fmt.Sprintf("rgb(%d, %d, %d)", value>>16, value>>8&0xff, value&0xff)
+}
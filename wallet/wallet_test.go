@@ -0,0 +1,380 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func testPass() *Pass {
+	return &Pass{
+		SerialNumber:     "TICKET-0001",
+		OrganizationName: "Example Theatre",
+		Description:      "Evening performance",
+		Style:            EventTicket,
+		Barcode:          Barcode{Message: "https://example.com/t/0001", AltText: "0001"},
+		BackgroundColor:  "#1a2b3c",
+		PrimaryFields:    []Field{{Key: "event", Label: "Event", Value: "Hamlet"}},
+		SecondaryFields:  []Field{{Key: "seat", Label: "Seat", Value: "F12"}},
+	}
+}
+
+// writePEM writes blocks to a file in the test's directory.
+func writePEM(t *testing.T, name string, blocks ...*pem.Block) string {
+	t.Helper()
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(block)...)
+	}
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// testSigner makes a self-signed Pass Type ID certificate for key and loads
+// it as Apple's would be.
+func testSigner(t *testing.T, key crypto.Signer) *AppleSigner {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject: pkix.Name{
+			CommonName:         "Pass Type ID: pass.com.example.ticket",
+			OrganizationalUnit: []string{"ABCDE12345"},
+			ExtraNames:         []pkix.AttributeTypeAndValue{{Type: oidUserID, Value: "pass.com.example.ticket"}},
+		},
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := LoadAppleSigner(
+		writePEM(t, "certificate.pem", &pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		writePEM(t, "key.pem", &pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		"",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.PassTypeIdentifier != "pass.com.example.ticket" || signer.TeamIdentifier != "ABCDE12345" {
+		t.Fatalf("signer is for %q of team %q", signer.PassTypeIdentifier, signer.TeamIdentifier)
+	}
+	return signer
+}
+
+func unzip(t *testing.T, archive []byte) map[string][]byte {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, file := range reader.File {
+		opened, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name], err = io.ReadAll(opened)
+		opened.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+// verifyDetached checks a detached signature of content: the message digest
+// attribute and the signature over the attributes, with the certificate the
+// signature carries.
+func verifyDetached(t *testing.T, signature, content []byte) {
+	t.Helper()
+	var outer contentInfo
+	if rest, err := asn1.Unmarshal(signature, &outer); err != nil || len(rest) > 0 {
+		t.Fatalf("signature is not a ContentInfo: %v", err)
+	}
+	if !outer.ContentType.Equal(oidSignedData) {
+		t.Fatalf("content type is %v, expected signed data", outer.ContentType)
+	}
+	var data signedData
+	if _, err := asn1.Unmarshal(outer.Content.Bytes, &data); err != nil {
+		t.Fatalf("could not read the signed data: %v", err)
+	}
+	if len(data.ContentInfo.Content.Bytes) > 0 {
+		t.Errorf("the signature is not detached")
+	}
+	certificates, err := x509.ParseCertificates(data.Certificates.Bytes)
+	if err != nil || len(certificates) == 0 {
+		t.Fatalf("could not read the certificates: %v", err)
+	}
+	if len(data.SignerInfos) != 1 {
+		t.Fatalf("signature has %d signers", len(data.SignerInfos))
+	}
+	info := data.SignerInfos[0]
+	if info.IssuerAndSerialNumber.SerialNumber.Cmp(certificates[0].SerialNumber) != 0 {
+		t.Errorf("signer is not the certificate's")
+	}
+
+	var attributes []attribute
+	if _, err := asn1.UnmarshalWithParams(info.AuthenticatedAttributes.FullBytes, &attributes, "set,tag:0"); err != nil {
+		t.Fatalf("could not read the signed attributes: %v", err)
+	}
+	digest := sha256.Sum256(content)
+	found := false
+	for _, attr := range attributes {
+		if attr.Type.Equal(oidMessageDigest) {
+			var value []byte
+			asn1.Unmarshal(attr.Values[0].FullBytes, &value)
+			found = bytes.Equal(value, digest[:])
+		}
+	}
+	if !found {
+		t.Errorf("the message digest attribute does not match the content")
+	}
+
+	set, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: info.AuthenticatedAttributes.Bytes})
+	signed := sha256.Sum256(set)
+	switch key := certificates[0].PublicKey.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA256, signed[:], info.EncryptedDigest)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, signed[:], info.EncryptedDigest) {
+			err = errors.New("ecdsa: verification error")
+		}
+	}
+	if err != nil {
+		t.Errorf("the signature does not verify: %v", err)
+	}
+}
+
+func TestApplePass(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, key := range map[string]crypto.Signer{"rsa": rsaKey, "ecdsa": ecdsaKey} {
+		t.Run(name, func(t *testing.T) {
+			signer := testSigner(t, key)
+			archive, err := testPass().ApplePass(signer, map[string][]byte{"logo.png": []byte("not really a PNG")}, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := unzip(t, archive)
+
+			var manifest map[string]string
+			if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+				t.Fatalf("could not read manifest.json: %v", err)
+			}
+			for _, name := range []string{"pass.json", "logo.png", "icon.png", "icon@2x.png"} {
+				if _, ok := manifest[name]; !ok {
+					t.Errorf("manifest.json does not list %s", name)
+				}
+			}
+			for name, hash := range manifest {
+				digest := sha1.Sum(files[name])
+				if hash != hex.EncodeToString(digest[:]) {
+					t.Errorf("manifest.json has the wrong hash for %s", name)
+				}
+			}
+			if len(manifest) != len(files)-2 {
+				t.Errorf("manifest.json lists %d of the %d files", len(manifest), len(files)-2)
+			}
+			verifyDetached(t, files["signature"], files["manifest.json"])
+
+			var document map[string]interface{}
+			if err := json.Unmarshal(files["pass.json"], &document); err != nil {
+				t.Fatal(err)
+			}
+			if document["passTypeIdentifier"] != signer.PassTypeIdentifier || document["teamIdentifier"] != signer.TeamIdentifier {
+				t.Errorf("pass.json is for %v of team %v", document["passTypeIdentifier"], document["teamIdentifier"])
+			}
+			if _, ok := document["eventTicket"]; !ok {
+				t.Errorf("pass.json has no eventTicket fields")
+			}
+			if document["backgroundColor"] != "rgb(26, 43, 60)" {
+				t.Errorf("background color is %v", document["backgroundColor"])
+			}
+		})
+	}
+}
+
+func TestApplePassImages(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	icon := []byte("the caller's icon")
+	archive, err := testPass().ApplePass(testSigner(t, key), map[string][]byte{"icon@2x.png": icon}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := unzip(t, archive)
+	if !bytes.Equal(files["icon@2x.png"], icon) {
+		t.Errorf("icon@2x.png was replaced with a plain icon")
+	}
+	if _, ok := files["icon.png"]; !ok {
+		t.Errorf("no icon.png was added")
+	}
+}
+
+func TestMessageEncoding(t *testing.T) {
+	for message, want := range map[string]string{
+		"https://example.com/t/0001": "iso-8859-1",
+		"Café Zürich":                "iso-8859-1",
+		"Łódź 2024":                  "utf-8",
+		"チケット":                       "utf-8",
+	} {
+		pass := testPass()
+		pass.Barcode.Message = message
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := pass.PassJSON(testSigner(t, key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var document struct {
+			Barcode struct {
+				Message         string `json:"message"`
+				MessageEncoding string `json:"messageEncoding"`
+			} `json:"barcode"`
+		}
+		if err := json.Unmarshal(data, &document); err != nil {
+			t.Fatal(err)
+		}
+		if document.Barcode.Message != message || document.Barcode.MessageEncoding != want {
+			t.Errorf("%q is encoded as %s, expected %s", message, document.Barcode.MessageEncoding, want)
+		}
+	}
+}
+
+func TestApplePassRejectsImagePaths(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testPass().ApplePass(testSigner(t, key), map[string][]byte{"../logo.png": nil}, now); err == nil {
+		t.Errorf("an image path with a directory should be rejected")
+	}
+}
+
+func TestGoogleSaveJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "wallet@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "service-account.json")
+	if err := os.WriteFile(file, keyFile, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	account, err := LoadGoogleServiceAccount(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	object, err := testPass().GoogleObject("3388000000012345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.ID != "3388000000012345678.TICKET-0001" || object.ClassID != "3388000000012345678.event_ticket" {
+		t.Errorf("object %q has class %q", object.ID, object.ClassID)
+	}
+	token, err := account.GoogleSaveJWT(object, []string{"https://example.com"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token has %d parts", len(parts))
+	}
+	decoded := make([][]byte, 3)
+	for i, part := range parts {
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			t.Fatalf("part %d is not base64url: %v", i+1, err)
+		}
+	}
+	var header map[string]string
+	if err := json.Unmarshal(decoded[0], &header); err != nil || header["alg"] != "RS256" || header["typ"] != "JWT" {
+		t.Errorf("header is %s", decoded[0])
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], decoded[2]); err != nil {
+		t.Errorf("the signature does not verify: %v", err)
+	}
+
+	var claims struct {
+		Issuer   string   `json:"iss"`
+		Audience string   `json:"aud"`
+		Type     string   `json:"typ"`
+		IssuedAt int64    `json:"iat"`
+		Origins  []string `json:"origins"`
+		Payload  struct {
+			GenericClasses []struct {
+				ID string `json:"id"`
+			} `json:"genericClasses"`
+			GenericObjects []GoogleObject `json:"genericObjects"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(decoded[1], &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != "wallet@example.iam.gserviceaccount.com" || claims.Audience != "google" || claims.Type != "savetowallet" {
+		t.Errorf("claims are iss %q, aud %q and typ %q", claims.Issuer, claims.Audience, claims.Type)
+	}
+	if claims.IssuedAt != now.Unix() || len(claims.Origins) != 1 || claims.Origins[0] != "https://example.com" {
+		t.Errorf("claims are iat %d and origins %v", claims.IssuedAt, claims.Origins)
+	}
+	if len(claims.Payload.GenericClasses) != 1 || claims.Payload.GenericClasses[0].ID != object.ClassID {
+		t.Errorf("payload classes are %+v", claims.Payload.GenericClasses)
+	}
+	if objects := claims.Payload.GenericObjects; len(objects) != 1 || objects[0].ID != object.ID || objects[0].Barcode.Value != "https://example.com/t/0001" {
+		t.Errorf("payload objects are %+v", objects)
+	}
+}